@@ -1,16 +1,26 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	_ "time/tzdata" // store timezones must resolve on hosts without zoneinfo
 
+	"simple-template/internal/channel"
 	"simple-template/internal/config"
 	"simple-template/internal/database"
 	"simple-template/internal/handler"
+	"simple-template/internal/logging"
+	"simple-template/internal/mailer"
 	"simple-template/internal/middleware"
 	"simple-template/internal/repository"
 	"simple-template/internal/usecase"
 	"simple-template/pkg/response"
+	"simple-template/pkg/traceparent"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -18,12 +28,25 @@ import (
 )
 
 func main() {
+	printOpenAPI := flag.Bool("openapi", false, "print the OpenAPI document and exit")
+	checkOpenAPI := flag.Bool("openapi-check", false, "exit with an error when the routes and the OpenAPI operations drift apart")
+	flag.Parse()
+	if *printOpenAPI || *checkOpenAPI {
+		if err := runOpenAPI(*checkOpenAPI); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// JSON logs, the log package writes through them too
+	slog.SetDefault(logging.New(os.Stdout, cfg.Server.LogLevel))
+
 	// Connect to database
 	db, err := database.Connect(database.Config{
 		Host:            cfg.Database.Host,
@@ -46,31 +69,141 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	productRepo := repository.NewProductRepository(db)
 	customerRepo := repository.NewCustomerRepository(db)
+	customerAddressRepo := repository.NewCustomerAddressRepository(db)
 	platformRepo := repository.NewPlatformRepository(db)
 	retailStoreRepo := repository.NewRetailStoreRepository(db)
 	paymentMethodsRepo := repository.NewPaymentMethodsRepository(db)
 	ordersRepo := repository.NewOrdersRepository(db)
+	channelRepo := repository.NewChannelRepository(db)
+	posRepo := repository.NewPosRepository(db)
+	cashShiftRepo := repository.NewCashShiftRepository(db)
+	loyaltyRepo := repository.NewLoyaltyRepository(db)
+	segmentRepo := repository.NewSegmentRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	customerNoteRepo := repository.NewCustomerNoteRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+
+	// Outbound emails
+	mail, err := mailer.New(mailer.Config{
+		Driver:    cfg.Mail.Driver,
+		From:      cfg.Mail.From,
+		OutboxDir: cfg.Mail.OutboxDir,
+		SMTP: mailer.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			Timeout:  cfg.Mail.SMTPTimeout,
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up mailer: %v", err)
+	}
 
 	// Initialize usecases
-	userUsecase := usecase.NewUserUsecase(userRepo)
-	productUsecase := usecase.NewProductUsecase(productRepo)
-	customerUsecase := usecase.NewCustomerUsecase(customerRepo)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
+	userUsecase := usecase.NewUserUsecase(userRepo, auditUsecase)
+	authUsecase := usecase.NewAuthUsecase(userRepo, refreshTokenRepo, retailStoreRepo, apiKeyRepo, twoFactorRepo, usecase.AuthSettings{
+		JWTSecret:                []byte(cfg.Auth.JWTSecret),
+		Issuer:                   cfg.Auth.Issuer,
+		AccessTokenTTL:           cfg.Auth.AccessTokenTTL,
+		RefreshTokenTTL:          cfg.Auth.RefreshTokenTTL,
+		RequireTwoFactorForAdmin: cfg.Auth.RequireTwoFactorForAdmin,
+	})
+	twoFactorUsecase := usecase.NewTwoFactorUsecase(userRepo, twoFactorRepo, usecase.TwoFactorSettings{
+		Issuer:           cfg.Auth.TOTPIssuer,
+		RequiredForAdmin: cfg.Auth.RequireTwoFactorForAdmin,
+	})
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(apiKeyRepo, userRepo)
+	accountUsecase := usecase.NewAccountUsecase(userRepo, userTokenRepo, refreshTokenRepo, auditUsecase, mail, usecase.AccountSettings{
+		AppURL:               cfg.Mail.AppURL,
+		InvitationTTL:        cfg.Auth.InvitationTTL,
+		EmailVerificationTTL: cfg.Auth.EmailVerificationTTL,
+		PasswordResetTTL:     cfg.Auth.PasswordResetTTL,
+	})
+	if err := authUsecase.Bootstrap(context.Background(), cfg.Auth.AdminEmail, cfg.Auth.AdminPassword); err != nil {
+		log.Fatalf("Failed to bootstrap admin user: %v", err)
+	}
+	productUsecase := usecase.NewProductUsecase(productRepo, auditUsecase)
+	loyaltyUsecase := usecase.NewLoyaltyUsecase(loyaltyRepo, ordersRepo, customerRepo)
+	customerUsecase := usecase.NewCustomerUsecase(
+		customerRepo,
+		ordersRepo,
+		customerAddressRepo,
+		tagRepo,
+		customerNoteRepo,
+		loyaltyUsecase,
+		auditUsecase,
+	)
+	customerAddressUsecase := usecase.NewCustomerAddressUsecase(customerAddressRepo, customerRepo)
+	segmentUsecase := usecase.NewSegmentUsecase(segmentRepo, platformRepo)
+	tagUsecase := usecase.NewTagUsecase(tagRepo, customerRepo)
+	customerNoteUsecase := usecase.NewCustomerNoteUsecase(customerNoteRepo, customerRepo, userRepo)
 	platformUsecase := usecase.NewPlatformUsecase(platformRepo)
-	retailStoreUsecase := usecase.NewRetailStoreUsecase(retailStoreRepo)
-	paymentMethodsUsecase := usecase.NewPaymentMethodsUsecase(paymentMethodsRepo)
-	ordersUsecase := usecase.NewOrderUseCase(ordersRepo)
+	retailStoreUsecase := usecase.NewRetailStoreUsecase(retailStoreRepo, userRepo)
+	paymentMethodsUsecase := usecase.NewPaymentMethodsUsecase(paymentMethodsRepo, platformRepo, retailStoreRepo)
+	ordersUsecase := usecase.NewOrderUseCase(ordersRepo, platformRepo, retailStoreRepo, paymentMethodsRepo, customerAddressRepo, loyaltyUsecase, auditUsecase)
+	channelSyncUsecase := usecase.NewChannelSyncUsecase(
+		channelRepo,
+		platformRepo,
+		customerRepo,
+		customerUsecase,
+		ordersUsecase,
+		channel.DefaultRegistry(),
+		channelSettings(cfg.Channel),
+	)
+
+	posUsecase := usecase.NewPosUsecase(
+		posRepo,
+		ordersUsecase,
+		platformRepo,
+		retailStoreRepo,
+		paymentMethodsRepo,
+		customerRepo,
+		loyaltyUsecase,
+	)
+	cashShiftUsecase := usecase.NewCashShiftUsecase(
+		cashShiftRepo,
+		retailStoreRepo,
+		userRepo,
+		platformRepo,
+		paymentMethodsRepo,
+	)
 
 	// Initialize handlers
-	userHandler := handler.NewUserHandler(userUsecase)
-	productHandler := handler.NewProductHandler(productUsecase)
-	customerHandler := handler.NewCustomerHandler(customerUsecase)
-	platformHandler := handler.NewPlatformHandler(platformUsecase)
-	retailStoreHandler := handler.NewRetailStoreHandler(retailStoreUsecase)
-	paymentMethodsHandler := handler.NewPaymentMethodsHandler(paymentMethodsUsecase)
-	ordersHandler := handler.NewOrderHandler(ordersUsecase)
+	h := &routeHandlers{
+		user:            handler.NewUserHandler(userUsecase),
+		auth:            handler.NewAuthHandler(authUsecase),
+		apiKey:          handler.NewAPIKeyHandler(apiKeyUsecase),
+		account:         handler.NewAccountHandler(accountUsecase),
+		twoFactor:       handler.NewTwoFactorHandler(twoFactorUsecase),
+		product:         handler.NewProductHandler(productUsecase),
+		customer:        handler.NewCustomerHandler(customerUsecase),
+		customerAddress: handler.NewCustomerAddressHandler(customerAddressUsecase),
+		platform:        handler.NewPlatformHandler(platformUsecase),
+		retailStore:     handler.NewRetailStoreHandler(retailStoreUsecase),
+		paymentMethods:  handler.NewPaymentMethodsHandler(paymentMethodsUsecase),
+		orders:          handler.NewOrderHandler(ordersUsecase),
+		channel:         handler.NewChannelHandler(channelSyncUsecase),
+		pos:             handler.NewPosHandler(posUsecase),
+		cashShift:       handler.NewCashShiftHandler(cashShiftUsecase),
+		loyalty:         handler.NewLoyaltyHandler(loyaltyUsecase),
+		segment:         handler.NewSegmentHandler(segmentUsecase),
+		tag:             handler.NewTagHandler(tagUsecase),
+		customerNote:    handler.NewCustomerNoteHandler(customerNoteUsecase),
+		audit:           handler.NewAuditHandler(auditUsecase),
+		authUsecase:     authUsecase,
+	}
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		AppName: "Simple Golang API",
+		// The banner would break the JSON lines of the logs
+		DisableStartupMessage: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			return response.InternalServerError(c, "Internal server error", err)
 		},
@@ -79,57 +212,16 @@ func main() {
 	// Middleware
 	app.Use(recover.New())             // Recover from panics
 	app.Use(cors.New())                // Enable CORS
+	app.Use(middleware.RequestID())    // Request ID, echoed in the X-Request-ID header
+	app.Use(middleware.Trace())        // W3C trace of the traceparent header
+	app.Use(middleware.Locale())       // Language of the messages, from Accept-Language
 	app.Use(middleware.Logger())       // Custom logger
 	app.Use(middleware.ErrorHandler()) // Custom error handler
 
-	// Health check endpoint
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return response.Success(c, fiber.Map{
-			"status":   "ok",
-			"database": "connected",
-		}, "Service is healthy")
-	})
-
-	// API routes
-	api := app.Group("/api/v1")
-
-	// User routes
-	users := api.Group("/users")
-	users.Post("/", userHandler.CreateUser)      // Create new user
-	users.Get("/", userHandler.GetAllUsers)      // Get list of users
-	users.Get("/:id", userHandler.GetUser)       // Get user by ID
-	users.Put("/:id", userHandler.UpdateUser)    // Update user
-	users.Delete("/:id", userHandler.DeleteUser) // Delete user
-
-	product := api.Group("/products")
-	product.Get("/", productHandler.GetAll)
-
-	product.Get("/:id", productHandler.GetByID)
-	product.Post("/", productHandler.CreateProduct)
-	product.Delete("/:id", productHandler.DeleteProduct)
-
-	// Customer
-	customer := api.Group("/customer")
-	customer.Post("/", customerHandler.Create)
-	customer.Get("/", customerHandler.GetAllCustomers)
-	customer.Get("/:id", customerHandler.GetCustomer)
-	customer.Put("/:id", customerHandler.UpdateCustomers)
-	customer.Delete("/:id", customerHandler.DeleteCustomer)
-
-	// platform
-	platform := api.Group("/platform")
-	platform.Get("/", platformHandler.GetAll)
-	// retail store
-	retailStore := api.Group("/retail-store")
-	retailStore.Get("/", retailStoreHandler.GetAll)
-	// payment methods
-	paymentMethods := api.Group("/payment-methods")
-	paymentMethods.Get("/", paymentMethodsHandler.GetAll)
-	// orders
-	orders := api.Group("/orders")
-	orders.Get("/", ordersHandler.GetAll)
-	orders.Post("/", ordersHandler.Create)
-	orders.Put("/:id", ordersHandler.UpdateStatus)
+	registerRoutes(app, h)
+	if err := registerDocs(app); err != nil {
+		log.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
 
 	// Start server
 	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
@@ -138,3 +230,21 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// channelSettings converts the channel config into the settings shared by the marketplace adapters
+func channelSettings(cfg config.ChannelConfig) channel.Settings {
+	credentials := make(map[string]channel.Credentials, len(cfg.Credentials))
+	for ref, c := range cfg.Credentials {
+		credentials[ref] = channel.Credentials{
+			AppKey:      c.AppKey,
+			AppSecret:   c.AppSecret,
+			AccessToken: c.AccessToken,
+			ShopID:      c.ShopID,
+		}
+	}
+	return channel.Settings{
+		Credentials: credentials,
+		HTTPClient:  &http.Client{Timeout: cfg.HTTPTimeout, Transport: traceparent.Transport(nil)},
+		Currency:    cfg.Currency,
+	}
+}
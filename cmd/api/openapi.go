@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"simple-template/internal/model"
+	"simple-template/pkg/openapi"
+	"simple-template/pkg/pagination"
+	"simple-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// apiSpec documents every route of registerRoutes, `make openapi-check` fails when they drift apart
+var apiSpec = openapi.Spec{
+	Info: openapi.Info{
+		Title:       "Simple Golang API",
+		Version:     "1.0.0",
+		Description: "Every protected route accepts an access token or an API key, messages follow the Accept-Language header (en, vi)",
+	},
+	Envelope: response.Response{},
+	Security: map[string]*openapi.SecurityScheme{
+		"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+	},
+	Operations: []openapi.Operation{
+		{Method: fiber.MethodGet, Path: "/health", Tag: "health", Summary: "Health check", Public: true, Data: map[string]string{}},
+
+		// Auth
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/login", Tag: "auth", Summary: "Log in", Public: true,
+			Description: "Users with two-factor authentication get a challenge token to complete with /auth/login/2fa",
+			Body:        model.LoginRequest{}, Data: model.LoginResponse{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/login/2fa", Tag: "auth", Summary: "Complete a two-factor login", Public: true,
+			Body: model.LoginTwoFactorRequest{}, Data: model.TokenPair{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/refresh", Tag: "auth", Summary: "Rotate a refresh token", Public: true,
+			Body: model.RefreshTokenRequest{}, Data: model.TokenPair{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/invitation/accept", Tag: "auth", Summary: "Accept an invitation", Public: true,
+			Body: model.AcceptInvitationRequest{}, Data: model.User{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/email/verify", Tag: "auth", Summary: "Verify an email address", Public: true,
+			Body: model.VerifyEmailRequest{}, Data: model.User{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/password/forgot", Tag: "auth", Summary: "Email a password reset link", Public: true,
+			Body: model.ForgotPasswordRequest{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/password/reset", Tag: "auth", Summary: "Reset a password", Public: true,
+			Body: model.ResetPasswordRequest{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/logout", Tag: "auth", Summary: "Log out", Body: model.RefreshTokenRequest{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/logout-all", Tag: "auth", Summary: "Log out of every session"},
+		{Method: fiber.MethodGet, Path: "/api/v1/auth/me", Tag: "auth", Summary: "Get the signed in user", Data: model.CurrentUser{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/auth/password", Tag: "auth", Summary: "Change the password", Body: model.ChangePasswordRequest{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/email/verification", Tag: "auth", Summary: "Email a verification link"},
+
+		// Two-factor authentication
+		{Method: fiber.MethodGet, Path: "/api/v1/auth/2fa", Tag: "two-factor", Summary: "Get the two-factor status", Data: model.TwoFactorStatus{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/2fa/setup", Tag: "two-factor", Summary: "Generate a two-factor secret", Data: model.TwoFactorSetup{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/2fa/enable", Tag: "two-factor", Summary: "Enable two-factor authentication",
+			Body: model.TwoFactorCodeRequest{}, Data: model.RecoveryCodes{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/2fa/disable", Tag: "two-factor", Summary: "Disable two-factor authentication",
+			Body: model.DisableTwoFactorRequest{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/auth/2fa/recovery-codes", Tag: "two-factor", Summary: "Regenerate the recovery codes",
+			Body: model.TwoFactorCodeRequest{}, Data: model.RecoveryCodes{}},
+
+		// API keys
+		{Method: fiber.MethodGet, Path: "/api/v1/api-keys/", Tag: "api-keys", Summary: "List my API keys", Data: []*model.APIKey{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/api-keys/", Tag: "api-keys", Summary: "Create an API key",
+			Description: "The key is only in this response", Body: model.CreateAPIKeyRequest{}, Data: model.CreatedAPIKey{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodDelete, Path: "/api/v1/api-keys/:id", Tag: "api-keys", Summary: "Revoke one of my API keys"},
+
+		// Users
+		{Method: fiber.MethodPost, Path: "/api/v1/users/", Tag: "users", Summary: "Create a user",
+			Body: model.CreateUserRequest{}, Data: model.User{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodPost, Path: "/api/v1/users/invite", Tag: "users", Summary: "Invite a user by email",
+			Body: model.InviteUserRequest{}, Data: model.User{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/users/", Tag: "users", Summary: "List users", Data: []*model.User{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/users/roles", Tag: "users", Summary: "List the roles and their permissions", Data: []*model.RoleDefinition{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/users/:id", Tag: "users", Summary: "Get a user", Data: model.User{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/users/:id", Tag: "users", Summary: "Update a user", Body: model.UpdateUserRequest{}, Data: model.User{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/users/:id/role", Tag: "users", Summary: "Set the role of a user in every store",
+			Body: model.SetUserRoleRequest{}, Data: model.User{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/users/:id/invitation", Tag: "users", Summary: "Resend an invitation"},
+		{Method: fiber.MethodDelete, Path: "/api/v1/users/:id/2fa", Tag: "users", Summary: "Reset the two-factor authentication of a user"},
+		{Method: fiber.MethodGet, Path: "/api/v1/users/:id/api-keys", Tag: "users", Summary: "List the API keys of a user", Data: []*model.APIKey{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/users/:id/api-keys/:key_id", Tag: "users", Summary: "Revoke an API key of a user"},
+		{Method: fiber.MethodDelete, Path: "/api/v1/users/:id", Tag: "users", Summary: "Delete a user"},
+
+		// Products
+		{Method: fiber.MethodGet, Path: "/api/v1/products/", Tag: "products", Summary: "List products",
+			Query: []interface{}{pagination.Request{}}, Data: pagination.Response{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/products/:id", Tag: "products", Summary: "Get a product", Data: model.Product{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/products/", Tag: "products", Summary: "Create a product",
+			Body: model.CreateProductRequest{}, Data: model.Product{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodDelete, Path: "/api/v1/products/:id", Tag: "products", Summary: "Delete a product"},
+
+		// Customers
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/", Tag: "customers", Summary: "Create a customer",
+			Body: model.CreateCustomerRequest{}, Data: model.Customer{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/", Tag: "customers", Summary: "List customers",
+			Query: []interface{}{pagination.Request{}},
+			Params: []*openapi.Parameter{
+				openapi.QueryParam("search", "string", "Matches the name, phone number or email"),
+				openapi.QueryParam("tag_ids", "string", "Comma separated tag ids, customers with every one of them"),
+			},
+			Data: pagination.Response{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id", Tag: "customers", Summary: "Get a customer", Data: model.Customer{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/orders", Tag: "customers", Summary: "Get the order history of a customer",
+			Query: []interface{}{pagination.Request{}}, Data: model.CustomerOrderHistory{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/duplicates", Tag: "customers", Summary: "Find likely duplicates of a customer",
+			Params: []*openapi.Parameter{openapi.QueryParam("min_score", "number", "Lowest similarity score, 0.4 by default")},
+			Data:   []*model.CustomerDuplicate{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/:id/merge", Tag: "customers", Summary: "Merge customers into this one",
+			Body: model.MergeCustomersRequest{}, Data: model.CustomerMergeResult{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/export", Tag: "customers", Summary: "Export everything held about a customer",
+			Data: model.CustomerExport{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/:id/anonymize", Tag: "customers", Summary: "Anonymize a customer", Data: model.Customer{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/loyalty", Tag: "customers", Summary: "Get the loyalty balance of a customer",
+			Data: model.CustomerLoyalty{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/loyalty/ledger", Tag: "customers", Summary: "List the loyalty points ledger of a customer",
+			Query: []interface{}{pagination.Request{}}, Data: pagination.Response{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/:id/tags/:tag_id", Tag: "customers", Summary: "Tag a customer", Data: []*model.Tag{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/customer/:id/tags/:tag_id", Tag: "customers", Summary: "Untag a customer", Data: []*model.Tag{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/notes", Tag: "customers", Summary: "List the timeline of a customer",
+			Query: []interface{}{pagination.Request{}}, Data: pagination.Response{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/:id/notes", Tag: "customers", Summary: "Add a note to a customer",
+			Body: model.CreateCustomerNoteRequest{}, Data: model.CustomerNote{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/customer/:id/addresses", Tag: "customers", Summary: "List the addresses of a customer",
+			Data: []*model.CustomerAddress{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/customer/:id/addresses", Tag: "customers", Summary: "Add an address to a customer",
+			Body: model.CreateCustomerAddressRequest{}, Data: model.CustomerAddress{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodPut, Path: "/api/v1/customer/:id/addresses/:address_id", Tag: "customers", Summary: "Update an address of a customer",
+			Body: model.UpdateCustomerAddressRequest{}, Data: model.CustomerAddress{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/customer/:id/addresses/:address_id", Tag: "customers", Summary: "Delete an address of a customer"},
+		{Method: fiber.MethodPut, Path: "/api/v1/customer/:id", Tag: "customers", Summary: "Update a customer",
+			Body: model.UpdateCustomerRequest{}, Data: model.Customer{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/customer/:id", Tag: "customers", Summary: "Delete a customer"},
+
+		// Platforms
+		{Method: fiber.MethodPost, Path: "/api/v1/platform/", Tag: "platforms", Summary: "Create a platform",
+			Body: model.CreatePlatformRequest{}, Data: model.Platform{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/platform/", Tag: "platforms", Summary: "List platforms", Data: []*model.Platform{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/platform/:id", Tag: "platforms", Summary: "Get a platform", Data: model.Platform{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/platform/:id", Tag: "platforms", Summary: "Update a platform",
+			Body: model.UpdatePlatformRequest{}, Data: model.Platform{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/platform/:id", Tag: "platforms", Summary: "Delete a platform"},
+
+		// Retail stores
+		{Method: fiber.MethodPost, Path: "/api/v1/retail-store/", Tag: "retail-stores", Summary: "Create a retail store",
+			Body: model.CreateRetailStoreRequest{}, Data: model.RetailStore{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/retail-store/", Tag: "retail-stores", Summary: "List retail stores", Data: []*model.RetailStore{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/retail-store/:id", Tag: "retail-stores", Summary: "Get a retail store", Data: model.RetailStore{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/retail-store/:id", Tag: "retail-stores", Summary: "Update a retail store",
+			Body: model.UpdateRetailStoreRequest{}, Data: model.RetailStore{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/retail-store/:id/deactivate", Tag: "retail-stores", Summary: "Deactivate a retail store",
+			Data: model.RetailStore{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/retail-store/:id/activate", Tag: "retail-stores", Summary: "Activate a retail store",
+			Data: model.RetailStore{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/retail-store/:id/users", Tag: "retail-stores", Summary: "List the users of a retail store",
+			Data: []*model.StoreUser{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/retail-store/:id/users", Tag: "retail-stores", Summary: "Assign a user to a retail store",
+			Body: model.AssignStoreUserRequest{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/retail-store/:id/users/:user_id", Tag: "retail-stores", Summary: "Unassign a user from a retail store"},
+
+		// Payment methods
+		{Method: fiber.MethodPost, Path: "/api/v1/payment-methods/", Tag: "payment-methods", Summary: "Create a payment method",
+			Body: model.CreatePaymentMethodRequest{}, Data: model.PaymentMethods{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/payment-methods/", Tag: "payment-methods", Summary: "List payment methods",
+			Data: []*model.PaymentMethods{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/payment-methods/:id", Tag: "payment-methods", Summary: "Get a payment method",
+			Data: model.PaymentMethods{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/payment-methods/:id", Tag: "payment-methods", Summary: "Update a payment method",
+			Body: model.UpdatePaymentMethodRequest{}, Data: model.PaymentMethods{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/payment-methods/:id", Tag: "payment-methods", Summary: "Delete a payment method"},
+
+		// Orders
+		{Method: fiber.MethodGet, Path: "/api/v1/orders/", Tag: "orders", Summary: "List orders", Data: []*model.OrdersPage{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/orders/", Tag: "orders", Summary: "Create an order", Body: model.CreateOrders{}, Data: model.Orders{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/orders/revenue/by-platform", Tag: "orders", Summary: "Get the revenue of each platform",
+			Query: []interface{}{model.RevenueFilter{}}, Data: []*model.PlatformRevenue{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/orders/:id/revenue", Tag: "orders", Summary: "Get the revenue of an order", Data: model.OrderRevenue{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/orders/:id", Tag: "orders", Summary: "Update the status of an order", Body: model.UpdateOrderStatus{}},
+
+		// Point of sale
+		{Method: fiber.MethodGet, Path: "/api/v1/pos/lookup", Tag: "pos", Summary: "Look up an item by barcode or SKU",
+			Params: []*openapi.Parameter{openapi.QueryParam("code", "string", "Barcode or SKU")}, Data: model.PosItem{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/pos/cart", Tag: "pos", Summary: "Price a cart", Body: model.PosCartRequest{}, Data: model.PosCart{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/pos/checkout", Tag: "pos", Summary: "Check out a cart",
+			Body: model.PosCheckoutRequest{}, Data: model.Receipt{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/pos/receipts/:order_id", Tag: "pos", Summary: "Get the receipt of an order",
+			Description: "With format=text the receipt is plain text, ready to print",
+			Params:      []*openapi.Parameter{openapi.QueryParam("format", "string", "text for a printable receipt")},
+			Data:        model.Receipt{}},
+
+		// Cashier shifts
+		{Method: fiber.MethodPost, Path: "/api/v1/shifts/", Tag: "shifts", Summary: "Open a cashier shift",
+			Body: model.OpenCashShiftRequest{}, Data: model.CashShift{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/shifts/report", Tag: "shifts", Summary: "Get the cashier shift report of a store",
+			Query: []interface{}{model.CashShiftReportFilter{}}, Data: model.CashShiftReport{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/shifts/:id", Tag: "shifts", Summary: "Get a cashier shift", Data: model.CashShift{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/shifts/:id/cash-movements", Tag: "shifts", Summary: "Record a cash movement",
+			Body: model.CreateCashMovementRequest{}, Data: model.CashShift{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodPost, Path: "/api/v1/shifts/:id/close", Tag: "shifts", Summary: "Close a cashier shift",
+			Body: model.CloseCashShiftRequest{}, Data: model.CashShift{}},
+
+		// Loyalty program
+		{Method: fiber.MethodGet, Path: "/api/v1/loyalty/settings", Tag: "loyalty", Summary: "Get the loyalty settings", Data: model.LoyaltyConfig{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/loyalty/settings", Tag: "loyalty", Summary: "Update the loyalty settings",
+			Body: model.LoyaltyConfig{}, Data: model.LoyaltyConfig{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/loyalty/expire", Tag: "loyalty", Summary: "Expire loyalty points",
+			Params: []*openapi.Parameter{openapi.QueryParam("customer_id", "integer", "Only this customer, every customer when missing")},
+			Data:   model.LoyaltyExpiryResult{}},
+
+		// Tags
+		{Method: fiber.MethodGet, Path: "/api/v1/tags/", Tag: "tags", Summary: "List tags", Data: []*model.Tag{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/tags/", Tag: "tags", Summary: "Create a tag",
+			Body: model.CreateTagRequest{}, Data: model.Tag{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodPut, Path: "/api/v1/tags/:id", Tag: "tags", Summary: "Update a tag", Body: model.UpdateTagRequest{}, Data: model.Tag{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/tags/:id", Tag: "tags", Summary: "Delete a tag"},
+
+		// Customer segments
+		{Method: fiber.MethodGet, Path: "/api/v1/segments/", Tag: "segments", Summary: "List segments", Data: []*model.CustomerSegment{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/segments/", Tag: "segments", Summary: "Create a segment",
+			Body: model.CreateCustomerSegmentRequest{}, Data: model.CustomerSegment{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodGet, Path: "/api/v1/segments/rfm", Tag: "segments", Summary: "Get the RFM report",
+			Params: []*openapi.Parameter{
+				openapi.QueryParam("window_days", "integer", "Days of orders taken into account"),
+				openapi.QueryParam("platform_ids", "string", "Comma separated platform ids, every platform when missing"),
+			},
+			Data: model.RFMReport{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/segments/preview", Tag: "segments", Summary: "Preview the members of segment rules",
+			Body: model.SegmentRules{}, Data: model.SegmentMembership{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/segments/:id", Tag: "segments", Summary: "Get a segment", Data: model.CustomerSegment{}},
+		{Method: fiber.MethodPut, Path: "/api/v1/segments/:id", Tag: "segments", Summary: "Update a segment",
+			Body: model.UpdateCustomerSegmentRequest{}, Data: model.CustomerSegment{}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/segments/:id", Tag: "segments", Summary: "Delete a segment"},
+		{Method: fiber.MethodGet, Path: "/api/v1/segments/:id/members", Tag: "segments", Summary: "List the members of a segment",
+			Data: model.SegmentMembership{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/segments/:id/export", Tag: "segments", Summary: "Export the members of a segment as CSV",
+			ContentType: "text/csv"},
+
+		// Marketplace channels
+		{Method: fiber.MethodPost, Path: "/api/v1/channels/orders/:order_id/shipment", Tag: "channels", Summary: "Acknowledge the shipment of a marketplace order",
+			Body: model.AcknowledgeShipmentRequest{}, Data: model.ChannelOrder{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/channels/:platform_id/orders/sync", Tag: "channels", Summary: "Pull the orders of a marketplace",
+			Body: model.SyncOrdersRequest{}, Data: model.SyncOrdersResult{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/channels/:platform_id/stock/push", Tag: "channels", Summary: "Push the stock to a marketplace",
+			Data: model.SyncPushResult{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/channels/:platform_id/prices/push", Tag: "channels", Summary: "Push the prices to a marketplace",
+			Data: model.SyncPushResult{}},
+		{Method: fiber.MethodGet, Path: "/api/v1/channels/:platform_id/sku-mappings", Tag: "channels", Summary: "List the SKU mappings of a marketplace",
+			Data: []*model.ChannelListing{}},
+		{Method: fiber.MethodPost, Path: "/api/v1/channels/:platform_id/sku-mappings", Tag: "channels", Summary: "Map a marketplace SKU to a variant",
+			Body: model.CreateChannelSkuMappingRequest{}, Data: model.ChannelSkuMapping{}, Status: fiber.StatusCreated},
+		{Method: fiber.MethodDelete, Path: "/api/v1/channels/:platform_id/sku-mappings/:id", Tag: "channels", Summary: "Delete a SKU mapping"},
+		{Method: fiber.MethodGet, Path: "/api/v1/channels/:platform_id/sync-runs", Tag: "channels", Summary: "List the sync runs of a marketplace",
+			Data: []*model.ChannelSyncRun{}},
+
+		// Audit trail
+		{Method: fiber.MethodGet, Path: "/api/v1/audit/", Tag: "audit", Summary: "List audit entries",
+			Query: []interface{}{pagination.Request{}, model.AuditFilter{}}, Data: pagination.Response{}},
+	},
+}
+
+// registerDocs serves the OpenAPI document of the routes registered so far at /openapi.json, and Swagger UI at /docs
+func registerDocs(app *fiber.App) error {
+	routes := app.GetRoutes(true)
+	undocumented, unregistered := apiSpec.Drift(routes)
+	for _, route := range undocumented {
+		log.Printf("⚠️ Route without an OpenAPI operation: %s", route)
+	}
+	for _, route := range unregistered {
+		log.Printf("⚠️ OpenAPI operation without a route: %s", route)
+	}
+
+	specHandler, err := openapi.Handler(apiSpec.Document(routes))
+	if err != nil {
+		return err
+	}
+	app.Get("/openapi.json", specHandler)
+	app.Get("/docs", openapi.UIHandler(apiSpec.Info.Title, "/openapi.json"))
+	return nil
+}
+
+// runOpenAPI prints the OpenAPI document, or with check lists the drift between the routes and apiSpec.
+// It needs no config nor database, the routes are registered with empty handlers
+func runOpenAPI(check bool) error {
+	app := fiber.New()
+	registerRoutes(app, &routeHandlers{})
+	routes := app.GetRoutes(true)
+
+	if !check {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(apiSpec.Document(routes))
+	}
+
+	undocumented, unregistered := apiSpec.Drift(routes)
+	for _, route := range undocumented {
+		fmt.Printf("route without an operation in apiSpec: %s\n", route)
+	}
+	for _, route := range unregistered {
+		fmt.Printf("operation of apiSpec without a route: %s\n", route)
+	}
+	if len(undocumented) > 0 || len(unregistered) > 0 {
+		return fmt.Errorf("the routes and the OpenAPI operations drifted apart, update apiSpec in cmd/api/openapi.go")
+	}
+	fmt.Printf("OpenAPI operations match the %d routes\n", len(apiSpec.Operations))
+	return nil
+}
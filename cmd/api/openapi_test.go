@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPISpecMatchesRoutes(t *testing.T) {
+	app := fiber.New()
+	registerRoutes(app, &routeHandlers{})
+
+	undocumented, unregistered := apiSpec.Drift(app.GetRoutes(true))
+	for _, route := range undocumented {
+		t.Errorf("route without an operation in apiSpec: %s", route)
+	}
+	for _, route := range unregistered {
+		t.Errorf("operation of apiSpec without a route: %s", route)
+	}
+}
+
+func TestAPISpecOperationsAreUnique(t *testing.T) {
+	seen := make(map[string]bool, len(apiSpec.Operations))
+	for _, operation := range apiSpec.Operations {
+		key := operation.Method + " " + operation.Path
+		if seen[key] {
+			t.Errorf("operation documented twice: %s", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestAPISpecDocumentsEveryOperation(t *testing.T) {
+	app := fiber.New()
+	registerRoutes(app, &routeHandlers{})
+
+	doc := apiSpec.Document(app.GetRoutes(true))
+	endpoints := 0
+	for _, methods := range doc.Paths {
+		endpoints += len(methods)
+	}
+	if endpoints != len(apiSpec.Operations) {
+		t.Errorf("document has %d endpoints, apiSpec has %d operations", endpoints, len(apiSpec.Operations))
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"simple-template/internal/handler"
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// routeHandlers are the handlers of the routes, authUsecase checks the credentials of the protected routes
+type routeHandlers struct {
+	user            *handler.UserHandler
+	auth            *handler.AuthHandler
+	apiKey          *handler.APIKeyHandler
+	account         *handler.AccountHandler
+	twoFactor       *handler.TwoFactorHandler
+	product         *handler.ProductHandler
+	customer        *handler.CustomerHandler
+	customerAddress *handler.CustomerAddressHandler
+	platform        *handler.PlatformHandler
+	retailStore     *handler.RetailStoreHandler
+	paymentMethods  *handler.PaymentMethodsHandler
+	orders          *handler.OrderHandler
+	channel         *handler.ChannelHandler
+	pos             *handler.PosHandler
+	cashShift       *handler.CashShiftHandler
+	loyalty         *handler.LoyaltyHandler
+	segment         *handler.SegmentHandler
+	tag             *handler.TagHandler
+	customerNote    *handler.CustomerNoteHandler
+	audit           *handler.AuditHandler
+
+	authUsecase *usecase.AuthUsecase
+}
+
+// registerRoutes registers every route of the API, each one needs an operation in apiSpec (see openapi.go)
+func registerRoutes(app *fiber.App, h *routeHandlers) {
+	// Health check endpoint
+	app.Get("/health", func(c *fiber.Ctx) error {
+		return response.Success(c, fiber.Map{
+			"status":   "ok",
+			"database": "connected",
+		}, "Service is healthy")
+	})
+
+	// API routes
+	api := app.Group("/api/v1")
+
+	// Auth routes, login (with its two-factor step), refresh and the links sent by email are the only public API routes
+	auth := api.Group("/auth")
+	auth.Post("/login", h.auth.Login)
+	auth.Post("/login/2fa", h.auth.LoginTwoFactor)
+	auth.Post("/refresh", h.auth.Refresh)
+	auth.Post("/invitation/accept", h.account.AcceptInvitation)
+	auth.Post("/email/verify", h.account.VerifyEmail)
+	auth.Post("/password/forgot", h.account.ForgotPassword)
+	auth.Post("/password/reset", h.account.ResetPassword)
+
+	// Every route below needs an access token or an api key, each group then needs its read or write permission
+	api.Use(middleware.Authenticate(h.authUsecase))
+
+	auth.Post("/logout", h.auth.Logout)
+	auth.Post("/logout-all", h.auth.LogoutAll)
+	auth.Get("/me", h.auth.Me)
+	auth.Put("/password", h.auth.ChangePassword)
+	auth.Post("/email/verification", h.account.SendEmailVerification)
+
+	// Two-factor authentication of the signed in user, reachable by admins who still have to enable it
+	auth.Get("/2fa", h.twoFactor.Status)
+	auth.Post("/2fa/setup", h.twoFactor.Setup)
+	auth.Post("/2fa/enable", h.twoFactor.Enable)
+	auth.Post("/2fa/disable", h.twoFactor.Disable)
+	auth.Post("/2fa/recovery-codes", h.twoFactor.RegenerateRecoveryCodes)
+
+	// API keys of the signed in user
+	apiKeys := api.Group("/api-keys")
+	apiKeys.Get("/", h.apiKey.GetMine)
+	apiKeys.Post("/", h.apiKey.Create)
+	apiKeys.Delete("/:id", h.apiKey.RevokeMine)
+
+	// User routes
+	users := api.Group("/users", middleware.Permit(model.PermissionUsersRead, model.PermissionUsersWrite))
+	users.Post("/", h.user.CreateUser)                            // Create new user
+	users.Post("/invite", h.account.Invite)                       // Invite new user by email
+	users.Get("/", h.user.GetAllUsers)                            // Get list of users
+	users.Get("/roles", h.user.GetRoles)                          // Get roles and their permissions
+	users.Get("/:id", h.user.GetUser)                             // Get user by ID
+	users.Put("/:id", h.user.UpdateUser)                          // Update user
+	users.Put("/:id/role", h.user.SetRole)                        // Set role in every store
+	users.Post("/:id/invitation", h.account.ResendInvitation)     // Resend invitation
+	users.Delete("/:id/2fa", h.twoFactor.Reset)                   // Reset two-factor authentication
+	users.Get("/:id/api-keys", h.apiKey.GetByUser)                // Get api keys of user
+	users.Delete("/:id/api-keys/:key_id", h.apiKey.RevokeForUser) // Revoke api key of user
+	users.Delete("/:id", h.user.DeleteUser)                       // Delete user
+
+	product := api.Group("/products", middleware.Permit(model.PermissionProductsRead, model.PermissionProductsWrite))
+	product.Get("/", h.product.GetAll)
+
+	product.Get("/:id", h.product.GetByID)
+	product.Post("/", h.product.CreateProduct)
+	product.Delete("/:id", h.product.DeleteProduct)
+
+	// Customer, exporting, merging, anonymizing and deleting customers also need customers:admin
+	customer := api.Group("/customer", middleware.Permit(model.PermissionCustomersRead, model.PermissionCustomersWrite))
+	customerAdmin := middleware.Permit(model.PermissionCustomersAdmin, model.PermissionCustomersAdmin)
+	customer.Post("/", h.customer.Create)
+	customer.Get("/", h.customer.GetAllCustomers)
+	customer.Get("/:id", h.customer.GetCustomer)
+	customer.Get("/:id/orders", h.customer.GetOrderHistory)
+	customer.Get("/:id/duplicates", h.customer.GetDuplicates)
+	customer.Post("/:id/merge", customerAdmin, h.customer.Merge)
+	customer.Get("/:id/export", customerAdmin, h.customer.Export)
+	customer.Post("/:id/anonymize", customerAdmin, h.customer.Anonymize)
+	customer.Get("/:id/loyalty", h.loyalty.GetCustomerLoyalty)
+	customer.Get("/:id/loyalty/ledger", h.loyalty.GetLedger)
+	customer.Post("/:id/tags/:tag_id", h.tag.TagCustomer)
+	customer.Delete("/:id/tags/:tag_id", h.tag.UntagCustomer)
+	customer.Get("/:id/notes", h.customerNote.GetTimeline)
+	customer.Post("/:id/notes", h.customerNote.Create)
+	customer.Get("/:id/addresses", h.customerAddress.GetAll)
+	customer.Post("/:id/addresses", h.customerAddress.Create)
+	customer.Put("/:id/addresses/:address_id", h.customerAddress.Update)
+	customer.Delete("/:id/addresses/:address_id", h.customerAddress.Delete)
+	customer.Put("/:id", h.customer.UpdateCustomers)
+	customer.Delete("/:id", customerAdmin, h.customer.DeleteCustomer)
+
+	// platform
+	platform := api.Group("/platform", middleware.Permit(model.PermissionSettingsRead, model.PermissionSettingsWrite))
+	platform.Post("/", h.platform.Create)
+	platform.Get("/", h.platform.GetAll)
+	platform.Get("/:id", h.platform.GetByID)
+	platform.Put("/:id", h.platform.Update)
+	platform.Delete("/:id", h.platform.Delete)
+	// retail store
+	retailStore := api.Group("/retail-store", middleware.Permit(model.PermissionStoresRead, model.PermissionStoresWrite))
+	retailStore.Post("/", h.retailStore.Create)
+	retailStore.Get("/", h.retailStore.GetAll)
+	retailStore.Get("/:id", h.retailStore.GetByID)
+	retailStore.Put("/:id", h.retailStore.Update)
+	retailStore.Post("/:id/deactivate", h.retailStore.Deactivate)
+	retailStore.Post("/:id/activate", h.retailStore.Activate)
+	retailStore.Get("/:id/users", h.retailStore.GetUsers)
+	retailStore.Post("/:id/users", h.retailStore.AssignUser)
+	retailStore.Delete("/:id/users/:user_id", h.retailStore.UnassignUser)
+	// payment methods
+	paymentMethods := api.Group("/payment-methods", middleware.Permit(model.PermissionSettingsRead, model.PermissionSettingsWrite))
+	paymentMethods.Post("/", h.paymentMethods.Create)
+	paymentMethods.Get("/", h.paymentMethods.GetAll)
+	paymentMethods.Get("/:id", h.paymentMethods.GetByID)
+	paymentMethods.Put("/:id", h.paymentMethods.Update)
+	paymentMethods.Delete("/:id", h.paymentMethods.Delete)
+	// orders
+	orders := api.Group("/orders", middleware.Permit(model.PermissionOrdersRead, model.PermissionOrdersWrite))
+	orders.Get("/", h.orders.GetAll)
+	orders.Post("/", h.orders.Create)
+	orders.Get("/revenue/by-platform", h.orders.GetRevenueByPlatform)
+	orders.Get("/:id/revenue", h.orders.GetRevenue)
+	orders.Put("/:id", h.orders.UpdateStatus)
+	// point of sale
+	pos := api.Group("/pos", middleware.Permit(model.PermissionPosSell, model.PermissionPosSell))
+	pos.Get("/lookup", h.pos.Lookup)
+	pos.Post("/cart", h.pos.BuildCart)
+	pos.Post("/checkout", h.pos.Checkout)
+	pos.Get("/receipts/:order_id", h.pos.GetReceipt)
+	// cashier shifts
+	shifts := api.Group("/shifts", middleware.Permit(model.PermissionShiftsRead, model.PermissionShiftsWrite))
+	shifts.Post("/", h.cashShift.Open)
+	shifts.Get("/report", h.cashShift.GetReport)
+	shifts.Get("/:id", h.cashShift.GetByID)
+	shifts.Post("/:id/cash-movements", h.cashShift.AddMovement)
+	shifts.Post("/:id/close", h.cashShift.Close)
+	// loyalty program
+	loyalty := api.Group("/loyalty", middleware.Permit(model.PermissionSettingsRead, model.PermissionSettingsWrite))
+	loyalty.Get("/settings", h.loyalty.GetSettings)
+	loyalty.Put("/settings", h.loyalty.UpdateSettings)
+	loyalty.Post("/expire", h.loyalty.ExpirePoints)
+	// tags
+	tags := api.Group("/tags", middleware.Permit(model.PermissionCustomersRead, model.PermissionCustomersWrite))
+	tags.Get("/", h.tag.GetAll)
+	tags.Post("/", h.tag.Create)
+	tags.Put("/:id", h.tag.Update)
+	tags.Delete("/:id", h.tag.Delete)
+	// customer segments
+	segments := api.Group("/segments", middleware.Permit(model.PermissionCustomersRead, model.PermissionCustomersWrite))
+	segments.Get("/", h.segment.GetAll)
+	segments.Post("/", h.segment.Create)
+	segments.Get("/rfm", h.segment.GetRFMReport)
+	segments.Post("/preview", h.segment.Preview)
+	segments.Get("/:id", h.segment.GetByID)
+	segments.Put("/:id", h.segment.Update)
+	segments.Delete("/:id", h.segment.Delete)
+	segments.Get("/:id/members", h.segment.GetMembers)
+	segments.Get("/:id/export", h.segment.Export)
+	// marketplace channels
+	channels := api.Group("/channels", middleware.Permit(model.PermissionChannelsRead, model.PermissionChannelsWrite))
+	channels.Post("/orders/:order_id/shipment", h.channel.AcknowledgeShipment)
+	channels.Post("/:platform_id/orders/sync", h.channel.SyncOrders)
+	channels.Post("/:platform_id/stock/push", h.channel.PushStock)
+	channels.Post("/:platform_id/prices/push", h.channel.PushPrices)
+	channels.Get("/:platform_id/sku-mappings", h.channel.GetSkuMappings)
+	channels.Post("/:platform_id/sku-mappings", h.channel.CreateSkuMapping)
+	channels.Delete("/:platform_id/sku-mappings/:id", h.channel.DeleteSkuMapping)
+	channels.Get("/:platform_id/sync-runs", h.channel.GetSyncRuns)
+	// audit trail of user, customer, product and order changes
+	audit := api.Group("/audit", middleware.Permit(model.PermissionAuditRead, model.PermissionAuditRead))
+	audit.Get("/", h.audit.GetAll)
+}
@@ -0,0 +1,187 @@
+// Package apperror defines the domain errors returned by the repositories and usecases.
+// Each error carries a stable code clients can rely on, middleware.ErrorHandler turns it into
+// a response with the matching HTTP status. Errors without a code are internal errors
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"simple-template/pkg/i18n"
+	"strings"
+)
+
+// Code identifies the kind of a domain error, it is sent to clients and must not change
+type Code string
+
+const (
+	CodeValidation        Code = "validation_failed"
+	CodeUnauthorized      Code = "unauthorized"
+	CodeForbidden         Code = "forbidden"
+	CodeNotFound          Code = "not_found"
+	CodeConflict          Code = "conflict"
+	CodeOutOfStock        Code = "out_of_stock"
+	CodeInvalidTransition Code = "invalid_transition"
+	CodeTooManyAttempts   Code = "too_many_attempts"
+	CodeUpstream          Code = "upstream_failed"
+	CodeInternal          Code = "internal_error"
+)
+
+// Error is a domain error, Message is safe to show to clients
+type Error struct {
+	Code    Code
+	Message string
+	// Err is the cause, nil when the error originates here
+	Err error
+	// Fields are the request fields failing validation, when the error is about some
+	Fields []FieldError
+	// format and args give Message, kept to translate it
+	format string
+	args   []interface{}
+}
+
+// FieldError is the validation failure of one request field
+type FieldError struct {
+	// Field is the path of the field in the request with its JSON names, e.g. variants[1].name
+	Field string
+	// Rule is the failed rule, e.g. required or min, and Param its parameter, e.g. 8 for min=8.
+	// Together they let clients word the error themselves
+	Rule  string
+	Param string
+	// Message is the English description of the failure
+	Message string
+	format  string
+	args    []interface{}
+}
+
+// Field returns the failure of the field, the message is a format of fmt
+func Field(field, rule, format string, args ...interface{}) FieldError {
+	return FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Localize returns the message in the locale
+func (f FieldError) Localize(locale i18n.Locale) string {
+	if f.format == "" {
+		return i18n.T(locale, f.Message)
+	}
+	return i18n.T(locale, f.format, f.args...)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Localize returns the message in the locale, the failures of the fields joined for validation errors
+func (e *Error) Localize(locale i18n.Locale) string {
+	if len(e.Fields) > 0 {
+		messages := make([]string, len(e.Fields))
+		for i, field := range e.Fields {
+			messages[i] = field.Localize(locale)
+		}
+		return strings.Join(messages, "; ")
+	}
+	if e.format == "" {
+		return i18n.T(locale, e.Message)
+	}
+	return i18n.T(locale, e.format, e.args...)
+}
+
+func newError(code Code, format string, args []interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), format: format, args: args}
+}
+
+// Validation reports a request the usecase refuses as such, e.g. a missing field or an invalid value
+func Validation(format string, args ...interface{}) *Error {
+	return newError(CodeValidation, format, args)
+}
+
+// Invalid reports request fields failing validation, the message lists their failures
+func Invalid(fields ...FieldError) *Error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return &Error{Code: CodeValidation, Message: strings.Join(messages, "; "), Fields: fields}
+}
+
+// InvalidField reports one request field failing the rule
+func InvalidField(field, rule, format string, args ...interface{}) *Error {
+	return Invalid(Field(field, rule, format, args...))
+}
+
+// Unauthorized reports missing or invalid credentials
+func Unauthorized(format string, args ...interface{}) *Error {
+	return newError(CodeUnauthorized, format, args)
+}
+
+// Forbidden reports a user who is known but not allowed to do it
+func Forbidden(format string, args ...interface{}) *Error {
+	return newError(CodeForbidden, format, args)
+}
+
+// NotFound reports an entity that doesn't exist
+func NotFound(format string, args ...interface{}) *Error {
+	return newError(CodeNotFound, format, args)
+}
+
+// Conflict reports a request clashing with the current state, e.g. a duplicated name or an entity
+// already in the requested state
+func Conflict(format string, args ...interface{}) *Error {
+	return newError(CodeConflict, format, args)
+}
+
+// OutOfStock reports items that can't be sold in the requested quantity
+func OutOfStock(format string, args ...interface{}) *Error {
+	return newError(CodeOutOfStock, format, args)
+}
+
+// InvalidTransition reports a status change the workflow doesn't allow
+func InvalidTransition(format string, args ...interface{}) *Error {
+	return newError(CodeInvalidTransition, format, args)
+}
+
+// TooManyAttempts reports an action locked after too many failures
+func TooManyAttempts(format string, args ...interface{}) *Error {
+	return newError(CodeTooManyAttempts, format, args)
+}
+
+// Upstream reports a failure of an external service the request depends on, e.g. a marketplace
+func Upstream(err error, format string, args ...interface{}) *Error {
+	return Wrap(CodeUpstream, err, format, args...)
+}
+
+// Wrap returns a domain error with the code and message caused by err
+func Wrap(code Code, err error, format string, args ...interface{}) *Error {
+	e := newError(code, format, args)
+	e.Err = err
+	return e
+}
+
+// As returns the first domain error in the chain of err
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// CodeOf returns the code of the first domain error in the chain of err, CodeInternal when there is none
+func CodeOf(err error) Code {
+	if e, ok := As(err); ok {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// Is reports whether err is a domain error with the code
+func Is(err error, code Code) bool {
+	e, ok := As(err)
+	return ok && e.Code == code
+}
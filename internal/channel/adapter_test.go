@@ -0,0 +1,226 @@
+package channel_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"simple-template/internal/channel"
+	"simple-template/internal/channel/channeltest"
+)
+
+var platforms = []string{channel.PlatformShopee, channel.PlatformLazada, channel.PlatformTikTokShop}
+
+// newAdapter starts the stand-in of the platform and returns the registered adapter pointed at it
+func newAdapter(t *testing.T, platform string) (channel.Adapter, *channeltest.Marketplace) {
+	t.Helper()
+	market, err := channeltest.New(platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := market.Start()
+	t.Cleanup(server.Close)
+
+	adapter, err := channel.DefaultRegistry().New(platform, channel.Config{
+		BaseURL: server.URL,
+		Credentials: channel.Credentials{
+			AppKey:      "1001",
+			AppSecret:   "secret",
+			AccessToken: "token",
+			ShopID:      "2002",
+		},
+		Currency: "VND",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adapter, market
+}
+
+// externalOrders returns n paid orders a minute apart from start, with numeric ids as Lazada needs
+func externalOrders(start time.Time, n int) []channel.ExternalOrder {
+	orders := make([]channel.ExternalOrder, n)
+	for i := range orders {
+		orders[i] = channel.ExternalOrder{
+			ExternalID:      strconv.Itoa(500000 + i),
+			Status:          channel.ExternalStatusPaid,
+			BuyerName:       "Nguyen Van A",
+			BuyerPhone:      "0901234567",
+			ShippingAddress: "1 Le Loi, District 1",
+			CreatedAt:       start.Add(time.Duration(i) * time.Minute),
+			Items: []channel.ExternalOrderItem{
+				{SKU: "SKU-A", Quantity: 2, UnitPrice: 100000},
+				{SKU: "SKU-B", Quantity: 1, UnitPrice: 55000},
+			},
+		}
+	}
+	return orders
+}
+
+func TestPullOrdersFollowsPages(t *testing.T) {
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	// More than the largest page size, 100 on Lazada
+	const count = 230
+
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, market := newAdapter(t, platform)
+			market.AddOrders(externalOrders(start, count)...)
+
+			orders, err := adapter.PullOrders(context.Background(), start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != count {
+				t.Fatalf("got %d orders, want %d", len(orders), count)
+			}
+			seen := make(map[string]bool, len(orders))
+			for _, order := range orders {
+				if seen[order.ExternalID] {
+					t.Fatalf("order %s pulled twice", order.ExternalID)
+				}
+				seen[order.ExternalID] = true
+			}
+		})
+	}
+}
+
+func TestPullOrdersMapsOrders(t *testing.T) {
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, market := newAdapter(t, platform)
+			want := externalOrders(start, 1)[0]
+			market.AddOrders(want)
+
+			orders, err := adapter.PullOrders(context.Background(), start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != 1 {
+				t.Fatalf("got %d orders, want 1", len(orders))
+			}
+			got := orders[0]
+			if got.ExternalID != want.ExternalID || got.Status != want.Status {
+				t.Errorf("got order %s %s, want %s %s", got.ExternalID, got.Status, want.ExternalID, want.Status)
+			}
+			if got.BuyerName != want.BuyerName || got.BuyerPhone != want.BuyerPhone || got.ShippingAddress != want.ShippingAddress {
+				t.Errorf("got buyer %q %q %q, want %q %q %q",
+					got.BuyerName, got.BuyerPhone, got.ShippingAddress, want.BuyerName, want.BuyerPhone, want.ShippingAddress)
+			}
+			if !got.CreatedAt.Equal(want.CreatedAt) {
+				t.Errorf("got created at %s, want %s", got.CreatedAt, want.CreatedAt)
+			}
+			// Lazada and TikTok Shop return one line per unit, they are grouped back by SKU
+			if len(got.Items) != len(want.Items) {
+				t.Fatalf("got %d items, want %d", len(got.Items), len(want.Items))
+			}
+			for i, item := range got.Items {
+				if item != want.Items[i] {
+					t.Errorf("got item %+v, want %+v", item, want.Items[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPullOrdersSkipsOlderOrders(t *testing.T) {
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, market := newAdapter(t, platform)
+			market.AddOrders(externalOrders(start, 3)...)
+
+			orders, err := adapter.PullOrders(context.Background(), start.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(orders) != 2 {
+				t.Fatalf("got %d orders, want 2", len(orders))
+			}
+		})
+	}
+}
+
+func TestPushStockAndPrices(t *testing.T) {
+	// The stand-ins key the listings like their marketplace identifies them
+	keys := map[string]string{
+		channel.PlatformShopee:     "111/222",
+		channel.PlatformLazada:     "SKU-A",
+		channel.PlatformTikTokShop: "111/222",
+	}
+
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, market := newAdapter(t, platform)
+			ctx := context.Background()
+
+			err := adapter.PushStock(ctx, []channel.StockUpdate{
+				{SKU: "SKU-A", ExternalProductID: "111", ExternalVariantID: "222", Quantity: 7},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = adapter.PushPrices(ctx, []channel.PriceUpdate{
+				{SKU: "SKU-A", ExternalProductID: "111", ExternalVariantID: "222", Price: 125000},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key := keys[platform]
+			if stock := market.Stock()[key]; stock != 7 {
+				t.Errorf("got stock %d for %s, want 7", stock, key)
+			}
+			if price := market.Prices()[key]; price != 125000 {
+				t.Errorf("got price %v for %s, want 125000", price, key)
+			}
+		})
+	}
+}
+
+func TestAcknowledgeShipment(t *testing.T) {
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, market := newAdapter(t, platform)
+			order := externalOrders(time.Now().Add(-time.Hour), 1)[0]
+			market.AddOrders(order)
+
+			err := adapter.AcknowledgeShipment(context.Background(), channel.Shipment{
+				ExternalOrderID: order.ExternalID,
+				TrackingNumber:  "VN123456789",
+				Carrier:         "GHN",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shipments := market.Shipments()
+			if len(shipments) != 1 {
+				t.Fatalf("got %d shipments, want 1", len(shipments))
+			}
+			if shipments[0].ExternalOrderID != order.ExternalID || shipments[0].TrackingNumber != "VN123456789" {
+				t.Errorf("got shipment %+v", shipments[0])
+			}
+		})
+	}
+}
+
+func TestAcknowledgeShipmentOfUnknownOrder(t *testing.T) {
+	for _, platform := range platforms {
+		t.Run(platform, func(t *testing.T) {
+			adapter, _ := newAdapter(t, platform)
+
+			err := adapter.AcknowledgeShipment(context.Background(), channel.Shipment{
+				ExternalOrderID: "999999",
+				TrackingNumber:  "VN123456789",
+			})
+			if err == nil {
+				t.Fatal("shipment of an unknown order was acknowledged")
+			}
+		})
+	}
+}
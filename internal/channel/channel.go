@@ -0,0 +1,122 @@
+package channel
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Adapter talks to a single marketplace (Shopee, Lazada, TikTok Shop...)
+// Every marketplace has its own API shape, the adapter hides that behind
+// one set of operations used by the sync engine
+type Adapter interface {
+	// Name returns the platform name the adapter is registered under
+	Name() string
+
+	// PullOrders returns the marketplace orders created since the given time
+	PullOrders(ctx context.Context, since time.Time) ([]ExternalOrder, error)
+
+	// PushStock sends our stock levels to the marketplace
+	PushStock(ctx context.Context, updates []StockUpdate) error
+
+	// PushPrices sends our selling prices to the marketplace
+	PushPrices(ctx context.Context, updates []PriceUpdate) error
+
+	// AcknowledgeShipment tells the marketplace that an order has been shipped
+	AcknowledgeShipment(ctx context.Context, shipment Shipment) error
+}
+
+// Credentials contains the API credentials of one marketplace shop
+// AppKey/AppSecret are the partner_id/partner_key on Shopee
+type Credentials struct {
+	AppKey      string
+	AppSecret   string
+	AccessToken string
+	ShopID      string
+}
+
+// Config is passed to an adapter factory
+type Config struct {
+	// BaseURL is the marketplace API root (platform.api_endpoint)
+	// Point it at a local stand-in server to run against fake marketplaces
+	BaseURL     string
+	Credentials Credentials
+	HTTPClient  *http.Client
+	// Currency is sent along with prices by marketplaces that need it
+	Currency string
+}
+
+// External order statuses normalized across marketplaces
+const (
+	ExternalStatusPending   = "pending"
+	ExternalStatusPaid      = "paid"
+	ExternalStatusShipped   = "shipped"
+	ExternalStatusCompleted = "completed"
+	ExternalStatusCanceled  = "canceled"
+)
+
+// ExternalOrder is an order as seen on the marketplace
+type ExternalOrder struct {
+	ExternalID      string              `json:"external_id"`
+	Status          string              `json:"status"`
+	BuyerName       string              `json:"buyer_name"`
+	BuyerPhone      string              `json:"buyer_phone"`
+	BuyerEmail      string              `json:"buyer_email"`
+	ShippingAddress string              `json:"shipping_address"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Items           []ExternalOrderItem `json:"items"`
+}
+
+// ExternalOrderItem is one order line identified by the marketplace SKU
+type ExternalOrderItem struct {
+	SKU       string  `json:"sku"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+// StockUpdate is the stock level of one marketplace SKU
+type StockUpdate struct {
+	SKU               string
+	ExternalProductID string
+	ExternalVariantID string
+	Quantity          int
+}
+
+// PriceUpdate is the selling price of one marketplace SKU
+type PriceUpdate struct {
+	SKU               string
+	ExternalProductID string
+	ExternalVariantID string
+	Price             float64
+}
+
+// Shipment is the shipping information of one marketplace order
+type Shipment struct {
+	ExternalOrderID string
+	TrackingNumber  string
+	Carrier         string
+}
+
+// NormalizeName lower-cases and trims a platform name so "Shopee " and "shopee" match
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Settings holds the credentials of every platform and the options shared by all adapters
+type Settings struct {
+	// Credentials are keyed by credentials reference
+	Credentials map[string]Credentials
+	HTTPClient  *http.Client
+	Currency    string
+}
+
+// ConfigFor builds the adapter config of a platform
+func (s Settings) ConfigFor(credentialsRef, baseURL string) Config {
+	return Config{
+		BaseURL:     baseURL,
+		Credentials: s.Credentials[credentialsRef],
+		HTTPClient:  s.HTTPClient,
+		Currency:    s.Currency,
+	}
+}
@@ -0,0 +1,550 @@
+// Package channeltest provides in-memory stand-ins of the marketplace APIs
+// so the channel adapters and the sync engine can run against a local server
+// instead of the real Shopee, Lazada and TikTok Shop endpoints.
+//
+// Signatures are not verified, the stand-ins only speak the same request and
+// response shapes as the adapters in package channel.
+package channeltest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"simple-template/internal/channel"
+)
+
+// Marketplace is a fake marketplace API for one platform
+type Marketplace struct {
+	platform string
+
+	mu         sync.Mutex
+	orders     []channel.ExternalOrder
+	stock      map[string]int
+	prices     map[string]float64
+	shipments  []channel.Shipment
+	itemOrders map[string]string // lazada order_item_id -> order_id
+	itemIDs    map[string]string // lazada order line unit -> order_item_id
+	nextItemID int64
+}
+
+// New creates a fake marketplace for a platform registered in channel.DefaultRegistry
+// Lazada order IDs must be numeric because Lazada returns them as JSON numbers
+func New(platform string) (*Marketplace, error) {
+	switch channel.NormalizeName(platform) {
+	case channel.PlatformShopee, channel.PlatformLazada, channel.PlatformTikTokShop:
+	default:
+		return nil, fmt.Errorf("no stand-in for platform %q", platform)
+	}
+	return &Marketplace{
+		platform:   channel.NormalizeName(platform),
+		stock:      make(map[string]int),
+		prices:     make(map[string]float64),
+		itemOrders: make(map[string]string),
+		itemIDs:    make(map[string]string),
+		nextItemID: 1000,
+	}, nil
+}
+
+// Start serves the marketplace on a random local port
+// The returned server URL is the value to put in platform.api_endpoint
+func (m *Marketplace) Start() *httptest.Server {
+	return httptest.NewServer(m)
+}
+
+// AddOrders makes orders available to PullOrders
+func (m *Marketplace) AddOrders(orders ...channel.ExternalOrder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = append(m.orders, orders...)
+}
+
+// Stock returns the pushed stock levels keyed by the marketplace identifier
+// (item_id/model_id on Shopee, SellerSku on Lazada, product_id/sku_id on TikTok Shop)
+func (m *Marketplace) Stock() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]int, len(m.stock))
+	for key, value := range m.stock {
+		result[key] = value
+	}
+	return result
+}
+
+// Prices returns the pushed prices keyed like Stock
+func (m *Marketplace) Prices() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string]float64, len(m.prices))
+	for key, value := range m.prices {
+		result[key] = value
+	}
+	return result
+}
+
+// Shipments returns the acknowledged shipments
+func (m *Marketplace) Shipments() []channel.Shipment {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]channel.Shipment(nil), m.shipments...)
+}
+
+// ServeHTTP routes by path suffix so the stand-in works under any base path
+func (m *Marketplace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch m.platform {
+	case channel.PlatformShopee:
+		m.serveShopee(w, r)
+	case channel.PlatformLazada:
+		m.serveLazada(w, r)
+	case channel.PlatformTikTokShop:
+		m.serveTikTok(w, r)
+	}
+}
+
+func (m *Marketplace) serveShopee(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	query := r.URL.Query()
+
+	switch {
+	case strings.HasSuffix(path, "/order/get_order_list"):
+		from, _ := strconv.ParseInt(query.Get("time_from"), 10, 64)
+		page, next := paginate(m.ordersSince(time.Unix(from, 0)), query.Get("cursor"), query.Get("page_size"))
+		var list []map[string]interface{}
+		for _, order := range page {
+			list = append(list, map[string]interface{}{"order_sn": order.ExternalID})
+		}
+		writeJSON(w, map[string]interface{}{
+			"error": "",
+			"response": map[string]interface{}{
+				"order_list":  list,
+				"more":        next != "",
+				"next_cursor": next,
+			},
+		})
+
+	case strings.HasSuffix(path, "/order/get_order_detail"):
+		wanted := make(map[string]bool)
+		for _, sn := range strings.Split(query.Get("order_sn_list"), ",") {
+			wanted[sn] = true
+		}
+		var list []map[string]interface{}
+		for _, order := range m.orders {
+			if !wanted[order.ExternalID] {
+				continue
+			}
+			var items []map[string]interface{}
+			for _, item := range order.Items {
+				items = append(items, map[string]interface{}{
+					"model_sku":                item.SKU,
+					"model_quantity_purchased": item.Quantity,
+					"model_discounted_price":   item.UnitPrice,
+				})
+			}
+			list = append(list, map[string]interface{}{
+				"order_sn":     order.ExternalID,
+				"order_status": shopeeStatus(order.Status),
+				"create_time":  order.CreatedAt.Unix(),
+				"recipient_address": map[string]interface{}{
+					"name":         order.BuyerName,
+					"phone":        order.BuyerPhone,
+					"full_address": order.ShippingAddress,
+				},
+				"item_list": items,
+			})
+		}
+		writeJSON(w, map[string]interface{}{"error": "", "response": map[string]interface{}{"order_list": list}})
+
+	case strings.HasSuffix(path, "/product/update_stock"):
+		var body struct {
+			ItemID    int64 `json:"item_id"`
+			StockList []struct {
+				ModelID     int64 `json:"model_id"`
+				SellerStock []struct {
+					Stock int `json:"stock"`
+				} `json:"seller_stock"`
+			} `json:"stock_list"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		for _, entry := range body.StockList {
+			if len(entry.SellerStock) > 0 {
+				m.stock[fmt.Sprintf("%d/%d", body.ItemID, entry.ModelID)] = entry.SellerStock[0].Stock
+			}
+		}
+		writeJSON(w, map[string]interface{}{"error": "", "response": map[string]interface{}{}})
+
+	case strings.HasSuffix(path, "/product/update_price"):
+		var body struct {
+			ItemID    int64 `json:"item_id"`
+			PriceList []struct {
+				ModelID       int64   `json:"model_id"`
+				OriginalPrice float64 `json:"original_price"`
+			} `json:"price_list"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		for _, entry := range body.PriceList {
+			m.prices[fmt.Sprintf("%d/%d", body.ItemID, entry.ModelID)] = entry.OriginalPrice
+		}
+		writeJSON(w, map[string]interface{}{"error": "", "response": map[string]interface{}{}})
+
+	case strings.HasSuffix(path, "/logistics/ship_order"):
+		var body struct {
+			OrderSN       string `json:"order_sn"`
+			NonIntegrated struct {
+				TrackingNumber string `json:"tracking_number"`
+			} `json:"non_integrated"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		if !m.markShipped(body.OrderSN) {
+			writeJSON(w, map[string]interface{}{"error": "error_not_found", "message": "order not found"})
+			return
+		}
+		m.shipments = append(m.shipments, channel.Shipment{
+			ExternalOrderID: body.OrderSN,
+			TrackingNumber:  body.NonIntegrated.TrackingNumber,
+		})
+		writeJSON(w, map[string]interface{}{"error": "", "response": map[string]interface{}{}})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *Marketplace) serveLazada(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(path, "/orders/get"):
+		since, _ := time.Parse(time.RFC3339, r.Form.Get("created_after"))
+		offset, _ := strconv.Atoi(r.Form.Get("offset"))
+		limit, _ := strconv.Atoi(r.Form.Get("limit"))
+
+		matched := m.ordersSince(since)
+		if offset > len(matched) {
+			offset = len(matched)
+		}
+		end := len(matched)
+		if limit > 0 && offset+limit < end {
+			end = offset + limit
+		}
+
+		var list []map[string]interface{}
+		for _, order := range matched[offset:end] {
+			list = append(list, map[string]interface{}{
+				"order_id":   json.Number(order.ExternalID),
+				"created_at": order.CreatedAt.Format("2006-01-02 15:04:05 -0700"),
+				"statuses":   []string{lazadaStatus(order.Status)},
+				"address_shipping": map[string]interface{}{
+					"first_name": order.BuyerName,
+					"phone":      order.BuyerPhone,
+					"address1":   order.ShippingAddress,
+				},
+			})
+		}
+		writeJSON(w, map[string]interface{}{
+			"code": "0",
+			"data": map[string]interface{}{"count": len(list), "orders": list},
+		})
+
+	case strings.HasSuffix(path, "/orders/items/get"):
+		wanted := make(map[string]bool)
+		for _, id := range strings.Split(strings.Trim(r.Form.Get("order_ids"), "[]"), ",") {
+			wanted[strings.TrimSpace(id)] = true
+		}
+		var list []map[string]interface{}
+		for _, order := range m.orders {
+			if !wanted[order.ExternalID] {
+				continue
+			}
+			var items []map[string]interface{}
+			for _, item := range order.Items {
+				// One order item per unit, like Lazada does
+				for i := int64(0); i < item.Quantity; i++ {
+					itemID := m.lazadaItemID(order.ExternalID, item.SKU, i)
+					items = append(items, map[string]interface{}{
+						"order_item_id": json.Number(itemID),
+						"sku":           item.SKU,
+						"paid_price":    item.UnitPrice,
+					})
+				}
+			}
+			list = append(list, map[string]interface{}{
+				"order_id":    json.Number(order.ExternalID),
+				"order_items": items,
+			})
+		}
+		writeJSON(w, map[string]interface{}{"code": "0", "data": list})
+
+	case strings.HasSuffix(path, "/product/price_quantity/update"):
+		var payload struct {
+			Skus []struct {
+				SellerSku string   `xml:"SellerSku"`
+				Quantity  *int     `xml:"Quantity"`
+				Price     *float64 `xml:"Price"`
+			} `xml:"Product>Skus>Sku"`
+		}
+		if err := xml.Unmarshal([]byte(r.Form.Get("payload")), &payload); err != nil {
+			writeJSON(w, map[string]interface{}{"code": "InvalidPayload", "message": err.Error()})
+			return
+		}
+		for _, sku := range payload.Skus {
+			if sku.Quantity != nil {
+				m.stock[sku.SellerSku] = *sku.Quantity
+			}
+			if sku.Price != nil {
+				m.prices[sku.SellerSku] = *sku.Price
+			}
+		}
+		writeJSON(w, map[string]interface{}{"code": "0"})
+
+	case strings.HasSuffix(path, "/order/rts"):
+		ids := strings.Split(strings.Trim(r.Form.Get("order_item_ids"), "[]"), ",")
+		orderID, exists := m.itemOrders[strings.TrimSpace(ids[0])]
+		if !exists || !m.markShipped(orderID) {
+			writeJSON(w, map[string]interface{}{"code": "InvalidOrderItem", "message": "order item not found"})
+			return
+		}
+		m.shipments = append(m.shipments, channel.Shipment{
+			ExternalOrderID: orderID,
+			TrackingNumber:  r.Form.Get("tracking_number"),
+			Carrier:         r.Form.Get("shipment_provider"),
+		})
+		writeJSON(w, map[string]interface{}{"code": "0"})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *Marketplace) serveTikTok(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+
+	switch {
+	case strings.HasSuffix(path, "/order/202309/orders/search"):
+		var body struct {
+			CreateTimeGe int64 `json:"create_time_ge"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		page, next := paginate(m.ordersSince(time.Unix(body.CreateTimeGe, 0)), r.URL.Query().Get("page_token"), r.URL.Query().Get("page_size"))
+		var list []map[string]interface{}
+		for _, order := range page {
+			var items []map[string]interface{}
+			for _, item := range order.Items {
+				for i := int64(0); i < item.Quantity; i++ {
+					items = append(items, map[string]interface{}{
+						"seller_sku": item.SKU,
+						"sale_price": strconv.FormatFloat(item.UnitPrice, 'f', 2, 64),
+					})
+				}
+			}
+			list = append(list, map[string]interface{}{
+				"id":          order.ExternalID,
+				"status":      tiktokStatus(order.Status),
+				"create_time": order.CreatedAt.Unix(),
+				"buyer_email": order.BuyerEmail,
+				"recipient_address": map[string]interface{}{
+					"name":         order.BuyerName,
+					"phone_number": order.BuyerPhone,
+					"full_address": order.ShippingAddress,
+				},
+				"line_items": items,
+			})
+		}
+		writeJSON(w, map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"orders": list, "next_page_token": next},
+		})
+
+	case strings.HasSuffix(path, "/inventory/update"), strings.HasSuffix(path, "/prices/update"):
+		productID := pathSegmentAfter(path, "products")
+		var body struct {
+			Skus []struct {
+				ID        string `json:"id"`
+				Inventory []struct {
+					Quantity int `json:"quantity"`
+				} `json:"inventory"`
+				Price struct {
+					Amount string `json:"amount"`
+				} `json:"price"`
+			} `json:"skus"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		for _, sku := range body.Skus {
+			key := productID + "/" + sku.ID
+			if len(sku.Inventory) > 0 {
+				m.stock[key] = sku.Inventory[0].Quantity
+			}
+			if sku.Price.Amount != "" {
+				price, _ := strconv.ParseFloat(sku.Price.Amount, 64)
+				m.prices[key] = price
+			}
+		}
+		writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]interface{}{}})
+
+	case strings.HasSuffix(path, "/shipping_info/update"):
+		orderID := pathSegmentAfter(path, "orders")
+		var body struct {
+			TrackingNumber     string `json:"tracking_number"`
+			ShippingProviderID string `json:"shipping_provider_id"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		if !m.markShipped(orderID) {
+			writeJSON(w, map[string]interface{}{"code": 21011001, "message": "order not found"})
+			return
+		}
+		m.shipments = append(m.shipments, channel.Shipment{
+			ExternalOrderID: orderID,
+			TrackingNumber:  body.TrackingNumber,
+			Carrier:         body.ShippingProviderID,
+		})
+		writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]interface{}{}})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ordersSince returns the orders created at or after the given time, oldest first
+func (m *Marketplace) ordersSince(since time.Time) []channel.ExternalOrder {
+	var result []channel.ExternalOrder
+	for _, order := range m.orders {
+		if !order.CreatedAt.Before(since) {
+			result = append(result, order)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// paginate returns the page of orders starting at the offset in token and the token of the next page,
+// empty on the last page. Shopee cursors and TikTok page tokens are opaque, the stand-in uses offsets
+func paginate(orders []channel.ExternalOrder, token, pageSize string) ([]channel.ExternalOrder, string) {
+	offset, _ := strconv.Atoi(token)
+	if offset > len(orders) {
+		offset = len(orders)
+	}
+	size, _ := strconv.Atoi(pageSize)
+	if size <= 0 || offset+size >= len(orders) {
+		return orders[offset:], ""
+	}
+	return orders[offset : offset+size], strconv.Itoa(offset + size)
+}
+
+func (m *Marketplace) markShipped(externalID string) bool {
+	for i := range m.orders {
+		if m.orders[i].ExternalID == externalID {
+			m.orders[i].Status = channel.ExternalStatusShipped
+			return true
+		}
+	}
+	return false
+}
+
+// lazadaItemID returns a stable order_item_id for one unit of an order line
+func (m *Marketplace) lazadaItemID(orderID, sku string, unit int64) string {
+	key := fmt.Sprintf("%s|%s|%d", orderID, sku, unit)
+	if itemID, exists := m.itemIDs[key]; exists {
+		return itemID
+	}
+	m.nextItemID++
+	itemID := strconv.FormatInt(m.nextItemID, 10)
+	m.itemIDs[key] = itemID
+	m.itemOrders[itemID] = orderID
+	return itemID
+}
+
+func shopeeStatus(status string) string {
+	switch status {
+	case channel.ExternalStatusPaid:
+		return "READY_TO_SHIP"
+	case channel.ExternalStatusShipped:
+		return "SHIPPED"
+	case channel.ExternalStatusCompleted:
+		return "COMPLETED"
+	case channel.ExternalStatusCanceled:
+		return "CANCELLED"
+	default:
+		return "UNPAID"
+	}
+}
+
+func lazadaStatus(status string) string {
+	switch status {
+	case channel.ExternalStatusPaid:
+		return "pending"
+	case channel.ExternalStatusShipped:
+		return "shipped"
+	case channel.ExternalStatusCompleted:
+		return "delivered"
+	case channel.ExternalStatusCanceled:
+		return "canceled"
+	default:
+		return "unpaid"
+	}
+}
+
+func tiktokStatus(status string) string {
+	switch status {
+	case channel.ExternalStatusPaid:
+		return "AWAITING_SHIPMENT"
+	case channel.ExternalStatusShipped:
+		return "IN_TRANSIT"
+	case channel.ExternalStatusCompleted:
+		return "COMPLETED"
+	case channel.ExternalStatusCanceled:
+		return "CANCELLED"
+	default:
+		return "UNPAID"
+	}
+}
+
+// pathSegmentAfter returns the path segment that follows name ("products/123/..." -> "123")
+func pathSegmentAfter(path, name string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == name {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, out interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
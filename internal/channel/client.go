@@ -0,0 +1,116 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHTTPTimeout is used when the config doesn't provide an http client
+const defaultHTTPTimeout = 30 * time.Second
+
+// apiClient is the small HTTP helper shared by all adapters
+type apiClient struct {
+	baseURL *url.URL
+	http    *http.Client
+}
+
+func newAPIClient(cfg Config) (*apiClient, error) {
+	baseURL, err := url.Parse(strings.TrimSpace(cfg.BaseURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid api endpoint: %w", err)
+	}
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid api endpoint %q", cfg.BaseURL)
+	}
+	// Make sure relative paths are appended to the base path
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+
+	return &apiClient{
+		baseURL: baseURL,
+		http:    httpClient,
+	}, nil
+}
+
+// endpoint resolves an API path (e.g. "order/get_order_list") against the base URL
+func (c *apiClient) endpoint(path string) *url.URL {
+	return c.baseURL.ResolveReference(&url.URL{Path: strings.TrimPrefix(path, "/")})
+}
+
+// do sends the request and decodes the JSON response into out
+func (c *apiClient) do(
+	ctx context.Context,
+	method string,
+	endpoint *url.URL,
+	body []byte,
+	headers map[string]string,
+	out interface{},
+) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", endpoint.Path, err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response of %s: %w", endpoint.Path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d: %s", endpoint.Path, resp.StatusCode, truncate(string(payload), 200))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(payload, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", endpoint.Path, err)
+	}
+	return nil
+}
+
+// hmacSHA256Hex signs the message with the secret and returns lower-case hex
+func hmacSHA256Hex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max] + "..."
+}
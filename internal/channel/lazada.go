@@ -0,0 +1,328 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lazadaPageSize is the max page size of /orders/get
+const lazadaPageSize = 100
+
+// lazadaTimeLayout is the timestamp format used in Lazada order payloads
+const lazadaTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// LazadaAdapter talks to the Lazada Open Platform API
+type LazadaAdapter struct {
+	client      *apiClient
+	credentials Credentials
+	now         func() time.Time
+}
+
+// NewLazadaAdapter creates a Lazada adapter
+func NewLazadaAdapter(cfg Config) (Adapter, error) {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &LazadaAdapter{
+		client:      client,
+		credentials: cfg.Credentials,
+		now:         time.Now,
+	}, nil
+}
+
+func (a *LazadaAdapter) Name() string {
+	return PlatformLazada
+}
+
+type lazadaEnvelope struct {
+	Code    string          `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type lazadaOrders struct {
+	Count  int `json:"count"`
+	Orders []struct {
+		OrderID           json.Number `json:"order_id"`
+		CreatedAt         string      `json:"created_at"`
+		Statuses          []string    `json:"statuses"`
+		CustomerFirstName string      `json:"customer_first_name"`
+		CustomerLastName  string      `json:"customer_last_name"`
+		AddressShipping   struct {
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Phone     string `json:"phone"`
+			Address1  string `json:"address1"`
+			City      string `json:"city"`
+		} `json:"address_shipping"`
+	} `json:"orders"`
+}
+
+type lazadaOrderItems []struct {
+	OrderID    json.Number `json:"order_id"`
+	OrderItems []struct {
+		OrderItemID json.Number `json:"order_item_id"`
+		SKU         string      `json:"sku"`
+		PaidPrice   float64     `json:"paid_price"`
+	} `json:"order_items"`
+}
+
+// lazadaSkuPayload is the XML payload of /product/price_quantity/update
+type lazadaSkuPayload struct {
+	XMLName xml.Name    `xml:"Request"`
+	Skus    []lazadaSku `xml:"Product>Skus>Sku"`
+}
+
+type lazadaSku struct {
+	SellerSku string   `xml:"SellerSku"`
+	Quantity  *int     `xml:"Quantity,omitempty"`
+	Price     *float64 `xml:"Price,omitempty"`
+}
+
+func (a *LazadaAdapter) PullOrders(ctx context.Context, since time.Time) ([]ExternalOrder, error) {
+	var orders []ExternalOrder
+	ordersByID := make(map[string]int)
+
+	for offset := 0; ; offset += lazadaPageSize {
+		params := url.Values{}
+		params.Set("created_after", since.Format(time.RFC3339))
+		params.Set("sort_direction", "ASC")
+		params.Set("offset", strconv.Itoa(offset))
+		params.Set("limit", strconv.Itoa(lazadaPageSize))
+
+		var page lazadaOrders
+		if err := a.call(ctx, http.MethodGet, "orders/get", params, &page); err != nil {
+			return nil, fmt.Errorf("failed to get lazada orders: %w", err)
+		}
+
+		var pageIDs []string
+		for _, o := range page.Orders {
+			createdAt, err := time.Parse(lazadaTimeLayout, o.CreatedAt)
+			if err != nil {
+				createdAt = a.now()
+			}
+			status := ""
+			if len(o.Statuses) > 0 {
+				status = o.Statuses[0]
+			}
+
+			shipping := o.AddressShipping
+			order := ExternalOrder{
+				ExternalID: o.OrderID.String(),
+				Status:     lazadaStatus(status),
+				BuyerName: firstNonEmpty(
+					strings.TrimSpace(shipping.FirstName+" "+shipping.LastName),
+					strings.TrimSpace(o.CustomerFirstName+" "+o.CustomerLastName),
+				),
+				BuyerPhone:      shipping.Phone,
+				ShippingAddress: strings.Trim(strings.TrimSpace(shipping.Address1+", "+shipping.City), ", "),
+				CreatedAt:       createdAt,
+			}
+			ordersByID[order.ExternalID] = len(orders)
+			orders = append(orders, order)
+			pageIDs = append(pageIDs, order.ExternalID)
+		}
+
+		if len(pageIDs) > 0 {
+			items, err := a.getOrderItems(ctx, pageIDs)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range items {
+				index, exists := ordersByID[entry.OrderID.String()]
+				if !exists {
+					continue
+				}
+				// Lazada returns one order item per unit, group them by SKU
+				lines := make(map[string]int)
+				for _, item := range entry.OrderItems {
+					if line, seen := lines[item.SKU]; seen {
+						orders[index].Items[line].Quantity++
+						continue
+					}
+					lines[item.SKU] = len(orders[index].Items)
+					orders[index].Items = append(orders[index].Items, ExternalOrderItem{
+						SKU:       item.SKU,
+						Quantity:  1,
+						UnitPrice: item.PaidPrice,
+					})
+				}
+			}
+		}
+
+		if len(page.Orders) < lazadaPageSize {
+			break
+		}
+	}
+
+	return orders, nil
+}
+
+func (a *LazadaAdapter) PushStock(ctx context.Context, updates []StockUpdate) error {
+	var payload lazadaSkuPayload
+	for _, update := range updates {
+		quantity := update.Quantity
+		payload.Skus = append(payload.Skus, lazadaSku{SellerSku: update.SKU, Quantity: &quantity})
+	}
+	if err := a.updatePriceQuantity(ctx, payload); err != nil {
+		return fmt.Errorf("failed to update lazada stock: %w", err)
+	}
+	return nil
+}
+
+func (a *LazadaAdapter) PushPrices(ctx context.Context, updates []PriceUpdate) error {
+	var payload lazadaSkuPayload
+	for _, update := range updates {
+		price := update.Price
+		payload.Skus = append(payload.Skus, lazadaSku{SellerSku: update.SKU, Price: &price})
+	}
+	if err := a.updatePriceQuantity(ctx, payload); err != nil {
+		return fmt.Errorf("failed to update lazada prices: %w", err)
+	}
+	return nil
+}
+
+func (a *LazadaAdapter) AcknowledgeShipment(ctx context.Context, shipment Shipment) error {
+	// Lazada ships order items, not orders
+	items, err := a.getOrderItems(ctx, []string{shipment.ExternalOrderID})
+	if err != nil {
+		return err
+	}
+	var itemIDs []string
+	for _, entry := range items {
+		for _, item := range entry.OrderItems {
+			itemIDs = append(itemIDs, item.OrderItemID.String())
+		}
+	}
+	if len(itemIDs) == 0 {
+		return fmt.Errorf("lazada order %s has no items", shipment.ExternalOrderID)
+	}
+
+	params := url.Values{}
+	params.Set("order_item_ids", "["+strings.Join(itemIDs, ",")+"]")
+	params.Set("delivery_type", "dropship")
+	params.Set("shipment_provider", shipment.Carrier)
+	params.Set("tracking_number", shipment.TrackingNumber)
+	if err := a.call(ctx, http.MethodPost, "order/rts", params, nil); err != nil {
+		return fmt.Errorf("failed to ship lazada order %s: %w", shipment.ExternalOrderID, err)
+	}
+	return nil
+}
+
+func (a *LazadaAdapter) getOrderItems(ctx context.Context, orderIDs []string) (lazadaOrderItems, error) {
+	params := url.Values{}
+	params.Set("order_ids", "["+strings.Join(orderIDs, ",")+"]")
+
+	var items lazadaOrderItems
+	if err := a.call(ctx, http.MethodGet, "orders/items/get", params, &items); err != nil {
+		return nil, fmt.Errorf("failed to get lazada order items: %w", err)
+	}
+	return items, nil
+}
+
+func (a *LazadaAdapter) updatePriceQuantity(ctx context.Context, payload lazadaSkuPayload) error {
+	if len(payload.Skus) == 0 {
+		return nil
+	}
+	encoded, err := xml.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("payload", string(encoded))
+	return a.call(ctx, http.MethodPost, "product/price_quantity/update", params, nil)
+}
+
+// call adds the common Lazada parameters, signs them and unwraps the envelope
+// POST parameters are sent form-encoded like the official SDK does
+func (a *LazadaAdapter) call(
+	ctx context.Context,
+	method string,
+	path string,
+	params url.Values,
+	out interface{},
+) error {
+	endpoint := a.client.endpoint(path)
+
+	all := url.Values{}
+	for key, values := range params {
+		all[key] = values
+	}
+	all.Set("app_key", a.credentials.AppKey)
+	all.Set("timestamp", strconv.FormatInt(a.now().UnixMilli(), 10))
+	all.Set("sign_method", "sha256")
+	all.Set("access_token", a.credentials.AccessToken)
+	all.Set("sign", a.sign("/"+strings.TrimPrefix(path, "/"), all))
+
+	var (
+		body    []byte
+		headers map[string]string
+	)
+	if method == http.MethodGet {
+		endpoint.RawQuery = all.Encode()
+	} else {
+		body = []byte(all.Encode())
+		headers = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	}
+
+	var envelope lazadaEnvelope
+	if err := a.client.do(ctx, method, endpoint, body, headers, &envelope); err != nil {
+		return err
+	}
+	if envelope.Code != "0" {
+		return fmt.Errorf("lazada error %s: %s", envelope.Code, envelope.Message)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("failed to decode lazada response: %w", err)
+		}
+	}
+	return nil
+}
+
+// sign = upper(hex(HMAC-SHA256(app_secret, api_name + sorted(key + value))))
+func (a *LazadaAdapter) sign(apiName string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "sign" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(apiName)
+	for _, key := range keys {
+		builder.WriteString(key)
+		builder.WriteString(params.Get(key))
+	}
+	return strings.ToUpper(hmacSHA256Hex(a.credentials.AppSecret, builder.String()))
+}
+
+// lazadaStatus maps a Lazada order status to the normalized status
+func lazadaStatus(status string) string {
+	switch status {
+	case "unpaid":
+		return ExternalStatusPending
+	case "pending", "packed", "repacked":
+		return ExternalStatusPaid
+	case "ready_to_ship", "shipped":
+		return ExternalStatusShipped
+	case "delivered":
+		return ExternalStatusCompleted
+	case "canceled", "returned", "failed", "shipped_back":
+		return ExternalStatusCanceled
+	default:
+		return ExternalStatusPending
+	}
+}
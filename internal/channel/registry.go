@@ -0,0 +1,73 @@
+package channel
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Platform names as stored in the platform table
+const (
+	PlatformShopee     = "shopee"
+	PlatformLazada     = "lazada"
+	PlatformTikTokShop = "tiktok shop"
+)
+
+// Factory creates an adapter from its configuration
+type Factory func(cfg Config) (Adapter, error)
+
+// Registry keeps the adapter factories keyed by platform name
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]Factory),
+	}
+}
+
+// DefaultRegistry creates a registry with all built-in marketplace adapters
+func DefaultRegistry() *Registry {
+	registry := NewRegistry()
+	registry.Register(PlatformShopee, NewShopeeAdapter)
+	registry.Register(PlatformLazada, NewLazadaAdapter)
+	registry.Register(PlatformTikTokShop, NewTikTokAdapter)
+	return registry
+}
+
+// Register adds (or replaces) the factory for a platform name
+func (r *Registry) Register(name string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[NormalizeName(name)] = factory
+}
+
+// New creates the adapter registered for the platform name
+func (r *Registry) New(name string, cfg Config) (Adapter, error) {
+	r.mu.RLock()
+	factory, exists := r.factories[NormalizeName(name)]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("no channel adapter registered for platform %q", name)
+	}
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("platform %q has no api endpoint", name)
+	}
+	return factory(cfg)
+}
+
+// Names returns the registered platform names in alphabetical order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,285 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// shopeePageSize is the max page size of get_order_list and get_order_detail
+const shopeePageSize = 50
+
+// ShopeeAdapter talks to the Shopee Open Platform v2 API
+type ShopeeAdapter struct {
+	client      *apiClient
+	credentials Credentials
+	now         func() time.Time
+}
+
+// NewShopeeAdapter creates a Shopee adapter
+func NewShopeeAdapter(cfg Config) (Adapter, error) {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ShopeeAdapter{
+		client:      client,
+		credentials: cfg.Credentials,
+		now:         time.Now,
+	}, nil
+}
+
+func (a *ShopeeAdapter) Name() string {
+	return PlatformShopee
+}
+
+type shopeeEnvelope struct {
+	Error    string          `json:"error"`
+	Message  string          `json:"message"`
+	Response json.RawMessage `json:"response"`
+}
+
+type shopeeOrderList struct {
+	OrderList []struct {
+		OrderSN string `json:"order_sn"`
+	} `json:"order_list"`
+	More       bool   `json:"more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+type shopeeOrderDetail struct {
+	OrderList []struct {
+		OrderSN          string `json:"order_sn"`
+		OrderStatus      string `json:"order_status"`
+		CreateTime       int64  `json:"create_time"`
+		BuyerUsername    string `json:"buyer_username"`
+		RecipientAddress struct {
+			Name        string `json:"name"`
+			Phone       string `json:"phone"`
+			FullAddress string `json:"full_address"`
+		} `json:"recipient_address"`
+		ItemList []struct {
+			ItemSKU                string  `json:"item_sku"`
+			ModelSKU               string  `json:"model_sku"`
+			ModelQuantityPurchased int64   `json:"model_quantity_purchased"`
+			ModelDiscountedPrice   float64 `json:"model_discounted_price"`
+		} `json:"item_list"`
+	} `json:"order_list"`
+}
+
+func (a *ShopeeAdapter) PullOrders(ctx context.Context, since time.Time) ([]ExternalOrder, error) {
+	// Collect order numbers page by page
+	var orderSNs []string
+	cursor := ""
+	for {
+		params := url.Values{}
+		params.Set("time_range_field", "create_time")
+		params.Set("time_from", strconv.FormatInt(since.Unix(), 10))
+		params.Set("time_to", strconv.FormatInt(a.now().Unix(), 10))
+		params.Set("page_size", strconv.Itoa(shopeePageSize))
+		params.Set("cursor", cursor)
+
+		var page shopeeOrderList
+		if err := a.call(ctx, http.MethodGet, "order/get_order_list", params, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to get shopee order list: %w", err)
+		}
+		for _, order := range page.OrderList {
+			orderSNs = append(orderSNs, order.OrderSN)
+		}
+		if !page.More || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	// Fetch details in batches
+	var orders []ExternalOrder
+	for start := 0; start < len(orderSNs); start += shopeePageSize {
+		end := start + shopeePageSize
+		if end > len(orderSNs) {
+			end = len(orderSNs)
+		}
+
+		params := url.Values{}
+		params.Set("order_sn_list", strings.Join(orderSNs[start:end], ","))
+		params.Set("response_optional_fields", "buyer_username,recipient_address,item_list")
+
+		var detail shopeeOrderDetail
+		if err := a.call(ctx, http.MethodGet, "order/get_order_detail", params, nil, &detail); err != nil {
+			return nil, fmt.Errorf("failed to get shopee order detail: %w", err)
+		}
+
+		for _, o := range detail.OrderList {
+			order := ExternalOrder{
+				ExternalID:      o.OrderSN,
+				Status:          shopeeStatus(o.OrderStatus),
+				BuyerName:       firstNonEmpty(o.RecipientAddress.Name, o.BuyerUsername),
+				BuyerPhone:      o.RecipientAddress.Phone,
+				ShippingAddress: o.RecipientAddress.FullAddress,
+				CreatedAt:       time.Unix(o.CreateTime, 0),
+			}
+			for _, item := range o.ItemList {
+				order.Items = append(order.Items, ExternalOrderItem{
+					SKU:       firstNonEmpty(item.ModelSKU, item.ItemSKU),
+					Quantity:  item.ModelQuantityPurchased,
+					UnitPrice: item.ModelDiscountedPrice,
+				})
+			}
+			orders = append(orders, order)
+		}
+	}
+
+	return orders, nil
+}
+
+func (a *ShopeeAdapter) PushStock(ctx context.Context, updates []StockUpdate) error {
+	// Shopee updates stock per item (product), grouped by model (variant)
+	byItem := make(map[string][]map[string]interface{})
+	for _, update := range updates {
+		if update.ExternalProductID == "" {
+			return fmt.Errorf("sku %s: shopee item id is required", update.SKU)
+		}
+		byItem[update.ExternalProductID] = append(byItem[update.ExternalProductID], map[string]interface{}{
+			"model_id":     parseIDOrZero(update.ExternalVariantID),
+			"seller_stock": []map[string]interface{}{{"stock": update.Quantity}},
+		})
+	}
+
+	for itemID, stockList := range byItem {
+		body := map[string]interface{}{
+			"item_id":    parseIDOrZero(itemID),
+			"stock_list": stockList,
+		}
+		if err := a.call(ctx, http.MethodPost, "product/update_stock", nil, body, nil); err != nil {
+			return fmt.Errorf("failed to update shopee stock of item %s: %w", itemID, err)
+		}
+	}
+	return nil
+}
+
+func (a *ShopeeAdapter) PushPrices(ctx context.Context, updates []PriceUpdate) error {
+	byItem := make(map[string][]map[string]interface{})
+	for _, update := range updates {
+		if update.ExternalProductID == "" {
+			return fmt.Errorf("sku %s: shopee item id is required", update.SKU)
+		}
+		byItem[update.ExternalProductID] = append(byItem[update.ExternalProductID], map[string]interface{}{
+			"model_id":       parseIDOrZero(update.ExternalVariantID),
+			"original_price": update.Price,
+		})
+	}
+
+	for itemID, priceList := range byItem {
+		body := map[string]interface{}{
+			"item_id":    parseIDOrZero(itemID),
+			"price_list": priceList,
+		}
+		if err := a.call(ctx, http.MethodPost, "product/update_price", nil, body, nil); err != nil {
+			return fmt.Errorf("failed to update shopee price of item %s: %w", itemID, err)
+		}
+	}
+	return nil
+}
+
+func (a *ShopeeAdapter) AcknowledgeShipment(ctx context.Context, shipment Shipment) error {
+	body := map[string]interface{}{
+		"order_sn": shipment.ExternalOrderID,
+		"non_integrated": map[string]interface{}{
+			"tracking_number": shipment.TrackingNumber,
+		},
+	}
+	if err := a.call(ctx, http.MethodPost, "logistics/ship_order", nil, body, nil); err != nil {
+		return fmt.Errorf("failed to ship shopee order %s: %w", shipment.ExternalOrderID, err)
+	}
+	return nil
+}
+
+// call signs the request with the common Shopee parameters and unwraps the envelope
+func (a *ShopeeAdapter) call(
+	ctx context.Context,
+	method string,
+	path string,
+	params url.Values,
+	body interface{},
+	out interface{},
+) error {
+	endpoint := a.client.endpoint(path)
+	timestamp := strconv.FormatInt(a.now().Unix(), 10)
+
+	// sign = HMAC-SHA256(partner_key, partner_id + api_path + timestamp + access_token + shop_id)
+	baseString := a.credentials.AppKey + endpoint.Path + timestamp + a.credentials.AccessToken + a.credentials.ShopID
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("partner_id", a.credentials.AppKey)
+	query.Set("timestamp", timestamp)
+	query.Set("access_token", a.credentials.AccessToken)
+	query.Set("shop_id", a.credentials.ShopID)
+	query.Set("sign", hmacSHA256Hex(a.credentials.AppSecret, baseString))
+	endpoint.RawQuery = query.Encode()
+
+	var payload []byte
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = encoded
+	}
+
+	var envelope shopeeEnvelope
+	if err := a.client.do(ctx, method, endpoint, payload, nil, &envelope); err != nil {
+		return err
+	}
+	if envelope.Error != "" {
+		return fmt.Errorf("shopee error %s: %s", envelope.Error, envelope.Message)
+	}
+	if out != nil && len(envelope.Response) > 0 {
+		if err := json.Unmarshal(envelope.Response, out); err != nil {
+			return fmt.Errorf("failed to decode shopee response: %w", err)
+		}
+	}
+	return nil
+}
+
+// shopeeStatus maps a Shopee order_status to the normalized status
+func shopeeStatus(status string) string {
+	switch status {
+	case "UNPAID":
+		return ExternalStatusPending
+	case "READY_TO_SHIP", "PROCESSED", "RETRY_SHIP":
+		return ExternalStatusPaid
+	case "SHIPPED", "TO_CONFIRM_RECEIVE":
+		return ExternalStatusShipped
+	case "COMPLETED":
+		return ExternalStatusCompleted
+	case "CANCELLED", "IN_CANCEL", "TO_RETURN":
+		return ExternalStatusCanceled
+	default:
+		return ExternalStatusPending
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func parseIDOrZero(value string) int64 {
+	id, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
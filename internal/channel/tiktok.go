@@ -0,0 +1,276 @@
+package channel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tiktokPageSize is the max page size of the order search API
+const tiktokPageSize = 50
+
+// tiktokDefaultCurrency is used when the config doesn't set a currency
+const tiktokDefaultCurrency = "VND"
+
+// TikTokAdapter talks to the TikTok Shop Partner API (version 202309)
+type TikTokAdapter struct {
+	client      *apiClient
+	credentials Credentials
+	currency    string
+	now         func() time.Time
+}
+
+// NewTikTokAdapter creates a TikTok Shop adapter
+func NewTikTokAdapter(cfg Config) (Adapter, error) {
+	client, err := newAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	currency := cfg.Currency
+	if currency == "" {
+		currency = tiktokDefaultCurrency
+	}
+	return &TikTokAdapter{
+		client:      client,
+		credentials: cfg.Credentials,
+		currency:    currency,
+		now:         time.Now,
+	}, nil
+}
+
+func (a *TikTokAdapter) Name() string {
+	return PlatformTikTokShop
+}
+
+type tiktokEnvelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type tiktokOrderSearch struct {
+	Orders []struct {
+		ID               string `json:"id"`
+		Status           string `json:"status"`
+		CreateTime       int64  `json:"create_time"`
+		BuyerEmail       string `json:"buyer_email"`
+		RecipientAddress struct {
+			Name        string `json:"name"`
+			PhoneNumber string `json:"phone_number"`
+			FullAddress string `json:"full_address"`
+		} `json:"recipient_address"`
+		LineItems []struct {
+			SellerSKU string `json:"seller_sku"`
+			SalePrice string `json:"sale_price"`
+		} `json:"line_items"`
+	} `json:"orders"`
+	NextPageToken string `json:"next_page_token"`
+}
+
+func (a *TikTokAdapter) PullOrders(ctx context.Context, since time.Time) ([]ExternalOrder, error) {
+	var orders []ExternalOrder
+	pageToken := ""
+	for {
+		params := url.Values{}
+		params.Set("page_size", strconv.Itoa(tiktokPageSize))
+		if pageToken != "" {
+			params.Set("page_token", pageToken)
+		}
+		body := map[string]interface{}{
+			"create_time_ge": since.Unix(),
+		}
+
+		var page tiktokOrderSearch
+		if err := a.call(ctx, http.MethodPost, "order/202309/orders/search", params, body, &page); err != nil {
+			return nil, fmt.Errorf("failed to search tiktok orders: %w", err)
+		}
+
+		for _, o := range page.Orders {
+			order := ExternalOrder{
+				ExternalID:      o.ID,
+				Status:          tiktokStatus(o.Status),
+				BuyerName:       o.RecipientAddress.Name,
+				BuyerPhone:      o.RecipientAddress.PhoneNumber,
+				BuyerEmail:      o.BuyerEmail,
+				ShippingAddress: o.RecipientAddress.FullAddress,
+				CreatedAt:       time.Unix(o.CreateTime, 0),
+			}
+			// TikTok returns one line item per unit, group them by SKU
+			lines := make(map[string]int)
+			for _, item := range o.LineItems {
+				if line, seen := lines[item.SellerSKU]; seen {
+					order.Items[line].Quantity++
+					continue
+				}
+				price, _ := strconv.ParseFloat(item.SalePrice, 64)
+				lines[item.SellerSKU] = len(order.Items)
+				order.Items = append(order.Items, ExternalOrderItem{
+					SKU:       item.SellerSKU,
+					Quantity:  1,
+					UnitPrice: price,
+				})
+			}
+			orders = append(orders, order)
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return orders, nil
+}
+
+func (a *TikTokAdapter) PushStock(ctx context.Context, updates []StockUpdate) error {
+	byProduct := make(map[string][]map[string]interface{})
+	for _, update := range updates {
+		if update.ExternalProductID == "" || update.ExternalVariantID == "" {
+			return fmt.Errorf("sku %s: tiktok product id and sku id are required", update.SKU)
+		}
+		byProduct[update.ExternalProductID] = append(byProduct[update.ExternalProductID], map[string]interface{}{
+			"id":        update.ExternalVariantID,
+			"inventory": []map[string]interface{}{{"quantity": update.Quantity}},
+		})
+	}
+
+	for productID, skus := range byProduct {
+		path := "product/202309/products/" + url.PathEscape(productID) + "/inventory/update"
+		if err := a.call(ctx, http.MethodPost, path, nil, map[string]interface{}{"skus": skus}, nil); err != nil {
+			return fmt.Errorf("failed to update tiktok inventory of product %s: %w", productID, err)
+		}
+	}
+	return nil
+}
+
+func (a *TikTokAdapter) PushPrices(ctx context.Context, updates []PriceUpdate) error {
+	byProduct := make(map[string][]map[string]interface{})
+	for _, update := range updates {
+		if update.ExternalProductID == "" || update.ExternalVariantID == "" {
+			return fmt.Errorf("sku %s: tiktok product id and sku id are required", update.SKU)
+		}
+		byProduct[update.ExternalProductID] = append(byProduct[update.ExternalProductID], map[string]interface{}{
+			"id": update.ExternalVariantID,
+			"price": map[string]interface{}{
+				"amount":   strconv.FormatFloat(update.Price, 'f', 2, 64),
+				"currency": a.currency,
+			},
+		})
+	}
+
+	for productID, skus := range byProduct {
+		path := "product/202309/products/" + url.PathEscape(productID) + "/prices/update"
+		if err := a.call(ctx, http.MethodPost, path, nil, map[string]interface{}{"skus": skus}, nil); err != nil {
+			return fmt.Errorf("failed to update tiktok prices of product %s: %w", productID, err)
+		}
+	}
+	return nil
+}
+
+func (a *TikTokAdapter) AcknowledgeShipment(ctx context.Context, shipment Shipment) error {
+	path := "fulfillment/202309/orders/" + url.PathEscape(shipment.ExternalOrderID) + "/shipping_info/update"
+	body := map[string]interface{}{
+		"tracking_number":      shipment.TrackingNumber,
+		"shipping_provider_id": shipment.Carrier,
+	}
+	if err := a.call(ctx, http.MethodPost, path, nil, body, nil); err != nil {
+		return fmt.Errorf("failed to update tiktok shipping info of order %s: %w", shipment.ExternalOrderID, err)
+	}
+	return nil
+}
+
+// call adds the common TikTok Shop parameters, signs the request and unwraps the envelope
+func (a *TikTokAdapter) call(
+	ctx context.Context,
+	method string,
+	path string,
+	params url.Values,
+	body interface{},
+	out interface{},
+) error {
+	endpoint := a.client.endpoint(path)
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("app_key", a.credentials.AppKey)
+	query.Set("timestamp", strconv.FormatInt(a.now().Unix(), 10))
+	if a.credentials.ShopID != "" {
+		query.Set("shop_cipher", a.credentials.ShopID)
+	}
+
+	var payload []byte
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		payload = encoded
+	}
+
+	query.Set("sign", a.sign("/"+strings.TrimPrefix(path, "/"), query, payload))
+	endpoint.RawQuery = query.Encode()
+
+	headers := map[string]string{"x-tts-access-token": a.credentials.AccessToken}
+
+	var envelope tiktokEnvelope
+	if err := a.client.do(ctx, method, endpoint, payload, headers, &envelope); err != nil {
+		return err
+	}
+	if envelope.Code != 0 {
+		return fmt.Errorf("tiktok error %d: %s", envelope.Code, envelope.Message)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("failed to decode tiktok response: %w", err)
+		}
+	}
+	return nil
+}
+
+// sign = hex(HMAC-SHA256(app_secret, app_secret + path + sorted(key + value) + body + app_secret))
+func (a *TikTokAdapter) sign(path string, params url.Values, body []byte) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		if key != "sign" && key != "access_token" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(a.credentials.AppSecret)
+	builder.WriteString(path)
+	for _, key := range keys {
+		builder.WriteString(key)
+		builder.WriteString(params.Get(key))
+	}
+	builder.Write(body)
+	builder.WriteString(a.credentials.AppSecret)
+	return hmacSHA256Hex(a.credentials.AppSecret, builder.String())
+}
+
+// tiktokStatus maps a TikTok Shop order status to the normalized status
+func tiktokStatus(status string) string {
+	switch status {
+	case "UNPAID":
+		return ExternalStatusPending
+	case "ON_HOLD", "AWAITING_SHIPMENT", "AWAITING_COLLECTION":
+		return ExternalStatusPaid
+	case "PARTIALLY_SHIPPING", "IN_TRANSIT":
+		return ExternalStatusShipped
+	case "DELIVERED", "COMPLETED":
+		return ExternalStatusCompleted
+	case "CANCELLED":
+		return ExternalStatusCanceled
+	default:
+		return ExternalStatusPending
+	}
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,6 +15,9 @@ import (
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
+	Channel  ChannelConfig
+	Auth     AuthConfig
+	Mail     MailConfig
 }
 
 // ServerConfig contains server configuration
@@ -20,6 +25,8 @@ type ServerConfig struct {
 	Host string
 	Port string
 	Env  string
+	// LogLevel is the lowest level logged, SQL queries are logged at debug level
+	LogLevel slog.Level
 }
 
 // DatabaseConfig contains database configuration
@@ -34,6 +41,63 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration
 }
 
+// AuthConfig contains authentication configuration
+type AuthConfig struct {
+	// JWTSecret signs the access tokens (HS256)
+	JWTSecret       string
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// AdminEmail and AdminPassword create the first user able to sign in, when no user has a password yet
+	AdminEmail    string
+	AdminPassword string
+	// Lifetimes of the one-time tokens sent by email
+	InvitationTTL        time.Duration
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	// RequireTwoFactorForAdmin makes admins enable two-factor authentication before anything else
+	RequireTwoFactorForAdmin bool
+	// TOTPIssuer is the account issuer shown by authenticator apps
+	TOTPIssuer string
+}
+
+// MailConfig contains outbound email configuration
+type MailConfig struct {
+	// Driver is smtp, or outbox to write the emails to OutboxDir instead of sending them
+	Driver    string
+	From      string
+	OutboxDir string
+	// AppURL is the frontend the links in the emails point to
+	AppURL       string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+}
+
+// devJWTSecret is only accepted outside production
+const devJWTSecret = "dev-secret-change-me"
+
+// ChannelConfig contains marketplace channel configuration
+type ChannelConfig struct {
+	HTTPTimeout time.Duration
+	Currency    string
+	// Credentials are keyed by credentials reference (the env prefix), see PlatformConfig.CredentialsRef
+	Credentials map[string]ChannelCredentials
+}
+
+// ChannelCredentials contains the API credentials of one marketplace
+type ChannelCredentials struct {
+	AppKey      string
+	AppSecret   string
+	AccessToken string
+	ShopID      string
+}
+
+// defaultChannelCredentialRefs are always loaded, CHANNEL_CREDENTIAL_REFS adds more (e.g. SHOPEE_SHOP2)
+var defaultChannelCredentialRefs = []string{"SHOPEE", "LAZADA", "TIKTOK"}
+
 // Load reads the .env file and returns Config
 // If .env file doesn't exist, default values will be used
 func Load() (*Config, error) {
@@ -42,9 +106,10 @@ func Load() (*Config, error) {
 
 	config := &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("APP_ENV", "development"),
+			Host:     getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:     getEnv("SERVER_PORT", "8080"),
+			Env:      getEnv("APP_ENV", "development"),
+			LogLevel: getEnvAsLogLevel("LOG_LEVEL", slog.LevelInfo),
 		},
 		Database: DatabaseConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -56,6 +121,35 @@ func Load() (*Config, error) {
 			MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute),
 		},
+		Channel: ChannelConfig{
+			HTTPTimeout: getEnvAsDuration("CHANNEL_HTTP_TIMEOUT", 30*time.Second),
+			Currency:    getEnv("CHANNEL_CURRENCY", "VND"),
+			Credentials: loadChannelCredentials(),
+		},
+		Auth: AuthConfig{
+			JWTSecret:                getEnv("JWT_SECRET", devJWTSecret),
+			Issuer:                   getEnv("JWT_ISSUER", "simple-template"),
+			AccessTokenTTL:           getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:          getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			AdminEmail:               os.Getenv("AUTH_ADMIN_EMAIL"),
+			AdminPassword:            os.Getenv("AUTH_ADMIN_PASSWORD"),
+			InvitationTTL:            getEnvAsDuration("INVITATION_TTL", 72*time.Hour),
+			EmailVerificationTTL:     getEnvAsDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+			PasswordResetTTL:         getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+			RequireTwoFactorForAdmin: getEnvAsBool("AUTH_REQUIRE_2FA_FOR_ADMIN", false),
+			TOTPIssuer:               getEnv("TOTP_ISSUER", "Simple Template"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "outbox"),
+			From:         getEnv("MAIL_FROM", "Simple Template <no-reply@localhost>"),
+			OutboxDir:    getEnv("MAIL_OUTBOX_DIR", "tmp/outbox"),
+			AppURL:       strings.TrimRight(getEnv("APP_URL", "http://localhost:3000"), "/"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			SMTPTimeout:  getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+		},
 	}
 
 	// Validate cấu hình bắt buộc
@@ -77,9 +171,45 @@ func (c *Config) validate() error {
 	if c.Database.Name == "" {
 		return fmt.Errorf("DB_NAME is required")
 	}
+	if c.Server.Env == "production" && c.Auth.JWTSecret == devJWTSecret {
+		return fmt.Errorf("JWT_SECRET is required in production")
+	}
+	if len(c.Auth.JWTSecret) < 16 {
+		return fmt.Errorf("JWT_SECRET must be at least 16 characters")
+	}
+	switch c.Mail.Driver {
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			return fmt.Errorf("SMTP_HOST is required when MAIL_DRIVER is smtp")
+		}
+	case "outbox":
+	default:
+		return fmt.Errorf("MAIL_DRIVER must be smtp or outbox")
+	}
 	return nil
 }
 
+// loadChannelCredentials reads REF_APP_KEY, REF_APP_SECRET, REF_ACCESS_TOKEN and REF_SHOP_ID of every credentials reference
+func loadChannelCredentials() map[string]ChannelCredentials {
+	refs := append([]string{}, defaultChannelCredentialRefs...)
+	for _, ref := range strings.Split(os.Getenv("CHANNEL_CREDENTIAL_REFS"), ",") {
+		if ref = strings.ToUpper(strings.TrimSpace(ref)); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+
+	credentials := make(map[string]ChannelCredentials, len(refs))
+	for _, prefix := range refs {
+		credentials[prefix] = ChannelCredentials{
+			AppKey:      os.Getenv(prefix + "_APP_KEY"),
+			AppSecret:   os.Getenv(prefix + "_APP_SECRET"),
+			AccessToken: os.Getenv(prefix + "_ACCESS_TOKEN"),
+			ShopID:      os.Getenv(prefix + "_SHOP_ID"),
+		}
+	}
+	return credentials
+}
+
 // getEnv đọc biến môi trường, nếu không có thì trả về giá trị mặc định
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
@@ -114,3 +244,29 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return value
 }
+
+// getEnvAsBool reads environment variable as boolean
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsLogLevel reads environment variable as log level: debug, info, warn or error
+func getEnvAsLogLevel(key string, defaultValue slog.Level) slog.Level {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+	var value slog.Level
+	if err := value.UnmarshalText([]byte(valueStr)); err != nil {
+		return defaultValue
+	}
+	return value
+}
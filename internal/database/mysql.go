@@ -7,7 +7,7 @@ import (
 
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
 // DB contains database connection and goqu dialect
@@ -39,11 +39,16 @@ func Connect(cfg Config) (*DB, error) {
 		cfg.Name,
 	)
 
-	// Open database connection
-	sqlDB, err := sql.Open("mysql", dsn)
+	// Open database connection, its queries are logged at debug level
+	mysqlConfig, err := mysql.ParseDSN(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	connector, err := mysql.NewConnector(mysqlConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	sqlDB := sql.OpenDB(&queryLogConnector{Connector: connector})
 
 	// Configure connection pool
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"log/slog"
+	"time"
+)
+
+// maxLoggedQueryLength bounds the queries written to the logs, bulk inserts can be huge
+const maxLoggedQueryLength = 2000
+
+// queryLogConnector logs the queries of the connections at debug level, with the context they run in.
+// The logs of a request then carry its request id. goqu inlines the values into the queries,
+// so debug logs hold the data written and read: keep them out of production
+type queryLogConnector struct {
+	driver.Connector
+}
+
+func (c *queryLogConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &queryLogConn{conn: conn}, nil
+}
+
+// queryLogConn forwards everything to the driver connection, it only times the queries
+type queryLogConn struct {
+	conn driver.Conn
+}
+
+func (c *queryLogConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *queryLogConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &queryLogStmt{stmt: stmt, query: query}, nil
+}
+
+func (c *queryLogConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *queryLogConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *queryLogConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	// Drivers without BeginTx can only start default transactions
+	return c.conn.Begin()
+}
+
+func (c *queryLogConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	// ErrSkip sends the query to PrepareContext, the statement logs it
+	if err != driver.ErrSkip {
+		logQuery(ctx, query, start, err)
+	}
+	return result, err
+}
+
+func (c *queryLogConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		logQuery(ctx, query, start, err)
+	}
+	return rows, err
+}
+
+func (c *queryLogConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *queryLogConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *queryLogConn) IsValid() bool {
+	if validator, ok := c.conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *queryLogConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+// queryLogStmt logs the executions of a prepared statement
+type queryLogStmt struct {
+	stmt  driver.Stmt
+	query string
+}
+
+func (s *queryLogStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *queryLogStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *queryLogStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.stmt.Exec(args)
+}
+
+func (s *queryLogStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.stmt.Query(args)
+}
+
+func (s *queryLogStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		result, err = s.stmt.Exec(values(args))
+	}
+	logQuery(ctx, s.query, start, err)
+	return result, err
+}
+
+func (s *queryLogStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(values(args))
+	}
+	logQuery(ctx, s.query, start, err)
+	return rows, err
+}
+
+// values drops the names of the arguments for the statements of older drivers
+func values(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// logQuery logs the query at debug level, the duration is the time to the first row of queries
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	if len(query) > maxLoggedQueryLength {
+		query = query[:maxLoggedQueryLength] + "..."
+	}
+	attrs := []slog.Attr{
+		slog.String("query", query),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, slog.LevelDebug, "sql query", attrs...)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccountHandler struct {
+	accountUsecase *usecase.AccountUsecase
+}
+
+func NewAccountHandler(accountUsecase *usecase.AccountUsecase) *AccountHandler {
+	return &AccountHandler{
+		accountUsecase: accountUsecase,
+	}
+}
+
+// POST /api/v1/users/invite
+func (h *AccountHandler) Invite(c *fiber.Ctx) error {
+	var req model.InviteUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	user, err := h.accountUsecase.Invite(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, user, "invitation sent successfully")
+}
+
+// POST /api/v1/users/:id/invitation
+// The previous invitation link of the user stops working
+func (h *AccountHandler) ResendInvitation(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid user id", err)
+	}
+
+	if err := h.accountUsecase.ResendInvitation(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "invitation sent successfully")
+}
+
+// POST /api/v1/auth/invitation/accept
+func (h *AccountHandler) AcceptInvitation(c *fiber.Ctx) error {
+	var req model.AcceptInvitationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	user, err := h.accountUsecase.AcceptInvitation(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, user, "invitation accepted successfully, you can log in")
+}
+
+// POST /api/v1/auth/email/verification
+// Emails a verification link to the signed in user
+func (h *AccountHandler) SendEmailVerification(c *fiber.Ctx) error {
+	if err := h.accountUsecase.SendEmailVerification(c.Context(), middleware.CurrentUserID(c)); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "verification email sent successfully")
+}
+
+// POST /api/v1/auth/email/verify
+func (h *AccountHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req model.VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	user, err := h.accountUsecase.VerifyEmail(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, user, "email verified successfully")
+}
+
+// POST /api/v1/auth/password/forgot
+// Answers the same whether the email is known or not
+func (h *AccountHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.accountUsecase.ForgotPassword(c.Context(), &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "if the email is known, a password reset link has been sent")
+}
+
+// POST /api/v1/auth/password/reset
+// Every session of the user is revoked, they have to log in again
+func (h *AccountHandler) ResetPassword(c *fiber.Ctx) error {
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.accountUsecase.ResetPassword(c.Context(), &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "password reset successfully")
+}
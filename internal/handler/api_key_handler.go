@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type APIKeyHandler struct {
+	apiKeyUsecase *usecase.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(apiKeyUsecase *usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUsecase: apiKeyUsecase,
+	}
+}
+
+// GET /api/v1/api-keys
+// Lists the keys of the signed in user
+func (h *APIKeyHandler) GetMine(c *fiber.Ctx) error {
+	keys, err := h.apiKeyUsecase.GetByUser(c.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, keys, "api keys retrieved successfully")
+}
+
+// POST /api/v1/api-keys
+// The key is only in this response, store it right away
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	key, err := h.apiKeyUsecase.Create(c.Context(), middleware.CurrentUserID(c), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, key, "api key created successfully")
+}
+
+// DELETE /api/v1/api-keys/:id
+func (h *APIKeyHandler) RevokeMine(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.apiKeyUsecase.Revoke(c.Context(), middleware.CurrentUserID(c), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "api key revoked successfully")
+}
+
+// GET /api/v1/users/:id/api-keys
+func (h *APIKeyHandler) GetByUser(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	keys, err := h.apiKeyUsecase.GetByUser(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, keys, "api keys retrieved successfully")
+}
+
+// DELETE /api/v1/users/:id/api-keys/:key_id
+func (h *APIKeyHandler) RevokeForUser(c *fiber.Ctx) error {
+	userID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	keyID, err := strconv.ParseInt(c.Params("key_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid key_id", err)
+	}
+
+	if err := h.apiKeyUsecase.Revoke(c.Context(), userID, keyID); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "api key revoked successfully")
+}
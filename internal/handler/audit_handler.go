@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/pagination"
+	"simple-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	auditUsecase *usecase.AuditUsecase
+}
+
+func NewAuditHandler(auditUsecase *usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{
+		auditUsecase: auditUsecase,
+	}
+}
+
+// GET /api/v1/audit?entity_type=&entity_id=&actor_id=&action=&from=&to=
+func (h *AuditHandler) GetAll(c *fiber.Ctx) error {
+	var req pagination.Request
+	if err := c.QueryParser(&req); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+	var filter model.AuditFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+
+	entries, err := h.auditUsecase.GetAll(c.Context(), &req, &filter)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, entries, "audit logs retrieved successfully")
+}
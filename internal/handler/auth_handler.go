@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthHandler struct {
+	authUsecase *usecase.AuthUsecase
+}
+
+func NewAuthHandler(authUsecase *usecase.AuthUsecase) *AuthHandler {
+	return &AuthHandler{
+		authUsecase: authUsecase,
+	}
+}
+
+// POST /api/v1/auth/login
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	var req model.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	login, err := h.authUsecase.Login(c.Context(), &req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+	if login.TwoFactorRequired {
+		return response.Success(c, login, "two-factor code required, send it to /api/v1/auth/login/2fa")
+	}
+	return response.Success(c, login, "logged in successfully")
+}
+
+// POST /api/v1/auth/login/2fa
+// Completes the login of a user with two-factor authentication
+func (h *AuthHandler) LoginTwoFactor(c *fiber.Ctx) error {
+	var req model.LoginTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.LoginTwoFactor(c.Context(), &req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tokens, "logged in successfully")
+}
+
+// POST /api/v1/auth/refresh
+// The refresh token in the body is rotated, it can't be used again
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req model.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	tokens, err := h.authUsecase.Refresh(c.Context(), &req, clientInfo(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tokens, "tokens refreshed successfully")
+}
+
+// POST /api/v1/auth/logout
+// Revokes the session of the refresh token in the body
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req model.RefreshTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.authUsecase.Logout(c.Context(), middleware.CurrentUserID(c), &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "logged out successfully")
+}
+
+// POST /api/v1/auth/logout-all
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+	if err := h.authUsecase.LogoutAll(c.Context(), middleware.CurrentUserID(c)); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "logged out of all sessions successfully")
+}
+
+// GET /api/v1/auth/me
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	user, err := h.authUsecase.Me(c.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, user, "user retrieved successfully")
+}
+
+// PUT /api/v1/auth/password
+// Every session of the user is revoked, they have to log in again
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.authUsecase.ChangePassword(c.Context(), middleware.CurrentUserID(c), &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "password changed successfully")
+}
+
+func clientInfo(c *fiber.Ctx) model.ClientInfo {
+	return model.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+	}
+}
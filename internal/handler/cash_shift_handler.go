@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CashShiftHandler struct {
+	cashShiftUsecase *usecase.CashShiftUsecase
+}
+
+func NewCashShiftHandler(cashShiftUsecase *usecase.CashShiftUsecase) *CashShiftHandler {
+	return &CashShiftHandler{
+		cashShiftUsecase: cashShiftUsecase,
+	}
+}
+
+func (h *CashShiftHandler) Open(c *fiber.Ctx) error {
+	var req model.OpenCashShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	shift, err := h.cashShiftUsecase.Open(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, shift, "shift opened successfully")
+}
+
+func (h *CashShiftHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	shift, err := h.cashShiftUsecase.GetByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, shift, "shift retrieved successfully")
+}
+
+func (h *CashShiftHandler) AddMovement(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.CreateCashMovementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	shift, err := h.cashShiftUsecase.AddMovement(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, shift, "cash movement recorded successfully")
+}
+
+func (h *CashShiftHandler) Close(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.CloseCashShiftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	shift, err := h.cashShiftUsecase.Close(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, shift, "shift closed successfully")
+}
+
+func (h *CashShiftHandler) GetReport(c *fiber.Ctx) error {
+	var filter model.CashShiftReportFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return response.BadRequest(c, "invalid query", err)
+	}
+	if err := validateRequest(filter); err != nil {
+		return err
+	}
+
+	report, err := h.cashShiftUsecase.GetReport(c.Context(), &filter)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, report, "shift report retrieved successfully")
+}
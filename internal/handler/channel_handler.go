@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ChannelHandler struct {
+	channelSyncUsecase *usecase.ChannelSyncUsecase
+}
+
+func NewChannelHandler(channelSyncUsecase *usecase.ChannelSyncUsecase) *ChannelHandler {
+	return &ChannelHandler{
+		channelSyncUsecase: channelSyncUsecase,
+	}
+}
+
+func (h *ChannelHandler) SyncOrders(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	var req model.SyncOrdersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	result, err := h.channelSyncUsecase.SyncOrders(c.Context(), platformID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, result, "orders synchronized successfully")
+}
+
+func (h *ChannelHandler) PushStock(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	result, err := h.channelSyncUsecase.PushStock(c.Context(), platformID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, result, "stock pushed successfully")
+}
+
+func (h *ChannelHandler) PushPrices(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	result, err := h.channelSyncUsecase.PushPrices(c.Context(), platformID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, result, "prices pushed successfully")
+}
+
+func (h *ChannelHandler) AcknowledgeShipment(c *fiber.Ctx) error {
+	orderID, err := strconv.ParseInt(c.Params("order_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid order ID", err)
+	}
+
+	var req model.AcknowledgeShipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	channelOrder, err := h.channelSyncUsecase.AcknowledgeShipment(c.Context(), orderID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, channelOrder, "shipment acknowledged successfully")
+}
+
+func (h *ChannelHandler) GetSkuMappings(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	listings, err := h.channelSyncUsecase.GetListings(c.Context(), platformID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, listings, "sku mappings retrieved successfully")
+}
+
+func (h *ChannelHandler) CreateSkuMapping(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	var req model.CreateChannelSkuMappingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	mapping, err := h.channelSyncUsecase.CreateSkuMapping(c.Context(), platformID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, mapping, "sku mapping created successfully")
+}
+
+func (h *ChannelHandler) DeleteSkuMapping(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.channelSyncUsecase.DeleteSkuMapping(c.Context(), platformID, id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "sku mapping deleted successfully")
+}
+
+func (h *ChannelHandler) GetSyncRuns(c *fiber.Ctx) error {
+	platformID, err := strconv.ParseInt(c.Params("platform_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid platform ID", err)
+	}
+
+	runs, err := h.channelSyncUsecase.GetSyncRuns(c.Context(), platformID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, runs, "sync runs retrieved successfully")
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomerAddressHandler struct {
+	addressUsecase *usecase.CustomerAddressUsecase
+}
+
+func NewCustomerAddressHandler(addressUsecase *usecase.CustomerAddressUsecase) *CustomerAddressHandler {
+	return &CustomerAddressHandler{
+		addressUsecase: addressUsecase,
+	}
+}
+
+// GET /api/v1/customer/:id/addresses
+func (h *CustomerAddressHandler) GetAll(c *fiber.Ctx) error {
+	customerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	addresses, err := h.addressUsecase.GetAll(c.Context(), customerID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, addresses, "addresses retrieved successfully")
+}
+
+// POST /api/v1/customer/:id/addresses
+func (h *CustomerAddressHandler) Create(c *fiber.Ctx) error {
+	customerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.CreateCustomerAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	address, err := h.addressUsecase.Create(c.Context(), customerID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, address, "address created successfully")
+}
+
+// PUT /api/v1/customer/:id/addresses/:address_id
+func (h *CustomerAddressHandler) Update(c *fiber.Ctx) error {
+	customerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	addressID, err := strconv.ParseInt(c.Params("address_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid address id", err)
+	}
+
+	var req model.UpdateCustomerAddressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	address, err := h.addressUsecase.Update(c.Context(), customerID, addressID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, address, "address updated successfully")
+}
+
+// DELETE /api/v1/customer/:id/addresses/:address_id
+func (h *CustomerAddressHandler) Delete(c *fiber.Ctx) error {
+	customerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	addressID, err := strconv.ParseInt(c.Params("address_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid address id", err)
+	}
+
+	if err := h.addressUsecase.Delete(c.Context(), customerID, addressID); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "address deleted successfully")
+}
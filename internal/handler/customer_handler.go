@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"fmt"
 	"simple-template/internal/model"
 	"simple-template/internal/usecase"
+	"simple-template/pkg/pagination"
 	"simple-template/pkg/response"
 	"strconv"
+	"strings"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -25,13 +27,13 @@ func (h *CustomerHandler) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "Invalid request body", err)
 	}
-	if err := validator.New().Struct(req); err != nil {
-		return response.BadRequest(c, "validation failed", err)
+	if err := validateRequest(req); err != nil {
+		return err
 	}
 
 	customer, err := h.customerUsecase.CreateCustomer(c.Context(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create user", err)
+		return err
 	}
 	return response.Created(c, customer, "Customer created successfully")
 
@@ -46,20 +48,118 @@ func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
 
 	customer, err := h.customerUsecase.GetCustomerByID(c.Context(), id)
 	if err != nil {
-		return response.BadRequest(c, "customer not found", err)
+		return err
 	}
 	return response.Success(c, customer, "Customer retrieved successfully")
 }
 
+// GET /api/v1/customer?search=&tag_ids=1,2
 func (h *CustomerHandler) GetAllCustomers(c *fiber.Ctx) error {
+	var req pagination.Request
+	if err := c.QueryParser(&req); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+	tagIDs, err := parseIDList(c.Query("tag_ids"))
+	if err != nil {
+		return response.BadRequest(c, "invalid tag_ids", err)
+	}
 
-	customers, err := h.customerUsecase.GetAllCustomer(c.Context())
+	customers, err := h.customerUsecase.GetAllCustomer(c.Context(), &req, c.Query("search"), tagIDs)
 	if err != nil {
-		return response.BadRequest(c, "customer not found", err)
+		return err
 	}
 	return response.Success(c, customers, "Customer retrieved successfully")
 }
 
+// GET /api/v1/customer/:id/orders
+func (h *CustomerHandler) GetOrderHistory(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req pagination.Request
+	if err := c.QueryParser(&req); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+
+	history, err := h.customerUsecase.GetOrderHistory(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, history, "Order history retrieved successfully")
+}
+
+// GET /api/v1/customer/:id/duplicates?min_score=0.4
+func (h *CustomerHandler) GetDuplicates(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	minScore, err := strconv.ParseFloat(c.Query("min_score", "0.4"), 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid min_score", err)
+	}
+
+	duplicates, err := h.customerUsecase.FindDuplicates(c.Context(), id, minScore)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, duplicates, "Duplicates retrieved successfully")
+}
+
+// POST /api/v1/customer/:id/merge
+func (h *CustomerHandler) Merge(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.MergeCustomersRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	result, err := h.customerUsecase.MergeCustomers(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, result, "Customers merged successfully")
+}
+
+// GET /api/v1/customer/:id/export
+func (h *CustomerHandler) Export(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	export, err := h.customerUsecase.ExportCustomer(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	// Downloaded as a file, still in the response envelope
+	c.Attachment(fmt.Sprintf("customer-%d.json", id))
+	return response.Success(c, export, "Customer exported successfully")
+}
+
+// POST /api/v1/customer/:id/anonymize
+func (h *CustomerHandler) Anonymize(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	customer, err := h.customerUsecase.AnonymizeCustomer(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, customer, "Customer anonymized successfully")
+}
+
 func (h *CustomerHandler) UpdateCustomers(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -74,7 +174,7 @@ func (h *CustomerHandler) UpdateCustomers(c *fiber.Ctx) error {
 
 	customers, err := h.customerUsecase.UpdateCustomer(c.Context(), id, &req)
 	if err != nil {
-		return response.BadRequest(c, "customer not found", err)
+		return err
 	}
 	return response.Success(c, customers, "Customer updated successfully")
 }
@@ -87,7 +187,23 @@ func (h *CustomerHandler) DeleteCustomer(c *fiber.Ctx) error {
 	}
 
 	if err := h.customerUsecase.DeleteCustomer(c.Context(), id); err != nil {
-		return response.BadRequest(c, "failed", err)
+		return err
+	}
+	return response.Success(c, nil, "Customer deleted successfully")
+}
+
+// parseIDList parses a comma separated list of ids, empty items are skipped
+func parseIDList(value string) ([]int64, error) {
+	var ids []int64
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
 	}
-	return nil
+	return ids, nil
 }
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/pagination"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomerNoteHandler struct {
+	noteUsecase *usecase.CustomerNoteUsecase
+}
+
+func NewCustomerNoteHandler(noteUsecase *usecase.CustomerNoteUsecase) *CustomerNoteHandler {
+	return &CustomerNoteHandler{
+		noteUsecase: noteUsecase,
+	}
+}
+
+// GET /api/v1/customer/:id/notes
+func (h *CustomerNoteHandler) GetTimeline(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req pagination.Request
+	if err := c.QueryParser(&req); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+
+	notes, err := h.noteUsecase.GetTimeline(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, notes, "customer notes retrieved successfully")
+}
+
+// POST /api/v1/customer/:id/notes
+// The author is the authenticated user
+func (h *CustomerNoteHandler) Create(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	authorID := middleware.CurrentUserID(c)
+	if authorID == 0 {
+		return response.Unauthorized(c, "notes need an authenticated author")
+	}
+
+	var req model.CreateCustomerNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	note, err := h.noteUsecase.Create(c.Context(), id, authorID, &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, note, "customer note added successfully")
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/pagination"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LoyaltyHandler struct {
+	loyaltyUsecase *usecase.LoyaltyUsecase
+}
+
+func NewLoyaltyHandler(loyaltyUsecase *usecase.LoyaltyUsecase) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyUsecase: loyaltyUsecase,
+	}
+}
+
+// GET /api/v1/loyalty/settings
+func (h *LoyaltyHandler) GetSettings(c *fiber.Ctx) error {
+	config, err := h.loyaltyUsecase.GetSettings(c.Context())
+	if err != nil {
+		return err
+	}
+	return response.Success(c, config, "loyalty settings retrieved successfully")
+}
+
+// PUT /api/v1/loyalty/settings
+func (h *LoyaltyHandler) UpdateSettings(c *fiber.Ctx) error {
+	var req model.LoyaltyConfig
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	config, err := h.loyaltyUsecase.UpdateSettings(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, config, "loyalty settings updated successfully")
+}
+
+// POST /api/v1/loyalty/expire?customer_id=
+// Without customer_id the points of every customer are checked
+func (h *LoyaltyHandler) ExpirePoints(c *fiber.Ctx) error {
+	customerID, err := strconv.ParseInt(c.Query("customer_id", "0"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid customer_id", err)
+	}
+
+	result, err := h.loyaltyUsecase.ExpirePoints(c.Context(), customerID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, result, "points expired successfully")
+}
+
+// GET /api/v1/customer/:id/loyalty
+func (h *LoyaltyHandler) GetCustomerLoyalty(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	loyalty, err := h.loyaltyUsecase.GetCustomerLoyalty(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, loyalty, "loyalty retrieved successfully")
+}
+
+// GET /api/v1/customer/:id/loyalty/ledger
+func (h *LoyaltyHandler) GetLedger(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req pagination.Request
+	if err := c.QueryParser(&req); err != nil {
+		return response.BadRequest(c, "invalid query parameters", err)
+	}
+
+	ledger, err := h.loyaltyUsecase.GetLedger(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, ledger, "loyalty ledger retrieved successfully")
+}
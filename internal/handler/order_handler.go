@@ -6,7 +6,6 @@ import (
 	"simple-template/pkg/response"
 	"strconv"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -25,13 +24,12 @@ func (h *OrderHandler) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return response.BadRequest(c, "invalid body", err)
 	}
-	var validate = validator.New()
-	if err := validate.Struct(req); err != nil {
-		return response.BadRequest(c, "validation failed", err)
+	if err := validateRequest(req); err != nil {
+		return err
 	}
-	orders, err := h.orderUsecase.CreateOrders(c.Context(), &req)
+	orders, err := h.orderUsecase.CreateOrders(c.Context(), &req, nil)
 	if err != nil {
-		return response.BadRequest(c, "create failed", err)
+		return err
 	}
 
 	return response.Success(c, orders, "success")
@@ -40,7 +38,7 @@ func (h *OrderHandler) Create(c *fiber.Ctx) error {
 func (h *OrderHandler) GetAll(c *fiber.Ctx) error {
 	orders, err := h.orderUsecase.GetOrdersPage(c.Context())
 	if err != nil {
-		return response.BadRequest(c, "Failed to fetch", err)
+		return err
 	}
 	return response.Success(c, orders, "orders retrieved successfully")
 }
@@ -59,8 +57,34 @@ func (h *OrderHandler) UpdateStatus(c *fiber.Ctx) error {
 
 	err = h.orderUsecase.UpdateOrderStatus(c.Context(), req.Status, id)
 	if err != nil {
-		return response.BadRequest(c, "failed to update order status", err)
+		return err
 	}
 
 	return response.Success(c, nil, "order status updated successfully")
 }
+
+func (h *OrderHandler) GetRevenue(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid order ID", err)
+	}
+
+	revenue, err := h.orderUsecase.GetOrderRevenue(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, revenue, "order revenue retrieved successfully")
+}
+
+func (h *OrderHandler) GetRevenueByPlatform(c *fiber.Ctx) error {
+	var filter model.RevenueFilter
+	if err := c.QueryParser(&filter); err != nil {
+		return response.BadRequest(c, "invalid query", err)
+	}
+
+	revenues, err := h.orderUsecase.GetRevenueByPlatform(c.Context(), &filter)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, revenues, "platform revenue retrieved successfully")
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"simple-template/internal/model"
 	"simple-template/internal/usecase"
 	"simple-template/pkg/response"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,10 +19,72 @@ func NewPaymentMethodsHandler(PaymentMethodsUsecase *usecase.PaymentMethodsUseca
 	}
 }
 
+func (h *PaymentMethodsHandler) Create(c *fiber.Ctx) error {
+	var req model.CreatePaymentMethodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	paymentMethod, err := h.PaymentMethodsUsecase.Create(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, paymentMethod, "payment method created successfully")
+}
+
 func (h *PaymentMethodsHandler) GetAll(c *fiber.Ctx) error {
 	PaymentMethods, err := h.PaymentMethodsUsecase.GetAll(c.Context())
 	if err != nil {
-		return response.BadRequest(c, "failed to get", err)
+		return err
 	}
 	return response.Success(c, PaymentMethods, "payment methods retrieved successfully")
 }
+
+func (h *PaymentMethodsHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	paymentMethod, err := h.PaymentMethodsUsecase.GetByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, paymentMethod, "payment method retrieved successfully")
+}
+
+func (h *PaymentMethodsHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.UpdatePaymentMethodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	paymentMethod, err := h.PaymentMethodsUsecase.Update(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, paymentMethod, "payment method updated successfully")
+}
+
+func (h *PaymentMethodsHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.PaymentMethodsUsecase.Delete(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "payment method deleted successfully")
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"simple-template/internal/model"
 	"simple-template/internal/usecase"
 	"simple-template/pkg/response"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,10 +19,72 @@ func NewPlatformHandler(platformUsecase *usecase.PlatformUsecase) *PlatformHandl
 	}
 }
 
+func (h *PlatformHandler) Create(c *fiber.Ctx) error {
+	var req model.CreatePlatformRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	platform, err := h.platformUsecase.Create(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, platform, "Platform created successfully")
+}
+
 func (h *PlatformHandler) GetAll(c *fiber.Ctx) error {
 	platforms, err := h.platformUsecase.GetAll(c.Context())
 	if err != nil {
-		return response.BadRequest(c, "failed to get", err)
+		return err
 	}
 	return response.Success(c, platforms, "Plat form retrieved successfully")
 }
+
+func (h *PlatformHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	platform, err := h.platformUsecase.GetByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, platform, "Platform retrieved successfully")
+}
+
+func (h *PlatformHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.UpdatePlatformRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	platform, err := h.platformUsecase.Update(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, platform, "Platform updated successfully")
+}
+
+func (h *PlatformHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.platformUsecase.Delete(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "Platform deleted successfully")
+}
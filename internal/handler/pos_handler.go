@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PosHandler struct {
+	posUsecase *usecase.PosUsecase
+}
+
+func NewPosHandler(posUsecase *usecase.PosUsecase) *PosHandler {
+	return &PosHandler{
+		posUsecase: posUsecase,
+	}
+}
+
+func (h *PosHandler) Lookup(c *fiber.Ctx) error {
+	item, err := h.posUsecase.Lookup(c.Context(), c.Query("code"))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, item, "item retrieved successfully")
+}
+
+func (h *PosHandler) BuildCart(c *fiber.Ctx) error {
+	var req model.PosCartRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	cart, err := h.posUsecase.BuildCart(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, cart, "cart priced successfully")
+}
+
+func (h *PosHandler) Checkout(c *fiber.Ctx) error {
+	var req model.PosCheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	receipt, err := h.posUsecase.Checkout(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, receipt, "sale completed successfully")
+}
+
+// GetReceipt returns the receipt as JSON, or ready to print with ?format=text
+func (h *PosHandler) GetReceipt(c *fiber.Ctx) error {
+	orderID, err := strconv.ParseInt(c.Params("order_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid order id", err)
+	}
+
+	receipt, err := h.posUsecase.GetReceipt(c.Context(), orderID)
+	if err != nil {
+		return err
+	}
+	if c.Query("format") == "text" {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(receipt.PlainText())
+	}
+	return response.Success(c, receipt, "receipt retrieved successfully")
+}
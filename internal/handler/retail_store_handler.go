@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"simple-template/internal/model"
 	"simple-template/internal/usecase"
 	"simple-template/pkg/response"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,10 +19,130 @@ func NewRetailStoreHandler(RetailStoreUsecase *usecase.RetailStoreUsecase) *Reta
 	}
 }
 
+func (h *RetailStoreHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateRetailStoreRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	retailStore, err := h.RetailStoreUsecase.Create(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, retailStore, "Retail store created successfully")
+}
+
 func (h *RetailStoreHandler) GetAll(c *fiber.Ctx) error {
-	RetailStores, err := h.RetailStoreUsecase.GetAll(c.Context())
+	RetailStores, err := h.RetailStoreUsecase.GetAll(c.Context(), c.QueryBool("include_inactive"))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, RetailStores, "Retail store retrieved successfully")
+}
+
+func (h *RetailStoreHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	retailStore, err := h.RetailStoreUsecase.GetByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, retailStore, "Retail store retrieved successfully")
+}
+
+func (h *RetailStoreHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
-		return response.BadRequest(c, "failed to get", err)
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.UpdateRetailStoreRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	retailStore, err := h.RetailStoreUsecase.Update(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, retailStore, "Retail store updated successfully")
+}
+
+func (h *RetailStoreHandler) Deactivate(c *fiber.Ctx) error {
+	return h.setActive(c, false, "Retail store deactivated successfully")
+}
+
+func (h *RetailStoreHandler) Activate(c *fiber.Ctx) error {
+	return h.setActive(c, true, "Retail store activated successfully")
+}
+
+func (h *RetailStoreHandler) setActive(c *fiber.Ctx, isActive bool, message string) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	retailStore, err := h.RetailStoreUsecase.SetActive(c.Context(), id, isActive)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, retailStore, message)
+}
+
+func (h *RetailStoreHandler) GetUsers(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	users, err := h.RetailStoreUsecase.GetUsers(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, users, "Store users retrieved successfully")
+}
+
+func (h *RetailStoreHandler) AssignUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.AssignStoreUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.RetailStoreUsecase.AssignUser(c.Context(), id, &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "User assigned successfully")
+}
+
+func (h *RetailStoreHandler) UnassignUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+	userID, err := strconv.ParseInt(c.Params("user_id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid user id", err)
+	}
+
+	if err := h.RetailStoreUsecase.UnassignUser(c.Context(), id, userID); err != nil {
+		return err
 	}
-	return response.Success(c, RetailStores, "Platform retrieved successfully")
+	return response.Success(c, nil, "User unassigned successfully")
 }
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SegmentHandler struct {
+	segmentUsecase *usecase.SegmentUsecase
+}
+
+func NewSegmentHandler(segmentUsecase *usecase.SegmentUsecase) *SegmentHandler {
+	return &SegmentHandler{
+		segmentUsecase: segmentUsecase,
+	}
+}
+
+// GET /api/v1/segments
+func (h *SegmentHandler) GetAll(c *fiber.Ctx) error {
+	segments, err := h.segmentUsecase.GetAll(c.Context())
+	if err != nil {
+		return err
+	}
+	return response.Success(c, segments, "segments retrieved successfully")
+}
+
+// GET /api/v1/segments/:id
+func (h *SegmentHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	segment, err := h.segmentUsecase.GetByID(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, segment, "segment retrieved successfully")
+}
+
+// POST /api/v1/segments
+func (h *SegmentHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateCustomerSegmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	segment, err := h.segmentUsecase.Create(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, segment, "segment created successfully")
+}
+
+// PUT /api/v1/segments/:id
+func (h *SegmentHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.UpdateCustomerSegmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	segment, err := h.segmentUsecase.Update(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, segment, "segment updated successfully")
+}
+
+// DELETE /api/v1/segments/:id
+func (h *SegmentHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.segmentUsecase.Delete(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "segment deleted successfully")
+}
+
+// GET /api/v1/segments/:id/members
+func (h *SegmentHandler) GetMembers(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	membership, err := h.segmentUsecase.GetMembers(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, membership, "segment members retrieved successfully")
+}
+
+// GET /api/v1/segments/:id/export
+func (h *SegmentHandler) Export(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	membership, err := h.segmentUsecase.GetMembers(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := membership.WriteCSV(&buf); err != nil {
+		return response.InternalServerError(c, "failed to export segment", err)
+	}
+	c.Attachment(fmt.Sprintf("segment-%d-%s.csv", id, membership.EvaluatedAt.Format("20060102")))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Send(buf.Bytes())
+}
+
+// POST /api/v1/segments/preview
+// Evaluates the rules in the body without saving a segment
+func (h *SegmentHandler) Preview(c *fiber.Ctx) error {
+	var req model.SegmentRules
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	membership, err := h.segmentUsecase.Preview(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, membership, "segment members retrieved successfully")
+}
+
+// GET /api/v1/segments/rfm?window_days=365&platform_ids=1,2
+func (h *SegmentHandler) GetRFMReport(c *fiber.Ctx) error {
+	windowDays, err := strconv.Atoi(c.Query("window_days", "0"))
+	if err != nil {
+		return response.BadRequest(c, "invalid window_days", err)
+	}
+	platformIDs, err := parseIDList(c.Query("platform_ids"))
+	if err != nil {
+		return response.BadRequest(c, "invalid platform_ids", err)
+	}
+
+	report, err := h.segmentUsecase.GetRFMReport(c.Context(), windowDays, platformIDs)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, report, "RFM report retrieved successfully")
+}
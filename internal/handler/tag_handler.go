@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TagHandler struct {
+	tagUsecase *usecase.TagUsecase
+}
+
+func NewTagHandler(tagUsecase *usecase.TagUsecase) *TagHandler {
+	return &TagHandler{
+		tagUsecase: tagUsecase,
+	}
+}
+
+// GET /api/v1/tags
+func (h *TagHandler) GetAll(c *fiber.Ctx) error {
+	tags, err := h.tagUsecase.GetAll(c.Context())
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tags, "tags retrieved successfully")
+}
+
+// POST /api/v1/tags
+func (h *TagHandler) Create(c *fiber.Ctx) error {
+	var req model.CreateTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	tag, err := h.tagUsecase.Create(c.Context(), &req)
+	if err != nil {
+		return err
+	}
+	return response.Created(c, tag, "tag created successfully")
+}
+
+// PUT /api/v1/tags/:id
+func (h *TagHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	var req model.UpdateTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	tag, err := h.tagUsecase.Update(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tag, "tag updated successfully")
+}
+
+// DELETE /api/v1/tags/:id
+func (h *TagHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	if err := h.tagUsecase.Delete(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "tag deleted successfully")
+}
+
+// POST /api/v1/customer/:id/tags/:tag_id
+func (h *TagHandler) TagCustomer(c *fiber.Ctx) error {
+	customerID, tagID, err := customerTagParams(c)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	tags, err := h.tagUsecase.TagCustomer(c.Context(), customerID, tagID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tags, "customer tagged successfully")
+}
+
+// DELETE /api/v1/customer/:id/tags/:tag_id
+func (h *TagHandler) UntagCustomer(c *fiber.Ctx) error {
+	customerID, tagID, err := customerTagParams(c)
+	if err != nil {
+		return response.BadRequest(c, "invalid id", err)
+	}
+
+	tags, err := h.tagUsecase.UntagCustomer(c.Context(), customerID, tagID)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, tags, "customer untagged successfully")
+}
+
+func customerTagParams(c *fiber.Ctx) (int64, int64, error) {
+	customerID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	tagID, err := strconv.ParseInt(c.Params("tag_id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return customerID, tagID, nil
+}
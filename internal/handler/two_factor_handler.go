@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"simple-template/internal/middleware"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorHandler struct {
+	twoFactorUsecase *usecase.TwoFactorUsecase
+}
+
+func NewTwoFactorHandler(twoFactorUsecase *usecase.TwoFactorUsecase) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorUsecase: twoFactorUsecase,
+	}
+}
+
+// GET /api/v1/auth/2fa
+func (h *TwoFactorHandler) Status(c *fiber.Ctx) error {
+	status, err := h.twoFactorUsecase.Status(c.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, status, "two-factor status retrieved successfully")
+}
+
+// POST /api/v1/auth/2fa/setup
+// Add the secret to an authenticator app, e.g. by showing provisioning_uri as a QR code, then enable it
+func (h *TwoFactorHandler) Setup(c *fiber.Ctx) error {
+	setup, err := h.twoFactorUsecase.Setup(c.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, setup, "scan the provisioning uri and confirm with a code")
+}
+
+// POST /api/v1/auth/2fa/enable
+// The recovery codes are only in this response
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	codes, err := h.twoFactorUsecase.Enable(c.Context(), middleware.CurrentUserID(c), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, codes, "two-factor authentication enabled successfully")
+}
+
+// POST /api/v1/auth/2fa/disable
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	var req model.DisableTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	if err := h.twoFactorUsecase.Disable(c.Context(), middleware.CurrentUserID(c), &req); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "two-factor authentication disabled successfully")
+}
+
+// POST /api/v1/auth/2fa/recovery-codes
+// The previous recovery codes stop working
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req model.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "invalid request body", err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+
+	codes, err := h.twoFactorUsecase.RegenerateRecoveryCodes(c.Context(), middleware.CurrentUserID(c), &req)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, codes, "recovery codes regenerated successfully")
+}
+
+// DELETE /api/v1/users/:id/2fa
+// For users who lost their authenticator and recovery codes
+func (h *TwoFactorHandler) Reset(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "invalid user id", err)
+	}
+
+	if err := h.twoFactorUsecase.Reset(c.Context(), id); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "two-factor authentication reset successfully")
+}
@@ -34,7 +34,7 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	// Call usecase to create user
 	user, err := h.userUsecase.CreateUser(c.Context(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create user", err)
+		return err
 	}
 
 	return response.Created(c, user, "User created successfully")
@@ -53,10 +53,7 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	// Call usecase to get user
 	user, err := h.userUsecase.GetUserByID(c.Context(), id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			return response.NotFound(c, "User not found")
-		}
-		return response.InternalServerError(c, "Failed to get user", err)
+		return err
 	}
 
 	return response.Success(c, user, "User retrieved successfully")
@@ -68,7 +65,7 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 	// Call usecase to get list of users
 	users, err := h.userUsecase.GetAllUsers(c.Context())
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get users", err)
+		return err
 	}
 
 	return response.Success(c, users, "Users retrieved successfully")
@@ -93,10 +90,7 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	// Gọi usecase để update user
 	user, err := h.userUsecase.UpdateUser(c.Context(), id, &req)
 	if err != nil {
-		if err.Error() == "user not found" {
-			return response.NotFound(c, "User not found")
-		}
-		return response.InternalServerError(c, "Failed to update user", err)
+		return err
 	}
 
 	return response.Success(c, user, "User updated successfully")
@@ -114,11 +108,37 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 
 	// Call usecase to delete user
 	if err := h.userUsecase.DeleteUser(c.Context(), id); err != nil {
-		if err.Error() == "user not found" {
-			return response.NotFound(c, "User not found")
-		}
-		return response.InternalServerError(c, "Failed to delete user", err)
+		return err
 	}
 
 	return response.Success(c, nil, "User deleted successfully")
 }
+
+// GetRoles handles the request to list the roles and their permissions
+// GET /api/v1/users/roles
+func (h *UserHandler) GetRoles(c *fiber.Ctx) error {
+	return response.Success(c, h.userUsecase.GetRoles(), "Roles retrieved successfully")
+}
+
+// SetRole handles the request to set the role a user has in every store
+// PUT /api/v1/users/:id/role
+func (h *UserHandler) SetRole(c *fiber.Ctx) error {
+	// Parse ID from URL params
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return response.BadRequest(c, "Invalid user ID", err)
+	}
+
+	// Parse request body
+	var req model.SetUserRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.BadRequest(c, "Invalid request body", err)
+	}
+
+	user, err := h.userUsecase.SetRole(c.Context(), id, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, user, "User role updated successfully")
+}
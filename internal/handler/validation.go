@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+	"simple-template/internal/apperror"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate checks the request structs, it names the fields by their JSON (or query) names
+var validate = newValidator()
+
+// fieldMessages word the failed rules, %[1]s is the field and %[2]s the rule parameter.
+// Rules checking a size are worded by the kind of the field, under rule.string and rule.items
+var fieldMessages = map[string]string{
+	"required":         "%[1]s is required",
+	"required_without": "%[1]s is required when %[2]s is missing",
+	"excluded_with":    "%[1]s can't be used together with %[2]s",
+	"email":            "%[1]s must be a valid email address",
+	"url":              "%[1]s must be a valid URL",
+	"alphanum":         "%[1]s must only contain letters and digits",
+	"datetime":         "%[1]s must be formatted as %[2]s",
+	"hexcolor":         "%[1]s must be a hex color, e.g. #ff8800",
+	"latitude":         "%[1]s must be a latitude between -90 and 90",
+	"longitude":        "%[1]s must be a longitude between -180 and 180",
+	"oneof":            "%[1]s must be one of %[2]s",
+	"gt":               "%[1]s must be greater than %[2]s",
+	"gte":              "%[1]s must be at least %[2]s",
+	"lt":               "%[1]s must be less than %[2]s",
+	"lte":              "%[1]s must be at most %[2]s",
+	"min":              "%[1]s must be at least %[2]s",
+	"min.string":       "%[1]s must be at least %[2]s characters long",
+	"min.items":        "%[1]s must have at least %[2]s items",
+	"max":              "%[1]s must be at most %[2]s",
+	"max.string":       "%[1]s must be at most %[2]s characters long",
+	"max.items":        "%[1]s must have at most %[2]s items",
+	"len":              "%[1]s must be %[2]s",
+	"len.string":       "%[1]s must be %[2]s characters long",
+	"len.items":        "%[1]s must have %[2]s items",
+	"invalid":          "%[1]s is invalid",
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	return v
+}
+
+// validateRequest validates the request struct, the error lists every failing field
+func validateRequest(req interface{}) error {
+	var validationErrors validator.ValidationErrors
+	if err := validate.Struct(req); !errors.As(err, &validationErrors) {
+		return err
+	}
+
+	fields := make([]apperror.FieldError, len(validationErrors))
+	for i, fieldError := range validationErrors {
+		// The namespace starts with the name of the request struct
+		_, path, _ := strings.Cut(fieldError.Namespace(), ".")
+		rule, param := fieldError.Tag(), fieldError.Param()
+		switch rule {
+		case "required_without", "excluded_with":
+			param = snakeCase(param)
+		case "oneof":
+			param = strings.Join(strings.Fields(param), ", ")
+		}
+		fields[i] = apperror.Field(path, rule, fieldFormat(rule, fieldError.Kind()), path, param)
+		fields[i].Param = param
+	}
+	return apperror.Invalid(fields...)
+}
+
+// fieldFormat returns the message format of the rule, worded by the kind of the field when the rule checks a size
+func fieldFormat(rule string, kind reflect.Kind) string {
+	key := rule
+	switch kind {
+	case reflect.String:
+		key += ".string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		key += ".items"
+	}
+	if format, ok := fieldMessages[key]; ok {
+		return format
+	}
+	if format, ok := fieldMessages[rule]; ok {
+		return format
+	}
+	return fieldMessages["invalid"]
+}
+
+// snakeCase turns the Go field names of rule parameters into JSON names, e.g. VariantValueID to variant_value_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// A new word starts after a lower case letter, or before one at the end of an acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
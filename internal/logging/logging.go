@@ -0,0 +1,46 @@
+// Package logging writes the logs of the API as JSON lines with log/slog.
+// Records logged with the context of a request carry its request id, trace and user
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"simple-template/internal/model"
+	"simple-template/pkg/traceparent"
+)
+
+// New returns a JSON logger writing the records from the level up to w
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(&contextHandler{Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})})
+}
+
+// contextHandler adds the request of the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info := model.RequestInfoFromContext(ctx); info != nil {
+		record.AddAttrs(slog.String("request_id", info.RequestID))
+	}
+	if trace, ok := traceparent.FromContext(ctx); ok {
+		record.AddAttrs(slog.String("trace_id", trace.TraceID), slog.String("span_id", trace.SpanID))
+	}
+	if principal := model.PrincipalFromContext(ctx); principal != nil {
+		record.AddAttrs(slog.Int64("user_id", principal.UserID))
+		if principal.APIKeyID != 0 {
+			record.AddAttrs(slog.Int64("api_key_id", principal.APIKeyID))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,95 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Mailer delivers outbound emails, SMTPMailer sends them for real
+// and OutboxMailer writes them to files for local testing
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is a plain text email to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Drivers selectable with MAIL_DRIVER
+const (
+	DriverSMTP   = "smtp"
+	DriverOutbox = "outbox"
+)
+
+// Config is passed to New
+type Config struct {
+	Driver string
+	// From is the sender, "Name <address>" or a bare address
+	From string
+	SMTP SMTPConfig
+	// OutboxDir is where OutboxMailer writes the messages
+	OutboxDir string
+}
+
+// New creates the mailer of the configured driver
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg.From, cfg.SMTP)
+	case DriverOutbox:
+		return NewOutboxMailer(cfg.From, cfg.OutboxDir)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+// build renders the message in RFC 5322 format, the body is quoted-printable UTF-8
+func (m Message) build(from *mail.Address, now time.Time) ([]byte, error) {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", m.To, err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message id: %w", err)
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	if _, err := body.Write([]byte(strings.ReplaceAll(m.Body, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode message body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func parseFrom(from string) (*mail.Address, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+	return address, nil
+}
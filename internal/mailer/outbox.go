@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OutboxMailer writes each message to an .eml file of the outbox directory instead of sending it,
+// the files open in any mail client
+type OutboxMailer struct {
+	from *mail.Address
+	dir  string
+}
+
+func NewOutboxMailer(from, dir string) (*OutboxMailer, error) {
+	address, err := parseFrom(from)
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("outbox directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	return &OutboxMailer{
+		from: address,
+		dir:  dir,
+	}, nil
+}
+
+func (m *OutboxMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := msg.build(m.from, now)
+	if err != nil {
+		return err
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate file name: %w", err)
+	}
+
+	// Names sort in the order the messages were sent
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(m.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write message to outbox: %w", err)
+	}
+	return nil
+}
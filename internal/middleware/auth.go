@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"strings"
+
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/usecase"
+	"simple-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserIDKey is the c.Locals key holding the id of the user making the request
+const UserIDKey = "user_id"
+
+// APIKeyHeader carries the API key of machine integrations, instead of an access token
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate rejects the requests without either a valid "Authorization: Bearer <access token>"
+// or "X-API-Key: <api key>" header.
+// The principal is kept in c.Locals, where the usecases find it through the request context
+func Authenticate(authUsecase *usecase.AuthUsecase) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			principal *model.Principal
+			err       error
+		)
+		if key := c.Get(APIKeyHeader); key != "" {
+			principal, err = authUsecase.AuthenticateAPIKey(c.Context(), strings.TrimSpace(key))
+		} else {
+			scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				return response.Unauthorized(c, "missing access token or api key")
+			}
+			principal, err = authUsecase.Authenticate(c.Context(), strings.TrimSpace(token))
+		}
+		if err != nil {
+			// Failing to check the credentials isn't the client's fault
+			if apperror.CodeOf(err) == apperror.CodeInternal {
+				return err
+			}
+			return response.Error(c, fiber.StatusUnauthorized, "authentication failed", err)
+		}
+		c.Locals(UserIDKey, principal.UserID)
+		c.Locals(model.PrincipalKey, principal)
+		return c.Next()
+	}
+}
+
+// Permit rejects the requests of users without the permission in any store, reading requests
+// (GET and HEAD) need the read permission and the others the write one.
+// Usecases then check the permission in the store the request is about
+func Permit(read, write model.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		permission := write
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			permission = read
+		}
+		principal := CurrentPrincipal(c)
+		if principal != nil && principal.TwoFactorSetupRequired {
+			return apperror.Forbidden("two-factor authentication must be enabled first, see /api/v1/auth/2fa")
+		}
+		if principal == nil || !principal.Can(permission) {
+			return apperror.Forbidden("%s permission is required", permission)
+		}
+		return c.Next()
+	}
+}
+
+// CurrentPrincipal returns the authenticated user with their roles, nil when unknown
+func CurrentPrincipal(c *fiber.Ctx) *model.Principal {
+	principal, _ := c.Locals(model.PrincipalKey).(*model.Principal)
+	return principal
+}
+
+// CurrentUserID returns the id of the user making the request, 0 when unknown
+func CurrentUserID(c *fiber.Ctx) int64 {
+	userID, _ := c.Locals(UserIDKey).(int64)
+	return userID
+}
@@ -1,19 +1,40 @@
 package middleware
 
 import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"simple-template/internal/apperror"
+	"simple-template/pkg/i18n"
 	"simple-template/pkg/response"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 )
 
+// errorStatuses are the HTTP statuses of the domain error codes
+var errorStatuses = map[apperror.Code]int{
+	apperror.CodeValidation:        fiber.StatusBadRequest,
+	apperror.CodeUnauthorized:      fiber.StatusUnauthorized,
+	apperror.CodeForbidden:         fiber.StatusForbidden,
+	apperror.CodeNotFound:          fiber.StatusNotFound,
+	apperror.CodeConflict:          fiber.StatusConflict,
+	apperror.CodeOutOfStock:        fiber.StatusConflict,
+	apperror.CodeInvalidTransition: fiber.StatusConflict,
+	apperror.CodeTooManyAttempts:   fiber.StatusTooManyRequests,
+	apperror.CodeUpstream:          fiber.StatusBadGateway,
+}
+
 // ErrorHandler middleware handles global errors
+// Handlers return the errors of the usecases as is, domain errors get the status of their code
+// and a message in the locale of the request, any other error is an internal error.
+// The error is kept in c.Locals for the request log
 func ErrorHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Catch and handle panics
 		defer func() {
 			if r := recover(); r != nil {
-				log.Errorf("Panic recovered: %v", r)
+				c.Locals(handledErrorKey{}, fmt.Errorf("panic: %v", r))
+				slog.ErrorContext(c.Context(), "Panic recovered", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
 				_ = response.InternalServerError(c, "Internal server error", nil)
 			}
 		}()
@@ -23,16 +44,45 @@ func ErrorHandler() fiber.Handler {
 
 		// If there's an error, handle it here
 		if err != nil {
+			c.Locals(handledErrorKey{}, err)
+
 			// Fiber error
 			if e, ok := err.(*fiber.Error); ok {
 				return response.Error(c, e.Code, e.Message, nil)
 			}
 
+			// Domain error
+			if e, ok := apperror.As(err); ok {
+				locale := response.Locale(c)
+				if len(e.Fields) > 0 {
+					return response.ValidationFailed(c, e.Localize(locale), fieldErrors(e.Fields, locale))
+				}
+				if status, ok := errorStatuses[e.Code]; ok {
+					// The message is already the error when nothing wrapped it
+					if err.Error() == e.Message {
+						err = nil
+					}
+					return response.ErrorWithCode(c, status, string(e.Code), e.Localize(locale), err)
+				}
+			}
+
 			// Lỗi khác
-			log.Errorf("Unhandled error: %v", err)
-			return response.InternalServerError(c, "Internal server error", err)
+			return response.ErrorWithCode(c, fiber.StatusInternalServerError, string(apperror.CodeInternal), "Internal server error", err)
 		}
 
 		return nil
 	}
 }
+
+func fieldErrors(fields []apperror.FieldError, locale i18n.Locale) []response.FieldError {
+	errors := make([]response.FieldError, len(fields))
+	for i, field := range fields {
+		errors[i] = response.FieldError{
+			Field:   field.Field,
+			Code:    field.Rule,
+			Param:   field.Param,
+			Message: field.Localize(locale),
+		}
+	}
+	return errors
+}
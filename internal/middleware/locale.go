@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"simple-template/pkg/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locale picks the language of the messages from the Accept-Language header, English when none is supported.
+// The locale is kept in c.Locals, where the usecases find it through the request context
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := i18n.Parse(c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals(i18n.LocaleKey, locale)
+		c.Set(fiber.HeaderContentLanguage, string(locale))
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -1,13 +1,17 @@
 package middleware
 
 import (
+	"log/slog"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/log"
 )
 
-// Logger middleware logs each request
+// handledErrorKey is the c.Locals key of the error ErrorHandler turned into a response, for the request log
+type handledErrorKey struct{}
+
+// Logger middleware logs each request as a JSON line, the request id, trace and user are added by the logger
+// from the request context. Server errors are logged at error level
 func Logger() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Save start time
@@ -16,18 +20,27 @@ func Logger() fiber.Handler {
 		// Process request
 		err := c.Next()
 
-		// Calculate processing time
-		duration := time.Since(start)
-
-		// Log request information
-		log.Infof(
-			"[%s] %s %s - Status: %d - Duration: %v",
-			c.IP(),
-			c.Method(),
-			c.Path(),
-			c.Response().StatusCode(),
-			duration,
-		)
+		status := c.Response().StatusCode()
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		}
+		logged := err
+		if logged == nil {
+			logged, _ = c.Locals(handledErrorKey{}).(error)
+		}
+		if logged != nil {
+			attrs = append(attrs, slog.String("error", logged.Error()))
+		}
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(c.Context(), level, "request", attrs...)
 
 		return err
 	}
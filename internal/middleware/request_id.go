@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"simple-template/internal/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDHeader carries the id of the request, the id sent by the client is kept when valid
+const RequestIDHeader = fiber.HeaderXRequestID
+
+// maxRequestIDLength bounds the ids accepted from clients, they are stored in the audit log
+const maxRequestIDLength = 64
+
+// RequestID gives every request an id, returned in the X-Request-ID response header.
+// The id and the client IP are kept in c.Locals, where the usecases find them through the request context
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(RequestIDHeader, id)
+		c.Locals(model.RequestInfoKey, &model.RequestInfo{
+			RequestID: id,
+			IPAddress: c.IP(),
+		})
+		return c.Next()
+	}
+}
+
+// isValidRequestID accepts ids made of letters, digits, dots, dashes and underscores
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand never fails on supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
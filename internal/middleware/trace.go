@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"simple-template/pkg/traceparent"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Trace joins the W3C trace of the traceparent header, or starts one. The request gets its own span of the trace,
+// kept in c.Locals where the logs and the calls to other services find it through the request context
+func Trace() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		trace, ok := traceparent.Parse(c.Get(traceparent.Header))
+		if ok {
+			trace = trace.Child()
+		} else {
+			trace = traceparent.New()
+		}
+		c.Locals(traceparent.ContextKey, trace)
+		return c.Next()
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to spot
+const APIKeyPrefix = "sk_"
+
+// APIKey gives a machine access on behalf of its user, limited to its scopes
+type APIKey struct {
+	ID     int64  `db:"id" json:"id"`
+	UserID int64  `db:"user_id" json:"user_id"`
+	Name   string `db:"name" json:"name"`
+	// Prefix is the start of the key, shown to tell the keys apart
+	Prefix     string       `db:"prefix" json:"prefix"`
+	KeyHash    string       `db:"key_hash" json:"-"`
+	Scopes     []Permission `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time   `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time   `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time    `db:"created_at" json:"created_at"`
+}
+
+// IsUsable reports whether the key is neither revoked nor expired
+func (k *APIKey) IsUsable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string       `json:"name" validate:"required,max=100"`
+	Scopes []Permission `json:"scopes" validate:"required,min=1"`
+	// ExpiresAt is optional, the key never expires without it
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAPIKey is returned once on creation, the key can't be retrieved afterwards
+type CreatedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
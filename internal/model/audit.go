@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// Audited actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// Audited entity types
+const (
+	AuditEntityUser     = "user"
+	AuditEntityCustomer = "customer"
+	AuditEntityProduct  = "product"
+	AuditEntityOrder    = "order"
+)
+
+// AuditLog records who created, updated or deleted an entity, with the fields that changed
+type AuditLog struct {
+	ID int64 `db:"id" json:"id"`
+	// ActorUserID is nil when the server did it itself, e.g. a marketplace sync
+	ActorUserID   *int64                  `db:"actor_user_id" json:"actor_user_id"`
+	ActorAPIKeyID *int64                  `db:"actor_api_key_id" json:"actor_api_key_id,omitempty"`
+	Action        string                  `db:"action" json:"action"`
+	EntityType    string                  `db:"entity_type" json:"entity_type"`
+	EntityID      int64                   `db:"entity_id" json:"entity_id"`
+	Changes       map[string]*AuditChange `db:"changes" json:"changes"`
+	IPAddress     string                  `db:"ip_address" json:"ip_address,omitempty"`
+	RequestID     string                  `db:"request_id" json:"request_id,omitempty"`
+	CreatedAt     time.Time               `db:"created_at" json:"created_at"`
+}
+
+// AuditRedacted replaces the personal data of anonymized customers in the audit logs
+const AuditRedacted = "[redacted]"
+
+// CustomerPersonalFields are the audited fields of customers holding personal data
+var CustomerPersonalFields = []string{"first_name", "last_name", "address", "email", "phone_number", "addresses"}
+
+// OrderPersonalFields are the audited fields of orders holding personal data of their customer
+var OrderPersonalFields = []string{"shipping_address"}
+
+// RedactAuditChanges replaces the values of the fields with AuditRedacted, nil values stay nil
+// so creates and deletes still read as such. It reports whether anything was replaced
+func RedactAuditChanges(changes map[string]*AuditChange, fields []string) bool {
+	redacted := false
+	for _, field := range fields {
+		change, ok := changes[field]
+		if !ok || change == nil {
+			continue
+		}
+		if change.Before != nil && change.Before != AuditRedacted {
+			change.Before = AuditRedacted
+			redacted = true
+		}
+		if change.After != nil && change.After != AuditRedacted {
+			change.After = AuditRedacted
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// AuditChange is the value of a field before and after, nil before a create and after a delete
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter narrows GET /api/v1/audit, zero values don't filter
+type AuditFilter struct {
+	EntityType  string `query:"entity_type"`
+	EntityID    int64  `query:"entity_id"`
+	ActorUserID int64  `query:"actor_id"`
+	Action      string `query:"action"`
+	// From and To are dates (YYYY-MM-DD), both inclusive
+	From string `query:"from"`
+	To   string `query:"to"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+// Cash shift statuses
+const (
+	CashShiftStatusOpen   = "open"
+	CashShiftStatusClosed = "closed"
+)
+
+// Cash movement types
+const (
+	CashMovementTypeIn  = "cash_in"
+	CashMovementTypeOut = "cash_out"
+)
+
+// CashShift is a cashier session on the drawer of a store
+// The amounts are computed live while the shift is open and frozen when it's closed
+type CashShift struct {
+	ID             int64           `db:"id" json:"id"`
+	RetailStoreID  int64           `db:"retail_store_id" json:"retail_store_id"`
+	UserID         int64           `db:"user_id" json:"user_id"`
+	Status         string          `db:"status" json:"status"`
+	OpeningFloat   float64         `db:"opening_float" json:"opening_float"`
+	CashSales      float64         `db:"cash_sales_amount" json:"cash_sales_amount"`
+	CashIn         float64         `db:"cash_in_amount" json:"cash_in_amount"`
+	CashOut        float64         `db:"cash_out_amount" json:"cash_out_amount"`
+	ExpectedAmount float64         `db:"expected_amount" json:"expected_amount"`
+	CountedAmount  *float64        `db:"counted_amount" json:"counted_amount"`
+	Difference     *float64        `db:"difference_amount" json:"difference_amount"`
+	OrderCount     int64           `db:"order_count" json:"order_count"`
+	OpeningNote    string          `db:"opening_note" json:"opening_note,omitempty"`
+	ClosingNote    string          `db:"closing_note" json:"closing_note,omitempty"`
+	OpenedAt       time.Time       `db:"opened_at" json:"opened_at"`
+	ClosedAt       *time.Time      `db:"closed_at" json:"closed_at"`
+	Movements      []*CashMovement `db:"-" json:"movements,omitempty"`
+}
+
+// ComputeExpected sets the cash that should be in the drawer
+func (s *CashShift) ComputeExpected() {
+	s.ExpectedAmount = s.OpeningFloat + s.CashSales + s.CashIn - s.CashOut
+}
+
+type CashMovement struct {
+	ID        int64     `db:"id" json:"id"`
+	ShiftID   int64     `db:"shift_id" json:"shift_id"`
+	Type      string    `db:"type" json:"type"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// CashSales are the paid cash orders of a store over a period, canceled and returned orders excluded
+type CashSales struct {
+	Amount     float64
+	OrderCount int64
+}
+
+type OpenCashShiftRequest struct {
+	RetailStoreID int64   `json:"retail_store_id" validate:"required"`
+	UserID        int64   `json:"user_id" validate:"required"`
+	OpeningFloat  float64 `json:"opening_float" validate:"gte=0"`
+	Note          string  `json:"note,omitempty" validate:"max=1000"`
+}
+
+type CreateCashMovementRequest struct {
+	Type   string  `json:"type" validate:"required,oneof=cash_in cash_out"`
+	Amount float64 `json:"amount" validate:"required,gt=0"`
+	Reason string  `json:"reason" validate:"required,max=255"`
+}
+
+type CloseCashShiftRequest struct {
+	CountedAmount *float64 `json:"counted_amount" validate:"required,gte=0"`
+	Note          string   `json:"note,omitempty" validate:"max=1000"`
+}
+
+// CashShiftReportFilter dates are YYYY-MM-DD on the opening date, both inclusive
+type CashShiftReportFilter struct {
+	RetailStoreID int64  `query:"retail_store_id" validate:"required"`
+	From          string `query:"from"`
+	To            string `query:"to"`
+}
+
+// CashShiftReport lists the shifts of a store, counted and difference totals only cover closed shifts
+type CashShiftReport struct {
+	RetailStoreID   int64        `json:"retail_store_id"`
+	ShiftCount      int          `json:"shift_count"`
+	OpenShiftCount  int          `json:"open_shift_count"`
+	OrderCount      int64        `json:"order_count"`
+	TotalCashSales  float64      `json:"total_cash_sales"`
+	TotalCashIn     float64      `json:"total_cash_in"`
+	TotalCashOut    float64      `json:"total_cash_out"`
+	TotalExpected   float64      `json:"total_expected"`
+	TotalCounted    float64      `json:"total_counted"`
+	TotalDifference float64      `json:"total_difference"`
+	Shifts          []*CashShift `json:"shifts"`
+}
@@ -0,0 +1,107 @@
+package model
+
+import "time"
+
+// Sync run kinds
+const (
+	ChannelSyncKindOrders = "orders"
+	ChannelSyncKindStock  = "stock"
+	ChannelSyncKindPrices = "prices"
+)
+
+// Sync run statuses, a partial run finished with some items that failed
+const (
+	ChannelSyncStatusRunning   = "running"
+	ChannelSyncStatusSucceeded = "succeeded"
+	ChannelSyncStatusPartial   = "partial"
+	ChannelSyncStatusFailed    = "failed"
+)
+
+// ChannelSkuMapping links a marketplace SKU to one of our variant values
+type ChannelSkuMapping struct {
+	ID                int64     `db:"id" json:"id"`
+	PlatformID        int64     `db:"platform_id" json:"platform_id"`
+	ExternalSKU       string    `db:"external_sku" json:"external_sku"`
+	ExternalProductID string    `db:"external_product_id" json:"external_product_id,omitempty"`
+	ExternalVariantID string    `db:"external_variant_id" json:"external_variant_id,omitempty"`
+	VariantValueID    int64     `db:"variant_value_id" json:"variant_value_id"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ChannelListing is a SKU mapping joined with our current stock and active price
+type ChannelListing struct {
+	ChannelSkuMapping
+	StockQuantity int     `json:"stock_quantity"`
+	PriceID       int64   `json:"price_id"`
+	Price         float64 `json:"price"`
+}
+
+type CreateChannelSkuMappingRequest struct {
+	ExternalSKU       string `json:"external_sku" validate:"required"`
+	ExternalProductID string `json:"external_product_id,omitempty"`
+	ExternalVariantID string `json:"external_variant_id,omitempty"`
+	VariantValueID    int64  `json:"variant_value_id" validate:"required"`
+}
+
+// ChannelOrder links an imported marketplace order to our order
+type ChannelOrder struct {
+	ID              int64      `db:"id" json:"id"`
+	PlatformID      int64      `db:"platform_id" json:"platform_id"`
+	ExternalOrderID string     `db:"external_order_id" json:"external_order_id"`
+	OrderID         int64      `db:"order_id" json:"order_id"`
+	ExternalStatus  string     `db:"external_status" json:"external_status"`
+	TrackingNumber  string     `db:"tracking_number" json:"tracking_number,omitempty"`
+	Carrier         string     `db:"carrier" json:"carrier,omitempty"`
+	ShippedAt       *time.Time `db:"shipped_at" json:"shipped_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// ChannelSyncRun records one execution of a sync operation.
+// ResumeFrom is where the next order sync starts: the oldest order that failed to import, or the start of the run
+type ChannelSyncRun struct {
+	ID           int64      `db:"id" json:"id"`
+	PlatformID   int64      `db:"platform_id" json:"platform_id"`
+	Kind         string     `db:"kind" json:"kind"`
+	Status       string     `db:"status" json:"status"`
+	Processed    int        `db:"processed" json:"processed"`
+	Skipped      int        `db:"skipped" json:"skipped"`
+	Failed       int        `db:"failed" json:"failed"`
+	ErrorMessage string     `db:"error_message" json:"error_message,omitempty"`
+	ResumeFrom   *time.Time `db:"resume_from" json:"resume_from,omitempty"`
+	StartedAt    time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt   *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+type SyncOrdersRequest struct {
+	// RetailStoreID is the store that fulfils the marketplace orders
+	RetailStoreID int64 `json:"retail_store_id" validate:"required"`
+	// PaymentID is the payment method recorded on imported orders (e.g. ShopeePay)
+	PaymentID int64 `json:"payment_id" validate:"required"`
+	// Since defaults to where the last order sync stopped, see ChannelSyncRun.ResumeFrom
+	Since *time.Time `json:"since,omitempty"`
+}
+
+type SyncOrderFailure struct {
+	ExternalOrderID string `json:"external_order_id"`
+	Reason          string `json:"reason"`
+}
+
+type SyncOrdersResult struct {
+	RunID    int64              `json:"run_id"`
+	Imported int                `json:"imported"`
+	Skipped  int                `json:"skipped"`
+	OrderIDs []int64            `json:"order_ids"`
+	Failures []SyncOrderFailure `json:"failures"`
+}
+
+type SyncPushResult struct {
+	RunID  int64 `json:"run_id"`
+	Pushed int   `json:"pushed"`
+}
+
+type AcknowledgeShipmentRequest struct {
+	TrackingNumber string `json:"tracking_number" validate:"required"`
+	Carrier        string `json:"carrier,omitempty"`
+}
@@ -1,16 +1,26 @@
 package model
 
-import "time"
+import (
+	"simple-template/pkg/pagination"
+	"time"
+)
 
 type Customer struct {
-	ID          int64     `db:"id" json:"id"`
-	FirstName   string    `db:"first_name" json:"first_name"`
-	LastName    string    `db:"last_name" json:"last_name"`
-	Address     string    `db:"address" json:"address"`
-	Email       string    `db:"email" json:"email"`
-	PhoneNumber string    `db:"phone_number" json:"phone_number"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID          int64  `db:"id" json:"id"`
+	FirstName   string `db:"first_name" json:"first_name"`
+	LastName    string `db:"last_name" json:"last_name"`
+	Address     string `db:"address" json:"address"`
+	Email       string `db:"email" json:"email"`
+	PhoneNumber string `db:"phone_number" json:"phone_number"`
+	// AnonymizedAt is set once the personal data of the customer has been scrubbed
+	AnonymizedAt *time.Time `db:"anonymized_at" json:"anonymized_at,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	// Addresses are only loaded for a single customer
+	Addresses []*CustomerAddress `db:"-" json:"addresses,omitempty"`
+	// Loyalty is only loaded for a single customer
+	Loyalty *CustomerLoyalty `db:"-" json:"loyalty,omitempty"`
+	Tags    []*Tag           `db:"-" json:"tags,omitempty"`
 }
 
 type CreateCustomerRequest struct {
@@ -28,3 +38,112 @@ type UpdateCustomerRequest struct {
 	Email       *string `json:"email,omitempty"`
 	PhoneNumber *string `json:"phone_number,omitempty"`
 }
+
+// CustomerOrder is one order of the customer order history
+type CustomerOrder struct {
+	ID            int64  `json:"id"`
+	PlatformID    int64  `json:"platform_id"`
+	Platform      string `json:"platform"`
+	RetailStoreID int64  `json:"retail_store_id"`
+	RetailStore   string `json:"retail_store"`
+	PaymentMethod string `json:"payment_method"`
+	PaymentStatus int8   `json:"payment_status"`
+	OrderStatus   int8   `json:"order_status"`
+	ItemCount     int64  `json:"item_count"`
+	OrderAmounts
+	ShippingAddress *AddressFields `json:"shipping_address,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// CustomerStats are the lifetime stats of a customer, canceled and returned orders excluded
+type CustomerStats struct {
+	OrderCount         int64      `json:"order_count"`
+	CanceledOrderCount int64      `json:"canceled_order_count"`
+	TotalSpent         float64    `json:"total_spent"`
+	AverageOrderValue  float64    `json:"average_order_value"`
+	FirstOrderAt       *time.Time `json:"first_order_at"`
+	LastOrderAt        *time.Time `json:"last_order_at"`
+}
+
+type CustomerOrderHistory struct {
+	CustomerID int64               `json:"customer_id"`
+	Stats      *CustomerStats      `json:"stats"`
+	Orders     pagination.Response `json:"orders"`
+}
+
+// AddressFields are the structured parts of a postal address
+type AddressFields struct {
+	RecipientName string `json:"recipient_name" validate:"required,max=255"`
+	PhoneNumber   string `json:"phone_number" validate:"required,max=32"`
+	Street        string `json:"street" validate:"required,max=255"`
+	Ward          string `json:"ward,omitempty" validate:"max=255"`
+	District      string `json:"district,omitempty" validate:"max=255"`
+	Province      string `json:"province" validate:"required,max=255"`
+	PostalCode    string `json:"postal_code,omitempty" validate:"max=20"`
+}
+
+type CustomerAddress struct {
+	ID         int64  `db:"id" json:"id"`
+	CustomerID int64  `db:"customer_id" json:"customer_id"`
+	Label      string `db:"label" json:"label,omitempty"`
+	AddressFields
+	IsDefaultShipping bool      `db:"is_default_shipping" json:"is_default_shipping"`
+	IsDefaultBilling  bool      `db:"is_default_billing" json:"is_default_billing"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// The first address of a customer becomes the default shipping and billing address
+type CreateCustomerAddressRequest struct {
+	Label string `json:"label,omitempty" validate:"max=50"`
+	AddressFields
+	IsDefaultShipping bool `json:"is_default_shipping,omitempty"`
+	IsDefaultBilling  bool `json:"is_default_billing,omitempty"`
+}
+
+type UpdateCustomerAddressRequest struct {
+	Label             *string `json:"label,omitempty" validate:"omitempty,max=50"`
+	RecipientName     *string `json:"recipient_name,omitempty" validate:"omitempty,min=1,max=255"`
+	PhoneNumber       *string `json:"phone_number,omitempty" validate:"omitempty,min=1,max=32"`
+	Street            *string `json:"street,omitempty" validate:"omitempty,min=1,max=255"`
+	Ward              *string `json:"ward,omitempty" validate:"omitempty,max=255"`
+	District          *string `json:"district,omitempty" validate:"omitempty,max=255"`
+	Province          *string `json:"province,omitempty" validate:"omitempty,min=1,max=255"`
+	PostalCode        *string `json:"postal_code,omitempty" validate:"omitempty,max=20"`
+	IsDefaultShipping *bool   `json:"is_default_shipping,omitempty"`
+	IsDefaultBilling  *bool   `json:"is_default_billing,omitempty"`
+}
+
+// CustomerDuplicate is a customer that may be the same person, Score goes from 0 to 1
+type CustomerDuplicate struct {
+	Customer *Customer `json:"customer"`
+	Score    float64   `json:"score"`
+	Reasons  []string  `json:"reasons"`
+}
+
+// MergeCustomersRequest lists the customers merged into the surviving customer and deleted
+type MergeCustomersRequest struct {
+	CustomerIDs []int64 `json:"customer_ids" validate:"required,min=1,max=20,dive,gt=0"`
+}
+
+type CustomerMergeResult struct {
+	Customer          *Customer `json:"customer"`
+	MergedCustomerIDs []int64   `json:"merged_customer_ids"`
+	OrdersMoved       int64     `json:"orders_moved"`
+	AddressesMoved    int64     `json:"addresses_moved"`
+}
+
+// CustomerExport is everything held about a customer, for data access requests
+type CustomerExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	Customer      *Customer              `json:"customer"`
+	Stats         *CustomerStats         `json:"stats"`
+	Orders        []*CustomerExportOrder `json:"orders"`
+	LoyaltyLedger []*LoyaltyEntry        `json:"loyalty_ledger"`
+	Notes         []*CustomerNote        `json:"notes"`
+}
+
+type CustomerExportOrder struct {
+	*CustomerOrder
+	Items []*ReceiptLine `json:"items"`
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Loyalty ledger entry types
+const (
+	LoyaltyEntryEarn          = "earn"
+	LoyaltyEntryRedeem        = "redeem"
+	LoyaltyEntryReverseEarn   = "reverse_earn"
+	LoyaltyEntryReverseRedeem = "reverse_redeem"
+	LoyaltyEntryExpire        = "expire"
+)
+
+// Loyalty tiers, a customer without enough rolling spend has no tier
+const (
+	LoyaltyTierNone   = ""
+	LoyaltyTierSilver = "silver"
+	LoyaltyTierGold   = "gold"
+)
+
+// LoyaltyConfig holds the earning, redemption, expiry and tier rules of the loyalty program
+type LoyaltyConfig struct {
+	Enabled bool `json:"enabled"`
+	// EarnAmount is the order total that earns one point
+	EarnAmount float64 `json:"earn_amount" validate:"gt=0"`
+	// PointValue is the discount one redeemed point gives
+	PointValue      float64 `json:"point_value" validate:"gt=0"`
+	MinRedeemPoints int64   `json:"min_redeem_points" validate:"gte=0"`
+	// MaxRedeemRate is the share of the order subtotal points can pay, in percent
+	MaxRedeemRate float64 `json:"max_redeem_rate" validate:"gt=0,lte=100"`
+	// ExpiryMonths is how long earned points stay valid, 0 for never
+	ExpiryMonths int `json:"expiry_months" validate:"gte=0,lte=120"`
+	// TierWindowMonths is the rolling period of the spend tiers are computed from
+	TierWindowMonths    int               `json:"tier_window_months" validate:"gte=1,lte=60"`
+	Tiers               []LoyaltyTierRule `json:"tiers" validate:"dive"`
+	ExcludedPlatformIDs []int64           `json:"excluded_platform_ids,omitempty"`
+}
+
+// LoyaltyTierRule is reached with MinSpend over the tier window, points earned are multiplied by EarnMultiplier
+type LoyaltyTierRule struct {
+	Tier           string  `json:"tier" validate:"required,oneof=silver gold"`
+	MinSpend       float64 `json:"min_spend" validate:"gt=0"`
+	EarnMultiplier float64 `json:"earn_multiplier" validate:"gte=1,lte=10"`
+}
+
+// TierFor returns the highest tier rule reached with the rolling spend, or nil
+func (c LoyaltyConfig) TierFor(spend float64) *LoyaltyTierRule {
+	var reached *LoyaltyTierRule
+	for i, rule := range c.Tiers {
+		if spend >= rule.MinSpend && (reached == nil || rule.MinSpend > reached.MinSpend) {
+			reached = &c.Tiers[i]
+		}
+	}
+	return reached
+}
+
+// NextTier returns the lowest tier rule above the rolling spend, or nil
+func (c LoyaltyConfig) NextTier(spend float64) *LoyaltyTierRule {
+	var next *LoyaltyTierRule
+	for i, rule := range c.Tiers {
+		if spend < rule.MinSpend && (next == nil || rule.MinSpend < next.MinSpend) {
+			next = &c.Tiers[i]
+		}
+	}
+	return next
+}
+
+// EarnsOn tells whether orders of the platform earn points
+func (c LoyaltyConfig) EarnsOn(platformID int64) bool {
+	return c.Enabled && !slices.Contains(c.ExcludedPlatformIDs, platformID)
+}
+
+type LoyaltyEntry struct {
+	ID         int64  `db:"id" json:"id"`
+	CustomerID int64  `db:"customer_id" json:"customer_id"`
+	OrderID    *int64 `db:"order_id" json:"order_id,omitempty"`
+	Type       string `db:"type" json:"type"`
+	// Points are positive for credits and negative for debits
+	Points int64 `db:"points" json:"points"`
+	// RemainingPoints is the part of a credit not yet redeemed, reversed or expired
+	RemainingPoints int64      `db:"remaining_points" json:"remaining_points"`
+	ExpiresAt       *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	Description     string     `db:"description" json:"description"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
+
+// CustomerLoyalty is the points balance and tier of a customer
+type CustomerLoyalty struct {
+	Points          int64      `json:"points"`
+	PointsValue     float64    `json:"points_value"`
+	Tier            string     `json:"tier"`
+	RollingSpend    float64    `json:"rolling_spend"`
+	NextTier        string     `json:"next_tier,omitempty"`
+	SpendToNextTier float64    `json:"spend_to_next_tier,omitempty"`
+	NextExpiry      *time.Time `json:"next_expiry,omitempty"`
+	ExpiringPoints  int64      `json:"expiring_points,omitempty"`
+}
+
+// LoyaltyExpiryResult is the outcome of a points expiry run
+type LoyaltyExpiryResult struct {
+	EntryCount    int64 `json:"entry_count"`
+	ExpiredPoints int64 `json:"expired_points"`
+}
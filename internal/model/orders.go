@@ -3,23 +3,63 @@ package model
 import "time"
 
 type Orders struct {
-	ID            int64         `db:"id" json:"id"`
-	PaymentStatus int8          `db:"payment_status" json:"payment_status"`
-	CustomerID    int64         `db:"customer_id" json:"customer_id"`
-	PlatformID    int64         `db:"platform_id" json:"platform_id"`
-	RetailStoreID int64         `db:"retail_store_id" json:"retail_store_id"`
-	PaymentID     int64         `db:"payment_id" json:"payment_id"`
-	CreatedAt     time.Time     `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time     `db:"updated_at" json:"updated_at"`
-	Items         []*OrderItems `json:"items,omitempty"`
+	ID            int64 `db:"id" json:"id"`
+	PaymentStatus int8  `db:"payment_status" json:"payment_status"`
+	// CustomerID is 0 for walk-in sales
+	CustomerID int64 `db:"customer_id" json:"customer_id"`
+	// ShippingAddressID is the customer address the order ships to, ShippingAddress its copy at creation
+	ShippingAddressID *int64         `db:"shipping_address_id" json:"shipping_address_id,omitempty"`
+	ShippingAddress   *AddressFields `db:"shipping_address" json:"shipping_address,omitempty"`
+	PlatformID        int64          `db:"platform_id" json:"platform_id"`
+	RetailStoreID     int64          `db:"retail_store_id" json:"retail_store_id"`
+	PaymentID         int64          `db:"payment_id" json:"payment_id"`
+	OrderAmounts
+	CreatedAt time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `db:"updated_at" json:"updated_at"`
+	Items     []*OrderItems `json:"items,omitempty"`
+	Fees      []*OrderFee   `json:"fees,omitempty"`
+}
+
+// OrderAmounts are computed when the order is created
+type OrderAmounts struct {
+	SubtotalAmount   float64 `db:"subtotal_amount" json:"subtotal_amount"`
+	DiscountAmount   float64 `db:"discount_amount" json:"discount_amount"`
+	TotalAmount      float64 `db:"total_amount" json:"total_amount"`
+	CommissionAmount float64 `db:"commission_amount" json:"commission_amount"`
+	ChannelFeeAmount float64 `db:"channel_fee_amount" json:"channel_fee_amount"`
+	CogsAmount       float64 `db:"cogs_amount" json:"cogs_amount"`
+}
+
+// NetRevenue is what the shop keeps: total minus commission, channel fees and COGS
+// (the discount is already deducted from the total)
+func (a OrderAmounts) NetRevenue() float64 {
+	return a.TotalAmount - a.CommissionAmount - a.ChannelFeeAmount - a.CogsAmount
+}
+
+// OrderFee is one platform fee charged on an order, the commission included
+type OrderFee struct {
+	ID           int64   `db:"id" json:"id"`
+	OrderID      int64   `db:"order_id" json:"order_id"`
+	Name         string  `db:"name" json:"name"`
+	Type         string  `db:"type" json:"type"`
+	Rate         float64 `db:"rate" json:"rate"`
+	Amount       float64 `db:"amount" json:"amount"`
+	IsCommission bool    `db:"is_commission" json:"is_commission"`
 }
 
 type CreateOrders struct {
-	CustomerID    int64              `json:"customer_id" validate:"required"`
-	PlatformID    int64              `json:"platform_id" validate:"required"`
-	RetailStoreID int64              `json:"retail_store_id" validate:"required"`
-	PaymentID     int64              `json:"payment_id" validate:"required"`
-	Items         []CreateOrderItems `json:"items,omitempty" validate:"dive"`
+	CustomerID     int64              `json:"customer_id" validate:"required"`
+	PlatformID     int64              `json:"platform_id" validate:"required"`
+	RetailStoreID  int64              `json:"retail_store_id" validate:"required"`
+	PaymentID      int64              `json:"payment_id" validate:"required"`
+	DiscountAmount float64            `json:"discount_amount,omitempty" validate:"gte=0"`
+	Items          []CreateOrderItems `json:"items,omitempty" validate:"dive"`
+	// ShippingAddressID picks one of the customer addresses, ShippingAddress is a one-off address
+	// Without both the customer default shipping address is used
+	ShippingAddressID *int64         `json:"shipping_address_id,omitempty" validate:"omitempty,excluded_with=ShippingAddress"`
+	ShippingAddress   *AddressFields `json:"shipping_address,omitempty"`
+	// RedeemPoints pays part of the order with the customer loyalty points, added to the discount
+	RedeemPoints int64 `json:"redeem_points,omitempty" validate:"gte=0"`
 }
 
 type OrderItems struct {
@@ -33,7 +73,7 @@ type OrderItems struct {
 }
 
 type CreateOrderItems struct {
-	Quantity         int64 `json:"quantity" validate:"required"`
+	Quantity         int64 `json:"quantity" validate:"required,gt=0"`
 	ProductVariantID int64 `json:"product_variant_id" validate:"required"`
 	PriceID          int64 `json:"price_id" validate:"required"`
 }
@@ -50,6 +90,33 @@ type OrdersPage struct {
 	OrderStatus   int8
 }
 
+// OrderRevenue is the revenue breakdown of one order
+type OrderRevenue struct {
+	OrderID    int64  `json:"order_id"`
+	PlatformID int64  `json:"platform_id"`
+	Platform   string `json:"platform"`
+	OrderAmounts
+	NetRevenue float64     `json:"net_revenue"`
+	Fees       []*OrderFee `json:"fees"`
+}
+
+// PlatformRevenue aggregates the orders of a platform, canceled and returned orders excluded
+type PlatformRevenue struct {
+	PlatformID int64  `json:"platform_id"`
+	Platform   string `json:"platform"`
+	OrderCount int64  `json:"order_count"`
+	OrderAmounts
+	NetRevenue float64 `json:"net_revenue"`
+}
+
+// RevenueFilter dates are YYYY-MM-DD, both inclusive
+type RevenueFilter struct {
+	From string `query:"from"`
+	To   string `query:"to"`
+}
+
+// OrderStatus is one step of the status history of an order.
+// Description is stored in English, a key of the i18n catalog: translate it with i18n.T when responding
 type OrderStatus struct {
 	ID          int64     `db:"id" json:"id"`
 	Status      int8      `db:"status" json:"status"`
@@ -74,6 +141,14 @@ const (
 	OrderStatusShipped
 	OrderStatusCompleted
 	OrderStatusCanceled
+	// OrderStatusReturned is a completed order the customer sent back
+	OrderStatusReturned
+)
+
+const (
+	PaymentStatusUnpaid int8 = iota + 1
+	PaymentStatusPaid
+	PaymentStatusRefunded
 )
 
 type UpdateOrderStatus struct {
@@ -2,6 +2,9 @@ package model
 
 import "time"
 
+// PaymentMethodCodeCash is the only method that takes a tendered amount and gives change
+const PaymentMethodCodeCash = "CASH"
+
 type PaymentMethods struct {
 	ID          int64     `db:"id" json:"id"`
 	Name        string    `db:"name" json:"name"`
@@ -10,4 +13,43 @@ type PaymentMethods struct {
 	IsActive    bool      `db:"is_active" json:"is_active"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	// PlatformIDs and RetailStoreIDs restrict where the method can be used, empty means everywhere
+	PlatformIDs    []int64 `db:"-" json:"platform_ids"`
+	RetailStoreIDs []int64 `db:"-" json:"retail_store_ids"`
+}
+
+// AllowedFor reports whether the method can be used for an order of the platform and store
+func (p *PaymentMethods) AllowedFor(platformID, retailStoreID int64) bool {
+	return containsOrEmpty(p.PlatformIDs, platformID) && containsOrEmpty(p.RetailStoreIDs, retailStoreID)
+}
+
+func containsOrEmpty(ids []int64, id int64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+type CreatePaymentMethodRequest struct {
+	Name           string  `json:"name" validate:"required,max=50"`
+	Code           string  `json:"code" validate:"required,max=20,alphanum"`
+	Description    string  `json:"description,omitempty"`
+	IsActive       *bool   `json:"is_active,omitempty"`
+	PlatformIDs    []int64 `json:"platform_ids,omitempty" validate:"dive,gt=0"`
+	RetailStoreIDs []int64 `json:"retail_store_ids,omitempty" validate:"dive,gt=0"`
+}
+
+// UpdatePaymentMethodRequest replaces the restrictions when PlatformIDs / RetailStoreIDs are sent
+type UpdatePaymentMethodRequest struct {
+	Name           *string  `json:"name,omitempty" validate:"omitempty,max=50"`
+	Code           *string  `json:"code,omitempty" validate:"omitempty,max=20,alphanum"`
+	Description    *string  `json:"description,omitempty"`
+	IsActive       *bool    `json:"is_active,omitempty"`
+	PlatformIDs    *[]int64 `json:"platform_ids,omitempty" validate:"omitempty,dive,gt=0"`
+	RetailStoreIDs *[]int64 `json:"retail_store_ids,omitempty" validate:"omitempty,dive,gt=0"`
 }
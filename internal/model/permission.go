@@ -0,0 +1,225 @@
+package model
+
+import (
+	"context"
+	"slices"
+)
+
+// Roles a user can have, either for every store (User.Role) or for one store (store_users.role)
+const (
+	RoleAdmin     = "admin"
+	RoleManager   = "manager"
+	RoleCashier   = "cashier"
+	RoleWarehouse = "warehouse"
+	RoleReadOnly  = "read_only"
+)
+
+// Roles lists the roles, from the most to the least privileged
+var Roles = []string{RoleAdmin, RoleManager, RoleCashier, RoleWarehouse, RoleReadOnly}
+
+type Permission string
+
+// Permissions guard the route groups, reading routes need the read permission and the others the write one.
+// PermissionCustomersAdmin also guards exporting, merging, anonymizing and deleting customers
+const (
+	PermissionUsersRead      Permission = "users:read"
+	PermissionUsersWrite     Permission = "users:write"
+	PermissionStoresRead     Permission = "stores:read"
+	PermissionStoresWrite    Permission = "stores:write"
+	PermissionProductsRead   Permission = "products:read"
+	PermissionProductsWrite  Permission = "products:write"
+	PermissionCustomersRead  Permission = "customers:read"
+	PermissionCustomersWrite Permission = "customers:write"
+	PermissionCustomersAdmin Permission = "customers:admin"
+	PermissionOrdersRead     Permission = "orders:read"
+	PermissionOrdersWrite    Permission = "orders:write"
+	PermissionPosSell        Permission = "pos:sell"
+	PermissionShiftsRead     Permission = "shifts:read"
+	PermissionShiftsWrite    Permission = "shifts:write"
+	PermissionSettingsRead   Permission = "settings:read"
+	PermissionSettingsWrite  Permission = "settings:write"
+	PermissionChannelsRead   Permission = "channels:read"
+	PermissionChannelsWrite  Permission = "channels:write"
+	PermissionAuditRead      Permission = "audit:read"
+)
+
+var readPermissions = []Permission{
+	PermissionUsersRead,
+	PermissionStoresRead,
+	PermissionProductsRead,
+	PermissionCustomersRead,
+	PermissionOrdersRead,
+	PermissionShiftsRead,
+	PermissionSettingsRead,
+	PermissionChannelsRead,
+}
+
+// RolePermissions are the permissions granted by each role
+var RolePermissions = map[string][]Permission{
+	RoleAdmin: append(slices.Clone(readPermissions),
+		PermissionUsersWrite,
+		PermissionStoresWrite,
+		PermissionProductsWrite,
+		PermissionCustomersWrite,
+		PermissionCustomersAdmin,
+		PermissionOrdersWrite,
+		PermissionPosSell,
+		PermissionShiftsWrite,
+		PermissionSettingsWrite,
+		PermissionChannelsWrite,
+		PermissionAuditRead,
+	),
+	RoleManager: append(slices.Clone(readPermissions),
+		PermissionProductsWrite,
+		PermissionCustomersWrite,
+		PermissionCustomersAdmin,
+		PermissionOrdersWrite,
+		PermissionPosSell,
+		PermissionShiftsWrite,
+	),
+	RoleCashier: {
+		PermissionStoresRead,
+		PermissionProductsRead,
+		PermissionCustomersRead,
+		PermissionCustomersWrite,
+		PermissionOrdersRead,
+		PermissionPosSell,
+		PermissionShiftsRead,
+		PermissionShiftsWrite,
+		PermissionSettingsRead,
+	},
+	RoleWarehouse: {
+		PermissionStoresRead,
+		PermissionProductsRead,
+		PermissionProductsWrite,
+		PermissionOrdersRead,
+		PermissionOrdersWrite,
+		PermissionSettingsRead,
+		PermissionChannelsRead,
+		PermissionChannelsWrite,
+	},
+	RoleReadOnly: readPermissions,
+}
+
+// IsValidRole reports whether the role exists
+func IsValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// IsValidPermission reports whether the permission exists
+func IsValidPermission(permission Permission) bool {
+	return slices.Contains(RolePermissions[RoleAdmin], permission)
+}
+
+// RoleGrants reports whether the role grants the permission
+func RoleGrants(role string, permission Permission) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
+
+type RoleDefinition struct {
+	Role        string       `json:"role"`
+	Permissions []Permission `json:"permissions"`
+}
+
+type StoreRole struct {
+	StoreID int64  `json:"store_id"`
+	Role    string `json:"role"`
+}
+
+// StoreUser is a user working in a store with their role there
+type StoreUser struct {
+	*User
+	StoreRole string `json:"store_role"`
+}
+
+// CurrentUser is the signed in user with their roles and the permissions they have in at least one store
+type CurrentUser struct {
+	*User
+	StoreRoles  []*StoreRole `json:"store_roles"`
+	Permissions []Permission `json:"permissions"`
+	// TwoFactorSetupRequired is true for admins who must enable two-factor authentication,
+	// they have no permission until they do
+	TwoFactorEnabled       bool `json:"two_factor_enabled"`
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required"`
+}
+
+type SetUserRoleRequest struct {
+	// Role applies to every store, empty to remove it
+	Role string `json:"role"`
+}
+
+// Principal is the authenticated user with their roles, checked by the middleware and the usecases
+type Principal struct {
+	UserID int64 `json:"user_id"`
+	// Role applies to every store, empty when the user only has store roles
+	Role       string           `json:"role,omitempty"`
+	StoreRoles map[int64]string `json:"store_roles"`
+	// APIKeyID is set when authenticated with an API key, the user's roles are then limited to its Scopes
+	APIKeyID int64        `json:"api_key_id,omitempty"`
+	Scopes   []Permission `json:"scopes,omitempty"`
+	// TwoFactorSetupRequired withholds every permission until the user enables two-factor authentication
+	TwoFactorSetupRequired bool `json:"two_factor_setup_required,omitempty"`
+}
+
+// inScope reports whether the API key used, if any, allows the permission.
+// Nothing is allowed while two-factor setup is required
+func (p *Principal) inScope(permission Permission) bool {
+	if p.TwoFactorSetupRequired {
+		return false
+	}
+	return p.APIKeyID == 0 || slices.Contains(p.Scopes, permission)
+}
+
+// Can reports whether the principal has the permission, in at least one store
+func (p *Principal) Can(permission Permission) bool {
+	if !p.inScope(permission) {
+		return false
+	}
+	if RoleGrants(p.Role, permission) {
+		return true
+	}
+	for _, role := range p.StoreRoles {
+		if RoleGrants(role, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanInStore reports whether the principal has the permission in the store
+func (p *Principal) CanInStore(permission Permission, storeID int64) bool {
+	if !p.inScope(permission) {
+		return false
+	}
+	return RoleGrants(p.Role, permission) || RoleGrants(p.StoreRoles[storeID], permission)
+}
+
+// StoreScope returns the stores in which the principal has the permission, all is true when it is every store
+func (p *Principal) StoreScope(permission Permission) (storeIDs []int64, all bool) {
+	if !p.inScope(permission) {
+		return []int64{}, false
+	}
+	if RoleGrants(p.Role, permission) {
+		return nil, true
+	}
+	storeIDs = []int64{}
+	for storeID, role := range p.StoreRoles {
+		if RoleGrants(role, permission) {
+			storeIDs = append(storeIDs, storeID)
+		}
+	}
+	slices.Sort(storeIDs)
+	return storeIDs, false
+}
+
+type principalKey struct{}
+
+// PrincipalKey is the c.Locals key of the principal, usecases read it back from the request context
+var PrincipalKey = principalKey{}
+
+// PrincipalFromContext returns the principal of the request, nil outside of an authenticated request
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(PrincipalKey).(*Principal)
+	return principal
+}
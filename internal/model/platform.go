@@ -1,12 +1,86 @@
 package model
 
-import "time"
+import (
+	_ "embed"
+	"encoding/json"
+	"time"
+)
+
+// PlatformConfigSchema is the JSON schema every platform config must match
+//
+//go:embed platform_config.schema.json
+var PlatformConfigSchema []byte
+
+// Platform features that can be enabled in the config
+const (
+	PlatformFeatureOrderSync    = "order_sync"
+	PlatformFeatureStockSync    = "stock_sync"
+	PlatformFeaturePriceSync    = "price_sync"
+	PlatformFeatureShipmentSync = "shipment_sync"
+)
+
+// PlatformOffline is the platform of the sales made in the retail stores
+const PlatformOffline = "offline"
+
+// Platform fee types
+const (
+	PlatformFeeTypePercentage = "percentage"
+	PlatformFeeTypeFixed      = "fixed"
+)
 
 type Platform struct {
-	ID            int64     `db:"id" json:"id"`
-	Name          string    `db:"name" json:"name"`
-	ApiEndpoint   string    `db:"api_endpoint" json:"api_endpoint"`
-	FeatureStruct string    `db:"feature_structure" json:"feature_structure"`
-	CreatedAt     time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	ID          int64          `db:"id" json:"id"`
+	Name        string         `db:"name" json:"name"`
+	ApiEndpoint string         `db:"api_endpoint" json:"api_endpoint"`
+	Config      PlatformConfig `db:"feature_struct" json:"config"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// PlatformConfig is stored as JSON in platform.feature_struct
+type PlatformConfig struct {
+	// CommissionRate is a percentage of the order total
+	CommissionRate float64       `json:"commission_rate"`
+	Fees           []PlatformFee `json:"fees,omitempty"`
+	// CredentialsRef is the environment variable prefix of the API credentials
+	CredentialsRef string                `json:"credentials_ref,omitempty"`
+	SyncIntervals  PlatformSyncIntervals `json:"sync_intervals"`
+	Features       []string              `json:"features,omitempty"`
+}
+
+// PlatformFee is a percentage of the order total or a fixed amount per order
+type PlatformFee struct {
+	Name  string  `json:"name"`
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// PlatformSyncIntervals are in minutes, 0 disables the automatic sync
+type PlatformSyncIntervals struct {
+	Orders int `json:"orders"`
+	Stock  int `json:"stock"`
+	Prices int `json:"prices"`
+}
+
+// HasFeature reports whether the feature is enabled for the platform
+func (c PlatformConfig) HasFeature(feature string) bool {
+	for _, enabled := range c.Features {
+		if enabled == feature {
+			return true
+		}
+	}
+	return false
+}
+
+type CreatePlatformRequest struct {
+	Name        string `json:"name" validate:"required,max=50"`
+	ApiEndpoint string `json:"api_endpoint" validate:"omitempty,url"`
+	// Config is validated against PlatformConfigSchema
+	Config json.RawMessage `json:"config" validate:"required"`
+}
+
+type UpdatePlatformRequest struct {
+	Name        *string         `json:"name,omitempty" validate:"omitempty,max=50"`
+	ApiEndpoint *string         `json:"api_endpoint,omitempty" validate:"omitempty,url"`
+	Config      json.RawMessage `json:"config,omitempty"`
 }
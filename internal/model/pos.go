@@ -0,0 +1,179 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PosItem is a sellable variant value found by scanning its barcode or SKU
+type PosItem struct {
+	VariantValueID int64   `json:"variant_value_id"`
+	PriceID        int64   `json:"price_id"`
+	ProductID      int64   `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	VariantName    string  `json:"variant_name"`
+	Value          string  `json:"value"`
+	SKU            string  `json:"sku"`
+	Barcode        string  `json:"barcode,omitempty"`
+	Price          float64 `json:"price"`
+	StockQuantity  int     `json:"stock_quantity"`
+}
+
+// PosCartItemRequest is one scanned line, either by code or by variant value id
+type PosCartItemRequest struct {
+	Code           string `json:"code,omitempty" validate:"required_without=VariantValueID"`
+	VariantValueID int64  `json:"variant_value_id,omitempty"`
+	Quantity       int64  `json:"quantity" validate:"required,gt=0"`
+}
+
+type PosCartRequest struct {
+	RetailStoreID  int64                `json:"retail_store_id" validate:"required"`
+	DiscountAmount float64              `json:"discount_amount,omitempty" validate:"gte=0"`
+	Items          []PosCartItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type PosCartLine struct {
+	PosItem
+	Quantity  int64   `json:"quantity"`
+	LineTotal float64 `json:"line_total"`
+}
+
+// PosCart is the priced cart, lines scanned several times are merged
+type PosCart struct {
+	RetailStoreID  int64          `json:"retail_store_id"`
+	Lines          []*PosCartLine `json:"lines"`
+	SubtotalAmount float64        `json:"subtotal_amount"`
+	DiscountAmount float64        `json:"discount_amount"`
+	TotalAmount    float64        `json:"total_amount"`
+}
+
+type PosCheckoutRequest struct {
+	PosCartRequest
+	// CustomerID is omitted for walk-in customers
+	CustomerID int64 `json:"customer_id,omitempty"`
+	PaymentID  int64 `json:"payment_id" validate:"required"`
+	// AmountTendered is required for cash, other methods are charged the exact total
+	AmountTendered *float64 `json:"amount_tendered,omitempty" validate:"omitempty,gte=0"`
+	// RedeemPoints pays part of the sale with the customer loyalty points
+	RedeemPoints int64 `json:"redeem_points,omitempty" validate:"gte=0"`
+}
+
+// PosPayment is the payment captured at the counter for a POS order
+type PosPayment struct {
+	ID             int64     `db:"id" json:"id"`
+	OrderID        int64     `db:"order_id" json:"order_id"`
+	PaymentID      int64     `db:"payment_id" json:"payment_id"`
+	AmountDue      float64   `db:"amount_due" json:"amount_due"`
+	AmountTendered float64   `db:"amount_tendered" json:"amount_tendered"`
+	ChangeAmount   float64   `db:"change_amount" json:"change_amount"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type ReceiptLine struct {
+	ProductName string  `json:"product_name"`
+	VariantName string  `json:"variant_name"`
+	Value       string  `json:"value"`
+	Quantity    int64   `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	LineTotal   float64 `json:"line_total"`
+}
+
+// Receipt is the printable summary of a POS sale, IssuedAt is in the store timezone
+type Receipt struct {
+	OrderID        int64          `json:"order_id"`
+	ReceiptNumber  string         `json:"receipt_number"`
+	RetailStoreID  int64          `json:"retail_store_id"`
+	StoreName      string         `json:"store_name"`
+	StoreAddress   string         `json:"store_address,omitempty"`
+	StorePhone     string         `json:"store_phone,omitempty"`
+	CustomerName   string         `json:"customer_name"`
+	PaymentMethod  string         `json:"payment_method"`
+	Lines          []*ReceiptLine `json:"lines"`
+	SubtotalAmount float64        `json:"subtotal_amount"`
+	DiscountAmount float64        `json:"discount_amount"`
+	TotalAmount    float64        `json:"total_amount"`
+	AmountTendered float64        `json:"amount_tendered"`
+	ChangeAmount   float64        `json:"change_amount"`
+	IssuedAt       time.Time      `json:"issued_at"`
+}
+
+// WalkInCustomerName is printed on receipts of sales without a customer
+const WalkInCustomerName = "Walk-in customer"
+
+// ReceiptWidth is the number of characters per line of the thermal printers
+const ReceiptWidth = 40
+
+// PlainText renders the receipt for a fixed width printer
+func (r *Receipt) PlainText() string {
+	var b strings.Builder
+	separator := strings.Repeat("-", ReceiptWidth) + "\n"
+
+	b.WriteString(receiptCenter(r.StoreName))
+	if r.StoreAddress != "" {
+		b.WriteString(receiptCenter(r.StoreAddress))
+	}
+	if r.StorePhone != "" {
+		b.WriteString(receiptCenter("Tel: " + r.StorePhone))
+	}
+	b.WriteString(separator)
+	b.WriteString(receiptColumns("Receipt", r.ReceiptNumber))
+	b.WriteString(receiptColumns("Date", r.IssuedAt.Format("2006-01-02 15:04")))
+	b.WriteString(receiptColumns("Customer", r.CustomerName))
+	b.WriteString(separator)
+	for _, line := range r.Lines {
+		name := line.ProductName
+		if line.Value != "" {
+			name += " (" + line.Value + ")"
+		}
+		b.WriteString(receiptTruncate(name, ReceiptWidth) + "\n")
+		b.WriteString(receiptColumns(
+			fmt.Sprintf("  %d x %s", line.Quantity, receiptAmount(line.UnitPrice)),
+			receiptAmount(line.LineTotal),
+		))
+	}
+	b.WriteString(separator)
+	b.WriteString(receiptColumns("Subtotal", receiptAmount(r.SubtotalAmount)))
+	if r.DiscountAmount > 0 {
+		b.WriteString(receiptColumns("Discount", "-"+receiptAmount(r.DiscountAmount)))
+	}
+	b.WriteString(receiptColumns("TOTAL", receiptAmount(r.TotalAmount)))
+	b.WriteString(receiptColumns(r.PaymentMethod, receiptAmount(r.AmountTendered)))
+	if r.ChangeAmount > 0 {
+		b.WriteString(receiptColumns("Change", receiptAmount(r.ChangeAmount)))
+	}
+	b.WriteString(separator)
+	b.WriteString(receiptCenter("Thank you!"))
+	return b.String()
+}
+
+func receiptCenter(text string) string {
+	text = receiptTruncate(text, ReceiptWidth)
+	return strings.Repeat(" ", (ReceiptWidth-len([]rune(text)))/2) + text + "\n"
+}
+
+// receiptColumns prints left and right on one line, the left part is cut when both don't fit
+func receiptColumns(left, right string) string {
+	space := ReceiptWidth - len([]rune(right)) - 1
+	if space < 0 {
+		space = 0
+	}
+	left = receiptTruncate(left, space)
+	padding := ReceiptWidth - len([]rune(left)) - len([]rune(right))
+	if padding < 1 {
+		padding = 1
+	}
+	return left + strings.Repeat(" ", padding) + right + "\n"
+}
+
+func receiptTruncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
+
+func receiptAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
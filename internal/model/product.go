@@ -43,6 +43,8 @@ type ProductVariantValue struct {
 	ID            int64     `db:"id" json:"id"`
 	AttributeID   int64     `db:"attribute_id" json:"attribute_id"`
 	Value         string    `db:"value" json:"value"`
+	SKU           string    `db:"sku" json:"sku,omitempty"`
+	Barcode       string    `db:"barcode" json:"barcode,omitempty"`
 	DisplayOrder  int       `db:"display_order" json:"display_order"`
 	StockQuantity int       `db:"stock_quantity" json:"stock_quantity"`
 	CreatedAt     time.Time `db:"created_at" json:"created_at"`
@@ -53,6 +55,8 @@ type CreateProductVariantValue struct {
 	ID            *int64 `json:"id,omitempty"`
 	AttributeID   *int64 `json:"attribute_id,omitempty"`
 	Value         string `json:"value" validate:"required"`
+	SKU           string `json:"sku,omitempty" validate:"max=64"`
+	Barcode       string `json:"barcode,omitempty" validate:"max=64"`
 	DisplayOrder  *int   `json:"display_order,omitempty"`
 	StockQuantity *int   `json:"stock_quantity,omitempty"`
 }
@@ -92,4 +96,6 @@ type OrdersProduct struct {
 	Name           string
 	Value          string
 	Status         int32
+	Price          float64
+	CostPrice      float64
 }
@@ -0,0 +1,20 @@
+package model
+
+import "context"
+
+// RequestInfo identifies the HTTP request a usecase runs for
+type RequestInfo struct {
+	RequestID string
+	IPAddress string
+}
+
+type requestInfoKey struct{}
+
+// RequestInfoKey is the c.Locals key of the request info, usecases read it back from the request context
+var RequestInfoKey = requestInfoKey{}
+
+// RequestInfoFromContext returns the info of the request, nil outside of an HTTP request
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(RequestInfoKey).(*RequestInfo)
+	return info
+}
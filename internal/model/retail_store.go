@@ -2,10 +2,53 @@ package model
 
 import "time"
 
+// DefaultStoreTimezone is used when a store is created without a timezone
+const DefaultStoreTimezone = "Asia/Ho_Chi_Minh"
+
 type RetailStore struct {
-	ID          int64     `db:"id" json:"id"`
-	Name        string    `db:"name" json:"name"`
-	PhoneNumber string    `db:"phone_number" json:"phone_number"`
-	CreatedAt   time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	ID           int64          `db:"id" json:"id"`
+	Name         string         `db:"name" json:"name"`
+	PhoneNumber  string         `db:"phone_number" json:"phone_number"`
+	Address      string         `db:"address" json:"address"`
+	Latitude     *float64       `db:"latitude" json:"latitude"`
+	Longitude    *float64       `db:"longitude" json:"longitude"`
+	Timezone     string         `db:"timezone" json:"timezone"`
+	OpeningHours []OpeningHours `db:"opening_hours" json:"opening_hours"`
+	IsActive     bool           `db:"is_active" json:"is_active"`
+	CreatedAt    time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// OpeningHours is one opening range of a week day, in the store timezone
+// Close before Open means the store closes after midnight
+type OpeningHours struct {
+	Day   string `json:"day" validate:"required,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	Open  string `json:"open" validate:"required,datetime=15:04"`
+	Close string `json:"close" validate:"required,datetime=15:04"`
+}
+
+type CreateRetailStoreRequest struct {
+	Name         string         `json:"name" validate:"required,max=50"`
+	PhoneNumber  string         `json:"phone_number,omitempty" validate:"omitempty,max=32"`
+	Address      string         `json:"address,omitempty"`
+	Latitude     *float64       `json:"latitude,omitempty" validate:"omitempty,latitude"`
+	Longitude    *float64       `json:"longitude,omitempty" validate:"omitempty,longitude"`
+	Timezone     string         `json:"timezone,omitempty"`
+	OpeningHours []OpeningHours `json:"opening_hours,omitempty" validate:"dive"`
+}
+
+type UpdateRetailStoreRequest struct {
+	Name         *string         `json:"name,omitempty" validate:"omitempty,max=50"`
+	PhoneNumber  *string         `json:"phone_number,omitempty" validate:"omitempty,max=32"`
+	Address      *string         `json:"address,omitempty"`
+	Latitude     *float64        `json:"latitude,omitempty" validate:"omitempty,latitude"`
+	Longitude    *float64        `json:"longitude,omitempty" validate:"omitempty,longitude"`
+	Timezone     *string         `json:"timezone,omitempty"`
+	OpeningHours *[]OpeningHours `json:"opening_hours,omitempty" validate:"omitempty,dive"`
+}
+
+type AssignStoreUserRequest struct {
+	UserID int64 `json:"user_id" validate:"required"`
+	// Role in the store, cashier when empty. Assigning an assigned user changes their role
+	Role string `json:"role" validate:"omitempty,oneof=manager cashier warehouse read_only"`
 }
@@ -0,0 +1,179 @@
+package model
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RFM segments, from the best customers to the lapsed ones
+const (
+	RFMSegmentChampions = "champions"
+	RFMSegmentLoyal     = "loyal"
+	RFMSegmentNew       = "new"
+	RFMSegmentPromising = "promising"
+	RFMSegmentAtRisk    = "at_risk"
+	RFMSegmentLapsed    = "lapsed"
+)
+
+// RFMSegments lists the RFM segments in report order
+var RFMSegments = []string{
+	RFMSegmentChampions, RFMSegmentLoyal, RFMSegmentNew, RFMSegmentPromising, RFMSegmentAtRisk, RFMSegmentLapsed,
+}
+
+// DefaultSegmentWindowDays is the period frequency and monetary are computed over when none is given
+const DefaultSegmentWindowDays = 365
+
+// RFMSegmentFor names the segment of the recency, frequency and monetary scores, each from 1 to 5
+func RFMSegmentFor(recency, frequency, monetary int) string {
+	switch {
+	case recency >= 4 && frequency >= 4 && monetary >= 4:
+		return RFMSegmentChampions
+	case recency >= 3 && frequency >= 3:
+		return RFMSegmentLoyal
+	case recency >= 4:
+		return RFMSegmentNew
+	case recency >= 3:
+		return RFMSegmentPromising
+	case frequency >= 3 || monetary >= 4:
+		return RFMSegmentAtRisk
+	default:
+		return RFMSegmentLapsed
+	}
+}
+
+// CustomerRFM is the purchase activity of a customer with their RFM scores, Frequency and Monetary
+// count the orders of the window, canceled and returned orders excluded
+type CustomerRFM struct {
+	CustomerID     int64     `json:"customer_id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email"`
+	PhoneNumber    string    `json:"phone_number"`
+	LastOrderAt    time.Time `json:"last_order_at"`
+	RecencyDays    int       `json:"recency_days"`
+	Frequency      int64     `json:"frequency"`
+	Monetary       float64   `json:"monetary"`
+	RecencyScore   int       `json:"recency_score"`
+	FrequencyScore int       `json:"frequency_score"`
+	MonetaryScore  int       `json:"monetary_score"`
+	Segment        string    `json:"segment"`
+}
+
+// SegmentRules select customers from their activity, every rule set must match
+type SegmentRules struct {
+	// WindowDays is the period spend and orders are counted over, DefaultSegmentWindowDays when 0
+	WindowDays int `json:"window_days,omitempty" validate:"gte=0,lte=3650"`
+	// PlatformIDs restricts the orders counted to these platforms
+	PlatformIDs []int64  `json:"platform_ids,omitempty" validate:"dive,gt=0"`
+	MinSpent    *float64 `json:"min_spent,omitempty" validate:"omitempty,gte=0"`
+	MaxSpent    *float64 `json:"max_spent,omitempty" validate:"omitempty,gte=0"`
+	MinOrders   *int64   `json:"min_orders,omitempty" validate:"omitempty,gte=0"`
+	MaxOrders   *int64   `json:"max_orders,omitempty" validate:"omitempty,gte=0"`
+	// MinInactiveDays and MaxInactiveDays bound the days since the last order
+	MinInactiveDays *int     `json:"min_inactive_days,omitempty" validate:"omitempty,gte=0"`
+	MaxInactiveDays *int     `json:"max_inactive_days,omitempty" validate:"omitempty,gte=0"`
+	RFMSegments     []string `json:"rfm_segments,omitempty" validate:"dive,oneof=champions loyal new promising at_risk lapsed"`
+}
+
+// Window returns the number of days spend and orders are counted over
+func (r SegmentRules) Window() int {
+	if r.WindowDays == 0 {
+		return DefaultSegmentWindowDays
+	}
+	return r.WindowDays
+}
+
+// Matches tells whether the scored customer is part of the segment
+func (r SegmentRules) Matches(c *CustomerRFM) bool {
+	switch {
+	case r.MinSpent != nil && c.Monetary < *r.MinSpent,
+		r.MaxSpent != nil && c.Monetary > *r.MaxSpent,
+		r.MinOrders != nil && c.Frequency < *r.MinOrders,
+		r.MaxOrders != nil && c.Frequency > *r.MaxOrders,
+		r.MinInactiveDays != nil && c.RecencyDays < *r.MinInactiveDays,
+		r.MaxInactiveDays != nil && c.RecencyDays > *r.MaxInactiveDays,
+		len(r.RFMSegments) > 0 && !slices.Contains(r.RFMSegments, c.Segment):
+		return false
+	}
+	return true
+}
+
+type CustomerSegment struct {
+	ID          int64        `db:"id" json:"id"`
+	Name        string       `db:"name" json:"name"`
+	Description string       `db:"description" json:"description,omitempty"`
+	Rules       SegmentRules `db:"rules" json:"rules"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time    `db:"updated_at" json:"updated_at"`
+}
+
+type CreateCustomerSegmentRequest struct {
+	Name        string       `json:"name" validate:"required,max=100"`
+	Description string       `json:"description,omitempty" validate:"max=255"`
+	Rules       SegmentRules `json:"rules"`
+}
+
+type UpdateCustomerSegmentRequest struct {
+	Name        *string       `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string       `json:"description,omitempty" validate:"omitempty,max=255"`
+	Rules       *SegmentRules `json:"rules,omitempty"`
+}
+
+// SegmentMembership is the customers matching a segment when it was evaluated, highest spend first
+type SegmentMembership struct {
+	Segment     *CustomerSegment `json:"segment,omitempty"`
+	Rules       SegmentRules     `json:"rules"`
+	EvaluatedAt time.Time        `json:"evaluated_at"`
+	MemberCount int              `json:"member_count"`
+	Members     []*CustomerRFM   `json:"members"`
+}
+
+// WriteCSV writes one line per member, with a header line
+func (m *SegmentMembership) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"customer_id", "first_name", "last_name", "email", "phone_number", "last_order_at",
+		"recency_days", "frequency", "monetary", "recency_score", "frequency_score", "monetary_score", "segment",
+	})
+	for _, member := range m.Members {
+		writer.Write([]string{
+			strconv.FormatInt(member.CustomerID, 10),
+			member.FirstName,
+			member.LastName,
+			member.Email,
+			member.PhoneNumber,
+			member.LastOrderAt.Format(time.RFC3339),
+			strconv.Itoa(member.RecencyDays),
+			strconv.FormatInt(member.Frequency, 10),
+			strconv.FormatFloat(member.Monetary, 'f', -1, 64),
+			strconv.Itoa(member.RecencyScore),
+			strconv.Itoa(member.FrequencyScore),
+			strconv.Itoa(member.MonetaryScore),
+			member.Segment,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// RFMSegmentSummary aggregates the customers of one RFM segment
+type RFMSegmentSummary struct {
+	Segment          string  `json:"segment"`
+	CustomerCount    int     `json:"customer_count"`
+	Monetary         float64 `json:"monetary"`
+	AverageMonetary  float64 `json:"average_monetary"`
+	AverageFrequency float64 `json:"average_frequency"`
+	AverageRecency   float64 `json:"average_recency_days"`
+}
+
+// RFMReport breaks down the customers with orders by RFM segment
+type RFMReport struct {
+	WindowDays    int                  `json:"window_days"`
+	PlatformIDs   []int64              `json:"platform_ids,omitempty"`
+	CustomerCount int                  `json:"customer_count"`
+	Segments      []*RFMSegmentSummary `json:"segments"`
+	EvaluatedAt   time.Time            `json:"evaluated_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type Tag struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Color     string    `db:"color" json:"color,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type CreateTagRequest struct {
+	Name  string `json:"name" validate:"required,max=50"`
+	Color string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor|len=0"`
+}
+
+// CustomerNote is one entry of the notes timeline of a customer, notes can't be edited
+type CustomerNote struct {
+	ID         int64 `db:"id" json:"id"`
+	CustomerID int64 `db:"customer_id" json:"customer_id"`
+	// AuthorID is nil once the author has been deleted, AuthorName stays
+	AuthorID   *int64    `db:"author_id" json:"author_id,omitempty"`
+	AuthorName string    `db:"author_name" json:"author_name"`
+	Body       string    `db:"body" json:"body"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type CreateCustomerNoteRequest struct {
+	Body string `json:"body" validate:"required,max=5000"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// TwoFactor is the TOTP second factor of a user, pending until EnabledAt is set
+type TwoFactor struct {
+	UserID int64  `db:"user_id" json:"user_id"`
+	Secret string `db:"secret" json:"-"`
+	// LastStep is the time step of the last accepted code, older or equal steps are refused
+	LastStep       int64      `db:"last_step" json:"-"`
+	EnabledAt      *time.Time `db:"enabled_at" json:"enabled_at,omitempty"`
+	FailedAttempts int        `db:"failed_attempts" json:"-"`
+	LockedUntil    *time.Time `db:"locked_until" json:"-"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// IsEnabled reports whether codes are asked for at login
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// TwoFactorStatus is the second factor state of the signed in user
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required is true for admins when two-factor authentication is mandatory for them
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
+
+// TwoFactorSetup is the pending secret, ProvisioningURI is meant to be shown as a QR code
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodes are only returned when generated, each can be used once instead of a TOTP code
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// TwoFactorCodeRequest carries a code of the authenticator app, or a recovery code where accepted
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// LoginTwoFactorRequest completes a login of a user with two-factor authentication,
+// Code is a code of the authenticator app or a recovery code
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+// LoginResponse holds the tokens, or the challenge to complete with POST /auth/login/2fa
+// when the user has two-factor authentication
+type LoginResponse struct {
+	*TokenPair
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+	ChallengeExpiresIn int64  `json:"challenge_expires_in,omitempty"`
+}
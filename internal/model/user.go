@@ -4,17 +4,26 @@ import "time"
 
 // User represents a user in the system
 type User struct {
-	ID        int64     `db:"id" json:"id"`
-	Name      string    `db:"name" json:"name"`
-	Email     string    `db:"email" json:"email"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID    int64  `db:"id" json:"id"`
+	Name  string `db:"name" json:"name"`
+	Email string `db:"email" json:"email"`
+	// PasswordHash is only loaded to check credentials
+	PasswordHash string `db:"password_hash" json:"-"`
+	IsActive     bool   `db:"is_active" json:"is_active"`
+	// EmailVerifiedAt is cleared when the email changes
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at,omitempty"`
+	// Role applies to every store, store roles are in store_users
+	Role        string     `db:"role" json:"role,omitempty"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // CreateUserRequest is the request body for creating a new user
 type CreateUserRequest struct {
-	Name  string `json:"name" validate:"required"`
-	Email string `json:"email" validate:"required,email"`
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
 }
 
 // UpdateUserRequest is the request body for updating a user
@@ -22,3 +31,47 @@ type UpdateUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email" validate:"omitempty,email"`
 }
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// TokenPair is returned on login and refresh, the refresh token can be used once
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             *User     `json:"user"`
+}
+
+// RefreshToken is a server side session, only the SHA-256 of the token is stored
+type RefreshToken struct {
+	ID           int64      `db:"id" json:"id"`
+	UserID       int64      `db:"user_id" json:"user_id"`
+	FamilyID     string     `db:"family_id" json:"family_id"`
+	TokenHash    string     `db:"token_hash" json:"-"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt    *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	ReplacedByID *int64     `db:"replaced_by_id" json:"replaced_by_id,omitempty"`
+	UserAgent    string     `db:"user_agent" json:"user_agent,omitempty"`
+	IPAddress    string     `db:"ip_address" json:"ip_address,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ClientInfo identifies the client a session was opened from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// Purposes of the one-time tokens sent by email
+const (
+	TokenPurposeInvitation        = "invitation"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+// UserToken is a one-time token sent to Email, only the SHA-256 of the token is stored
+type UserToken struct {
+	ID        int64      `db:"id" json:"id"`
+	UserID    int64      `db:"user_id" json:"user_id"`
+	Purpose   string     `db:"purpose" json:"purpose"`
+	Email     string     `db:"email" json:"email"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time `db:"used_at" json:"used_at,omitempty"`
+	CreatedBy *int64     `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// InviteUserRequest creates a user without a password, they choose it when accepting the invitation
+type InviteUserRequest struct {
+	Name  string `json:"name" validate:"required,max=255"`
+	Email string `json:"email" validate:"required,email"`
+	// Role applies to every store, empty to only give store roles later
+	Role string `json:"role" validate:"omitempty,oneof=admin manager cashier warehouse read_only"`
+}
+
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type APIKeyRepository struct {
+	db *database.DB
+}
+
+func NewAPIKeyRepository(db *database.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+var apiKeyColumns = []interface{}{
+	"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "last_used_at", "revoked_at", "created_at",
+}
+
+// apiKeyTouchInterval limits how often last_used_at is written for a key in constant use
+const apiKeyTouchInterval = time.Minute
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode api key scopes: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Insert("api_keys").Rows(
+		goqu.Record{
+			"user_id":    key.UserID,
+			"name":       key.Name,
+			"prefix":     key.Prefix,
+			"key_hash":   key.KeyHash,
+			"scopes":     string(scopes),
+			"expires_at": key.ExpiresAt,
+		}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int64) (*model.APIKey, error) {
+	query, args, err := r.db.Dialect.
+		Select(apiKeyColumns...).From("api_keys").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	key, err := scanAPIKey(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("api key not found")
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetByHash returns the key with the hash, nil when unknown
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	query, args, err := r.db.Dialect.
+		Select(apiKeyColumns...).From("api_keys").Where(goqu.Ex{"key_hash": keyHash}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	key, err := scanAPIKey(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetByUser lists the keys of the user, newest first
+func (r *APIKeyRepository) GetByUser(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	query, args, err := r.db.Dialect.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		Where(goqu.Ex{"user_id": userID}).
+		Order(goqu.I("id").Desc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke revokes the key of the user, revoking twice is a no-op
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID, id int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("api_keys").
+		Set(goqu.Record{"revoked_at": at}).
+		Where(goqu.Ex{"id": id, "user_id": userID, "revoked_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Touch records the key was used, at most once per apiKeyTouchInterval
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("api_keys").
+		Set(goqu.Record{"last_used_at": at}).
+		Where(
+			goqu.Ex{"id": id},
+			goqu.Or(
+				goqu.I("last_used_at").IsNull(),
+				goqu.I("last_used_at").Lt(at.Add(-apiKeyTouchInterval)),
+			),
+		).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update api key last use: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var (
+		key        model.APIKey
+		scopes     string
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("invalid scopes of api key %d: %w", key.ID, err)
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
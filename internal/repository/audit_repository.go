@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"simple-template/pkg/pagination"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type AuditRepository struct {
+	db *database.DB
+}
+
+func NewAuditRepository(db *database.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+var auditLogColumns = []interface{}{
+	"id", "actor_user_id", "actor_api_key_id", "action", "entity_type", "entity_id", "changes", "ip_address", "request_id", "created_at",
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *model.AuditLog) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Insert("audit_logs").Rows(
+		goqu.Record{
+			"actor_user_id":    entry.ActorUserID,
+			"actor_api_key_id": entry.ActorAPIKeyID,
+			"action":           entry.Action,
+			"entity_type":      entry.EntityType,
+			"entity_id":        entry.EntityID,
+			"changes":          string(changes),
+			"ip_address":       utils.NullIfEmpty(entry.IPAddress),
+			"request_id":       utils.NullIfEmpty(entry.RequestID),
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+	return nil
+}
+
+// RedactCustomer replaces the personal data of the customer in its audit logs and in those of its orders
+func (r *AuditRepository) RedactCustomer(ctx context.Context, tx *sql.Tx, customerID int64) error {
+	orderIDs := r.db.Dialect.From("orders").Select("id").Where(goqu.Ex{"customer_id": customerID})
+	query, args, err := r.db.Dialect.
+		From("audit_logs").
+		Select("id", "entity_type", "changes").
+		Where(goqu.Or(
+			goqu.Ex{"entity_type": model.AuditEntityCustomer, "entity_id": customerID},
+			goqu.Ex{"entity_type": model.AuditEntityOrder, "entity_id": orderIDs},
+		)).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	redacted := map[int64]map[string]*model.AuditChange{}
+	for rows.Next() {
+		var (
+			id         int64
+			entityType string
+			data       string
+			changes    map[string]*model.AuditChange
+		)
+		if err := rows.Scan(&id, &entityType, &data); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &changes); err != nil {
+			rows.Close()
+			return fmt.Errorf("invalid changes of audit log %d: %w", id, err)
+		}
+		fields := model.CustomerPersonalFields
+		if entityType == model.AuditEntityOrder {
+			fields = model.OrderPersonalFields
+		}
+		if model.RedactAuditChanges(changes, fields) {
+			redacted[id] = changes
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for id, changes := range redacted {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("failed to encode audit changes: %w", err)
+		}
+		query, args, err := r.db.Dialect.
+			Update("audit_logs").
+			Set(goqu.Record{"changes": string(data)}).
+			Where(goqu.Ex{"id": id}).
+			ToSQL()
+		if err != nil {
+			return fmt.Errorf("failed to build query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to redact audit log %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// GetAllPaginated lists the audit logs matching the filter, from and to bound created_at as [from, to)
+func (r *AuditRepository) GetAllPaginated(
+	ctx context.Context,
+	filter *model.AuditFilter,
+	from, to *time.Time,
+	cursor string,
+	limit int,
+	order string,
+	sortBy string,
+) ([]*model.AuditLog, error) {
+	query := r.db.Dialect.Select(auditLogColumns...).From("audit_logs")
+	if filter.EntityType != "" {
+		query = query.Where(goqu.Ex{"entity_type": filter.EntityType})
+	}
+	if filter.EntityID > 0 {
+		query = query.Where(goqu.Ex{"entity_id": filter.EntityID})
+	}
+	if filter.ActorUserID > 0 {
+		query = query.Where(goqu.Ex{"actor_user_id": filter.ActorUserID})
+	}
+	if filter.Action != "" {
+		query = query.Where(goqu.Ex{"action": filter.Action})
+	}
+	if from != nil {
+		query = query.Where(goqu.I("created_at").Gte(*from))
+	}
+	if to != nil {
+		query = query.Where(goqu.I("created_at").Lt(*to))
+	}
+
+	query, err := pagination.NewQueryBuilder().ApplyCursorPagination(query, cursor, limit, order, sortBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply cursor pagination: %w", err)
+	}
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*model.AuditLog{}
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return entries, nil
+}
+
+func scanAuditLog(row rowScanner) (*model.AuditLog, error) {
+	var (
+		entry         model.AuditLog
+		actorUserID   sql.NullInt64
+		actorAPIKeyID sql.NullInt64
+		changes       string
+		ipAddress     sql.NullString
+		requestID     sql.NullString
+	)
+	err := row.Scan(
+		&entry.ID,
+		&actorUserID,
+		&actorAPIKeyID,
+		&entry.Action,
+		&entry.EntityType,
+		&entry.EntityID,
+		&changes,
+		&ipAddress,
+		&requestID,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit log: %w", err)
+	}
+	if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+		return nil, fmt.Errorf("invalid changes of audit log %d: %w", entry.ID, err)
+	}
+	if actorUserID.Valid {
+		entry.ActorUserID = &actorUserID.Int64
+	}
+	if actorAPIKeyID.Valid {
+		entry.ActorAPIKeyID = &actorAPIKeyID.Int64
+	}
+	entry.IPAddress = utils.NullStringToString(ipAddress)
+	entry.RequestID = utils.NullStringToString(requestID)
+	return &entry, nil
+}
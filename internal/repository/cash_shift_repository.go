@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type CashShiftRepository struct {
+	db *database.DB
+}
+
+func NewCashShiftRepository(db *database.DB) *CashShiftRepository {
+	return &CashShiftRepository{
+		db: db,
+	}
+}
+
+var cashShiftColumns = []interface{}{
+	"id", "retail_store_id", "user_id", "status", "opening_float",
+	"cash_sales_amount", "cash_in_amount", "cash_out_amount", "expected_amount",
+	"counted_amount", "difference_amount", "order_count",
+	"opening_note", "closing_note", "opened_at", "closed_at",
+}
+
+func (r *CashShiftRepository) Create(ctx context.Context, shift *model.CashShift) error {
+	query, args, err := r.db.Dialect.
+		Insert("cash_shifts").Rows(
+		goqu.Record{
+			"retail_store_id": shift.RetailStoreID,
+			"user_id":         shift.UserID,
+			"status":          model.CashShiftStatusOpen,
+			"opening_float":   shift.OpeningFloat,
+			"opening_note":    utils.NullIfEmpty(shift.OpeningNote),
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to open cash shift: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	shift.ID = id
+	return nil
+}
+
+func (r *CashShiftRepository) GetByID(ctx context.Context, id int64) (*model.CashShift, error) {
+	query, args, err := r.db.Dialect.
+		Select(cashShiftColumns...).
+		From("cash_shifts").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	shift, err := scanCashShift(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("cash shift not found")
+		}
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetOpenByStore returns the open shift of the store, or nil if there is none
+func (r *CashShiftRepository) GetOpenByStore(ctx context.Context, retailStoreID int64) (*model.CashShift, error) {
+	query, args, err := r.db.Dialect.
+		Select(cashShiftColumns...).
+		From("cash_shifts").
+		Where(goqu.Ex{"retail_store_id": retailStoreID, "status": model.CashShiftStatusOpen}).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	shift, err := scanCashShift(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetByStore returns the shifts of a store opened in [from, to), newest first
+func (r *CashShiftRepository) GetByStore(ctx context.Context, retailStoreID int64, from, to *time.Time) ([]*model.CashShift, error) {
+	where := []goqu.Expression{goqu.Ex{"retail_store_id": retailStoreID}}
+	if from != nil {
+		where = append(where, goqu.I("opened_at").Gte(*from))
+	}
+	if to != nil {
+		where = append(where, goqu.I("opened_at").Lt(*to))
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(cashShiftColumns...).
+		From("cash_shifts").
+		Where(where...).
+		Order(goqu.I("opened_at").Desc(), goqu.I("id").Desc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash shifts: %w", err)
+	}
+	defer rows.Close()
+
+	shifts := []*model.CashShift{}
+	for rows.Next() {
+		shift, err := scanCashShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return shifts, nil
+}
+
+// Close freezes the amounts of an open shift, it fails if the shift was closed meanwhile
+func (r *CashShiftRepository) Close(ctx context.Context, shift *model.CashShift) error {
+	query, args, err := r.db.Dialect.
+		Update("cash_shifts").
+		Set(goqu.Record{
+			"status":            model.CashShiftStatusClosed,
+			"cash_sales_amount": shift.CashSales,
+			"cash_in_amount":    shift.CashIn,
+			"cash_out_amount":   shift.CashOut,
+			"expected_amount":   shift.ExpectedAmount,
+			"counted_amount":    shift.CountedAmount,
+			"difference_amount": shift.Difference,
+			"order_count":       shift.OrderCount,
+			"closing_note":      utils.NullIfEmpty(shift.ClosingNote),
+			"closed_at":         shift.ClosedAt,
+		}).
+		Where(goqu.Ex{"id": shift.ID, "status": model.CashShiftStatusOpen}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to close cash shift: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.Conflict("cash shift is already closed")
+	}
+	return nil
+}
+
+func (r *CashShiftRepository) CreateMovement(ctx context.Context, movement *model.CashMovement) error {
+	query, args, err := r.db.Dialect.
+		Insert("cash_movements").Rows(
+		goqu.Record{
+			"shift_id": movement.ShiftID,
+			"type":     movement.Type,
+			"amount":   movement.Amount,
+			"reason":   movement.Reason,
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create cash movement: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	movement.ID = id
+	return nil
+}
+
+func (r *CashShiftRepository) GetMovements(ctx context.Context, shiftID int64) ([]*model.CashMovement, error) {
+	query, args, err := r.db.Dialect.
+		Select("id", "shift_id", "type", "amount", "reason", "created_at").
+		From("cash_movements").
+		Where(goqu.Ex{"shift_id": shiftID}).
+		Order(goqu.I("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash movements: %w", err)
+	}
+	defer rows.Close()
+
+	movements := []*model.CashMovement{}
+	for rows.Next() {
+		var movement model.CashMovement
+		if err := rows.Scan(
+			&movement.ID,
+			&movement.ShiftID,
+			&movement.Type,
+			&movement.Amount,
+			&movement.Reason,
+			&movement.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan cash movement: %w", err)
+		}
+		movements = append(movements, &movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return movements, nil
+}
+
+// GetCashSales sums the paid cash orders of a store created in [from, to), canceled and returned orders excluded.
+// Unpaid orders brought no cash to the drawer, orders are paid at the POS checkout or when moved to the paid status.
+// A nil to means up to now
+func (r *CashShiftRepository) GetCashSales(ctx context.Context, retailStoreID int64, from time.Time, to *time.Time) (*model.CashSales, error) {
+	latestStatusSubquery := latestOrderStatusQuery(r.db)
+
+	where := []goqu.Expression{
+		goqu.Ex{
+			"orders.retail_stores_id": retailStoreID,
+			"orders.payment_status":   model.PaymentStatusPaid,
+			"payment_methods.code":    model.PaymentMethodCodeCash,
+		},
+		goqu.I("orders.created_at").Gte(from),
+		goqu.Or(
+			goqu.I("ls.status").IsNull(),
+			goqu.I("ls.status").NotIn(int8(model.OrderStatusCanceled), int8(model.OrderStatusReturned)),
+		),
+	}
+	if to != nil {
+		where = append(where, goqu.I("orders.created_at").Lt(*to))
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.L("COALESCE(SUM(orders.total_amount), 0)"),
+			goqu.COUNT("orders.id"),
+		).
+		From("orders").
+		Join(
+			goqu.T("payment_methods"),
+			goqu.On(goqu.Ex{"payment_methods.id": goqu.I("orders.payment_id")}),
+		).
+		LeftJoin(
+			latestStatusSubquery.As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		Where(where...).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	sales := &model.CashSales{}
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&sales.Amount, &sales.OrderCount); err != nil {
+		return nil, fmt.Errorf("failed to get cash sales: %w", err)
+	}
+	return sales, nil
+}
+
+func scanCashShift(row rowScanner) (*model.CashShift, error) {
+	var (
+		shift          model.CashShift
+		cashSales      sql.NullFloat64
+		cashIn         sql.NullFloat64
+		cashOut        sql.NullFloat64
+		expectedAmount sql.NullFloat64
+		countedAmount  sql.NullFloat64
+		difference     sql.NullFloat64
+		orderCount     sql.NullInt64
+		openingNote    sql.NullString
+		closingNote    sql.NullString
+		closedAt       sql.NullTime
+	)
+	err := row.Scan(
+		&shift.ID,
+		&shift.RetailStoreID,
+		&shift.UserID,
+		&shift.Status,
+		&shift.OpeningFloat,
+		&cashSales,
+		&cashIn,
+		&cashOut,
+		&expectedAmount,
+		&countedAmount,
+		&difference,
+		&orderCount,
+		&openingNote,
+		&closingNote,
+		&shift.OpenedAt,
+		&closedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan cash shift: %w", err)
+	}
+	shift.CashSales = cashSales.Float64
+	shift.CashIn = cashIn.Float64
+	shift.CashOut = cashOut.Float64
+	shift.ExpectedAmount = expectedAmount.Float64
+	if countedAmount.Valid {
+		shift.CountedAmount = &countedAmount.Float64
+	}
+	if difference.Valid {
+		shift.Difference = &difference.Float64
+	}
+	shift.OrderCount = orderCount.Int64
+	shift.OpeningNote = utils.NullStringToString(openingNote)
+	shift.ClosingNote = utils.NullStringToString(closingNote)
+	if closedAt.Valid {
+		shift.ClosedAt = &closedAt.Time
+	}
+	return &shift, nil
+}
@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type ChannelRepository struct {
+	db *database.DB
+}
+
+func NewChannelRepository(db *database.DB) *ChannelRepository {
+	return &ChannelRepository{
+		db: db,
+	}
+}
+
+func (r *ChannelRepository) CreateSkuMapping(ctx context.Context, mapping *model.ChannelSkuMapping) error {
+	query, args, err := r.db.Dialect.
+		Insert("channel_sku_mappings").
+		Rows(goqu.Record{
+			"platform_id":         mapping.PlatformID,
+			"external_sku":        mapping.ExternalSKU,
+			"external_product_id": utils.NullIfEmpty(mapping.ExternalProductID),
+			"external_variant_id": utils.NullIfEmpty(mapping.ExternalVariantID),
+			"variant_value_id":    mapping.VariantValueID,
+		}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create sku mapping: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	mapping.ID = id
+	return nil
+}
+
+func (r *ChannelRepository) DeleteSkuMapping(ctx context.Context, platformID, id int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("channel_sku_mappings").
+		Where(goqu.Ex{"id": id, "platform_id": platformID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build delete query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete sku mapping: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("sku mapping not found")
+	}
+	return nil
+}
+
+// GetListings returns the SKU mappings of a platform with current stock and active price
+// When skus is not empty only those marketplace SKUs are returned
+func (r *ChannelRepository) GetListings(ctx context.Context, platformID int64, skus []string) ([]*model.ChannelListing, error) {
+	// Latest active price of the variant the value belongs to
+	activePrice := r.db.Dialect.
+		Select("id").
+		From("price").
+		Where(goqu.Ex{
+			"price.variant_id": goqu.I("pvv.attribute_id"),
+			"price.status":     1,
+		}).
+		Order(goqu.I("price.effective_from").Desc(), goqu.I("price.id").Desc()).
+		Limit(1)
+
+	query := r.db.Dialect.
+		Select(
+			goqu.I("m.id"),
+			goqu.I("m.platform_id"),
+			goqu.I("m.external_sku"),
+			goqu.I("m.external_product_id"),
+			goqu.I("m.external_variant_id"),
+			goqu.I("m.variant_value_id"),
+			goqu.I("m.created_at"),
+			goqu.I("m.updated_at"),
+			goqu.I("pvv.stock_quantity"),
+			goqu.L("COALESCE(p.id, 0)").As("price_id"),
+			goqu.L("COALESCE(p.price, 0)").As("price"),
+		).
+		From(goqu.T("channel_sku_mappings").As("m")).
+		Join(
+			goqu.T("product_variant_value").As("pvv"),
+			goqu.On(goqu.Ex{"pvv.id": goqu.I("m.variant_value_id")}),
+		).
+		LeftJoin(
+			goqu.T("price").As("p"),
+			goqu.On(goqu.L("? = ?", goqu.I("p.id"), activePrice)),
+		).
+		Where(goqu.Ex{"m.platform_id": platformID}).
+		Order(goqu.I("m.external_sku").Asc())
+
+	if len(skus) > 0 {
+		query = query.Where(goqu.Ex{"m.external_sku": skus})
+	}
+
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel listings: %w", err)
+	}
+	defer rows.Close()
+
+	var listings []*model.ChannelListing
+	for rows.Next() {
+		var (
+			listing           model.ChannelListing
+			externalProductID sql.NullString
+			externalVariantID sql.NullString
+		)
+		err := rows.Scan(
+			&listing.ID,
+			&listing.PlatformID,
+			&listing.ExternalSKU,
+			&externalProductID,
+			&externalVariantID,
+			&listing.VariantValueID,
+			&listing.CreatedAt,
+			&listing.UpdatedAt,
+			&listing.StockQuantity,
+			&listing.PriceID,
+			&listing.Price,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel listing: %w", err)
+		}
+		listing.ExternalProductID = utils.NullStringToString(externalProductID)
+		listing.ExternalVariantID = utils.NullStringToString(externalVariantID)
+		listings = append(listings, &listing)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return listings, nil
+}
+
+// GetImportedExternalIDs returns which of the marketplace order IDs were already imported
+func (r *ChannelRepository) GetImportedExternalIDs(ctx context.Context, platformID int64, externalIDs []string) (map[string]bool, error) {
+	imported := make(map[string]bool)
+	if len(externalIDs) == 0 {
+		return imported, nil
+	}
+
+	query, args, err := r.db.Dialect.
+		Select("external_order_id").
+		From("channel_orders").
+		Where(goqu.Ex{
+			"platform_id":       platformID,
+			"external_order_id": externalIDs,
+		}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var externalID string
+		if err := rows.Scan(&externalID); err != nil {
+			return nil, fmt.Errorf("failed to scan channel order: %w", err)
+		}
+		imported[externalID] = true
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return imported, nil
+}
+
+// CreateChannelOrder links the imported order in the transaction creating it,
+// the unique key on the external order id stops concurrent syncs from importing it twice
+func (r *ChannelRepository) CreateChannelOrder(ctx context.Context, tx *sql.Tx, channelOrder *model.ChannelOrder) error {
+	query, args, err := r.db.Dialect.
+		Insert("channel_orders").
+		Rows(goqu.Record{
+			"platform_id":       channelOrder.PlatformID,
+			"external_order_id": channelOrder.ExternalOrderID,
+			"order_id":          channelOrder.OrderID,
+			"external_status":   channelOrder.ExternalStatus,
+		}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return apperror.Conflict("marketplace order %s is already imported", channelOrder.ExternalOrderID)
+		}
+		return fmt.Errorf("failed to create channel order: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	channelOrder.ID = id
+	return nil
+}
+
+func (r *ChannelRepository) GetChannelOrderByOrderID(ctx context.Context, orderID int64) (*model.ChannelOrder, error) {
+	query, args, err := r.db.Dialect.
+		Select("id", "platform_id", "external_order_id", "order_id", "external_status",
+			"tracking_number", "carrier", "shipped_at", "created_at", "updated_at").
+		From("channel_orders").
+		Where(goqu.Ex{"order_id": orderID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	var (
+		channelOrder   model.ChannelOrder
+		externalStatus sql.NullString
+		trackingNumber sql.NullString
+		carrier        sql.NullString
+		shippedAt      sql.NullTime
+	)
+	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&channelOrder.ID,
+		&channelOrder.PlatformID,
+		&channelOrder.ExternalOrderID,
+		&channelOrder.OrderID,
+		&externalStatus,
+		&trackingNumber,
+		&carrier,
+		&shippedAt,
+		&channelOrder.CreatedAt,
+		&channelOrder.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("order was not imported from a marketplace")
+		}
+		return nil, fmt.Errorf("failed to get channel order: %w", err)
+	}
+
+	channelOrder.ExternalStatus = utils.NullStringToString(externalStatus)
+	channelOrder.TrackingNumber = utils.NullStringToString(trackingNumber)
+	channelOrder.Carrier = utils.NullStringToString(carrier)
+	if shippedAt.Valid {
+		channelOrder.ShippedAt = &shippedAt.Time
+	}
+	return &channelOrder, nil
+}
+
+func (r *ChannelRepository) MarkShipped(ctx context.Context, id int64, trackingNumber, carrier string) error {
+	query, args, err := r.db.Dialect.
+		Update("channel_orders").
+		Set(goqu.Record{
+			"tracking_number": trackingNumber,
+			"carrier":         utils.NullIfEmpty(carrier),
+			"shipped_at":      time.Now(),
+		}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update channel order: %w", err)
+	}
+	return nil
+}
+
+func (r *ChannelRepository) CreateSyncRun(ctx context.Context, run *model.ChannelSyncRun) error {
+	query, args, err := r.db.Dialect.
+		Insert("channel_sync_runs").
+		Rows(goqu.Record{
+			"platform_id": run.PlatformID,
+			"kind":        run.Kind,
+			"status":      run.Status,
+			"started_at":  run.StartedAt,
+		}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build insert query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create sync run: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+func (r *ChannelRepository) FinishSyncRun(ctx context.Context, run *model.ChannelSyncRun) error {
+	query, args, err := r.db.Dialect.
+		Update("channel_sync_runs").
+		Set(goqu.Record{
+			"status":        run.Status,
+			"processed":     run.Processed,
+			"skipped":       run.Skipped,
+			"failed":        run.Failed,
+			"error_message": utils.NullIfEmpty(run.ErrorMessage),
+			"resume_from":   run.ResumeFrom,
+			"finished_at":   run.FinishedAt,
+		}).
+		Where(goqu.Ex{"id": run.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update sync run: %w", err)
+	}
+	return nil
+}
+
+// GetLastFinishedRun returns the latest succeeded or partial run of a kind, or nil if there is none
+func (r *ChannelRepository) GetLastFinishedRun(ctx context.Context, platformID int64, kind string) (*model.ChannelSyncRun, error) {
+	runs, err := r.getSyncRuns(ctx, goqu.Ex{
+		"platform_id": platformID,
+		"kind":        kind,
+		"status":      []string{model.ChannelSyncStatusSucceeded, model.ChannelSyncStatusPartial},
+	}, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, nil
+	}
+	return runs[0], nil
+}
+
+func (r *ChannelRepository) GetSyncRuns(ctx context.Context, platformID int64, limit int) ([]*model.ChannelSyncRun, error) {
+	return r.getSyncRuns(ctx, goqu.Ex{"platform_id": platformID}, limit)
+}
+
+func (r *ChannelRepository) getSyncRuns(ctx context.Context, where goqu.Ex, limit int) ([]*model.ChannelSyncRun, error) {
+	query, args, err := r.db.Dialect.
+		Select("id", "platform_id", "kind", "status", "processed", "skipped", "failed",
+			"error_message", "resume_from", "started_at", "finished_at").
+		From("channel_sync_runs").
+		Where(where).
+		Order(goqu.I("started_at").Desc(), goqu.I("id").Desc()).
+		Limit(uint(limit)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*model.ChannelSyncRun
+	for rows.Next() {
+		var (
+			run          model.ChannelSyncRun
+			errorMessage sql.NullString
+			resumeFrom   sql.NullTime
+			finishedAt   sql.NullTime
+		)
+		err := rows.Scan(
+			&run.ID,
+			&run.PlatformID,
+			&run.Kind,
+			&run.Status,
+			&run.Processed,
+			&run.Skipped,
+			&run.Failed,
+			&errorMessage,
+			&resumeFrom,
+			&run.StartedAt,
+			&finishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync run: %w", err)
+		}
+		run.ErrorMessage = utils.NullStringToString(errorMessage)
+		if resumeFrom.Valid {
+			run.ResumeFrom = &resumeFrom.Time
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, &run)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Default address flags of a customer
+const (
+	AddressDefaultShipping = "is_default_shipping"
+	AddressDefaultBilling  = "is_default_billing"
+)
+
+type CustomerAddressRepository struct {
+	db *database.DB
+}
+
+func NewCustomerAddressRepository(db *database.DB) *CustomerAddressRepository {
+	return &CustomerAddressRepository{
+		db: db,
+	}
+}
+
+var customerAddressColumns = []interface{}{
+	"id", "customer_id", "label", "recipient_name", "phone_number", "street",
+	"ward", "district", "province", "postal_code",
+	"is_default_shipping", "is_default_billing", "created_at", "updated_at",
+}
+
+func (r *CustomerAddressRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.SQL.BeginTx(ctx, nil)
+}
+
+func (r *CustomerAddressRepository) Create(ctx context.Context, tx *sql.Tx, address *model.CustomerAddress) error {
+	query, args, err := r.db.Dialect.
+		Insert("customer_addresses").Rows(
+		addressRecord(address, goqu.Record{"customer_id": address.CustomerID}),
+	).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create customer address: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	address.ID = id
+	return nil
+}
+
+func (r *CustomerAddressRepository) Update(ctx context.Context, tx *sql.Tx, address *model.CustomerAddress) error {
+	query, args, err := r.db.Dialect.
+		Update("customer_addresses").
+		Set(addressRecord(address, goqu.Record{})).
+		Where(goqu.Ex{"id": address.ID, "customer_id": address.CustomerID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update customer address: %w", err)
+	}
+	return nil
+}
+
+func (r *CustomerAddressRepository) Delete(ctx context.Context, tx *sql.Tx, customerID, id int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("customer_addresses").
+		Where(goqu.Ex{"id": id, "customer_id": customerID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete customer address: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("customer address not found")
+	}
+	return nil
+}
+
+// DeleteByCustomer deletes every address of the customer, orders keep their shipping address snapshot
+func (r *CustomerAddressRepository) DeleteByCustomer(ctx context.Context, tx *sql.Tx, customerID int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("customer_addresses").
+		Where(goqu.Ex{"customer_id": customerID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete customer addresses: %w", err)
+	}
+	return nil
+}
+
+// ClearDefault unsets a default flag on every address of the customer except exceptID
+func (r *CustomerAddressRepository) ClearDefault(ctx context.Context, tx *sql.Tx, customerID int64, flag string, exceptID int64) error {
+	query, args, err := r.db.Dialect.
+		Update("customer_addresses").
+		Set(goqu.Record{flag: false}).
+		Where(
+			goqu.Ex{"customer_id": customerID, flag: true},
+			goqu.I("id").Neq(exceptID),
+		).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to clear default address: %w", err)
+	}
+	return nil
+}
+
+// PromoteDefault sets a default flag on the most recent address of the customer
+func (r *CustomerAddressRepository) PromoteDefault(ctx context.Context, tx *sql.Tx, customerID int64, flag string) error {
+	query, args, err := r.db.Dialect.
+		Update("customer_addresses").
+		Set(goqu.Record{flag: true}).
+		Where(goqu.Ex{"customer_id": customerID}).
+		Order(goqu.I("id").Desc()).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to promote default address: %w", err)
+	}
+	return nil
+}
+
+// ReassignCustomer moves the addresses of the merged customers to toID, their default flags are dropped
+func (r *CustomerAddressRepository) ReassignCustomer(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) (int64, error) {
+	query, args, err := r.db.Dialect.
+		Update("customer_addresses").
+		Set(goqu.Record{
+			"customer_id":          toID,
+			AddressDefaultShipping: false,
+			AddressDefaultBilling:  false,
+		}).
+		Where(goqu.Ex{"customer_id": fromIDs}).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move customer addresses: %w", err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return moved, nil
+}
+
+func (r *CustomerAddressRepository) GetByID(ctx context.Context, customerID, id int64) (*model.CustomerAddress, error) {
+	query, args, err := r.db.Dialect.
+		Select(customerAddressColumns...).
+		From("customer_addresses").
+		Where(goqu.Ex{"id": id, "customer_id": customerID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	address, err := scanCustomerAddress(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("customer address not found")
+		}
+		return nil, err
+	}
+	return address, nil
+}
+
+// GetByCustomer lists the addresses of a customer, default shipping address first
+func (r *CustomerAddressRepository) GetByCustomer(ctx context.Context, customerID int64) ([]*model.CustomerAddress, error) {
+	query, args, err := r.db.Dialect.
+		Select(customerAddressColumns...).
+		From("customer_addresses").
+		Where(goqu.Ex{"customer_id": customerID}).
+		Order(goqu.I("is_default_shipping").Desc(), goqu.I("id").Desc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer addresses: %w", err)
+	}
+	defer rows.Close()
+
+	addresses := []*model.CustomerAddress{}
+	for rows.Next() {
+		address, err := scanCustomerAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return addresses, nil
+}
+
+// GetDefaultShipping returns the default shipping address of the customer, or nil if there is none
+func (r *CustomerAddressRepository) GetDefaultShipping(ctx context.Context, customerID int64) (*model.CustomerAddress, error) {
+	query, args, err := r.db.Dialect.
+		Select(customerAddressColumns...).
+		From("customer_addresses").
+		Where(goqu.Ex{"customer_id": customerID, AddressDefaultShipping: true}).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	address, err := scanCustomerAddress(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return address, nil
+}
+
+func addressRecord(address *model.CustomerAddress, record goqu.Record) goqu.Record {
+	record["label"] = utils.NullIfEmpty(address.Label)
+	record["recipient_name"] = address.RecipientName
+	record["phone_number"] = address.PhoneNumber
+	record["street"] = address.Street
+	record["ward"] = utils.NullIfEmpty(address.Ward)
+	record["district"] = utils.NullIfEmpty(address.District)
+	record["province"] = utils.NullIfEmpty(address.Province)
+	record["postal_code"] = utils.NullIfEmpty(address.PostalCode)
+	record[AddressDefaultShipping] = address.IsDefaultShipping
+	record[AddressDefaultBilling] = address.IsDefaultBilling
+	return record
+}
+
+func scanCustomerAddress(row rowScanner) (*model.CustomerAddress, error) {
+	var (
+		address    model.CustomerAddress
+		label      sql.NullString
+		ward       sql.NullString
+		district   sql.NullString
+		province   sql.NullString
+		postalCode sql.NullString
+	)
+	err := row.Scan(
+		&address.ID,
+		&address.CustomerID,
+		&label,
+		&address.RecipientName,
+		&address.PhoneNumber,
+		&address.Street,
+		&ward,
+		&district,
+		&province,
+		&postalCode,
+		&address.IsDefaultShipping,
+		&address.IsDefaultBilling,
+		&address.CreatedAt,
+		&address.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan customer address: %w", err)
+	}
+	address.Label = utils.NullStringToString(label)
+	address.Ward = utils.NullStringToString(ward)
+	address.District = utils.NullStringToString(district)
+	address.Province = utils.NullStringToString(province)
+	address.PostalCode = utils.NullStringToString(postalCode)
+	return &address, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/pkg/pagination"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type CustomerNoteRepository struct {
+	db *database.DB
+}
+
+func NewCustomerNoteRepository(db *database.DB) *CustomerNoteRepository {
+	return &CustomerNoteRepository{
+		db: db,
+	}
+}
+
+var customerNoteColumns = []interface{}{"id", "customer_id", "author_id", "author_name", "body", "created_at"}
+
+func (r *CustomerNoteRepository) Create(ctx context.Context, note *model.CustomerNote) (*model.CustomerNote, error) {
+	query, args, err := r.db.Dialect.
+		Insert("customer_notes").Rows(
+		goqu.Record{
+			"customer_id": note.CustomerID,
+			"author_id":   note.AuthorID,
+			"author_name": note.AuthorName,
+			"body":        note.Body,
+		}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create customer note: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	query, args, err = r.db.Dialect.
+		Select(customerNoteColumns...).From("customer_notes").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	return scanCustomerNote(r.db.SQL.QueryRowContext(ctx, query, args...))
+}
+
+// GetPaginated lists the notes of a customer page by page
+func (r *CustomerNoteRepository) GetPaginated(
+	ctx context.Context,
+	customerID int64,
+	cursor string,
+	limit int,
+	order string,
+	sortBy string,
+) ([]*model.CustomerNote, error) {
+	query := r.db.Dialect.
+		Select(customerNoteColumns...).
+		From("customer_notes").
+		Where(goqu.Ex{"customer_id": customerID})
+
+	queryBuilder := pagination.NewQueryBuilder()
+	query, err := queryBuilder.ApplyCursorPagination(query, cursor, limit, order, sortBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply cursor pagination: %w", err)
+	}
+	return r.queryNotes(ctx, query)
+}
+
+// GetByCustomer lists every note of a customer, oldest first
+func (r *CustomerNoteRepository) GetByCustomer(ctx context.Context, customerID int64) ([]*model.CustomerNote, error) {
+	return r.queryNotes(ctx, r.db.Dialect.
+		Select(customerNoteColumns...).
+		From("customer_notes").
+		Where(goqu.Ex{"customer_id": customerID}).
+		Order(goqu.I("id").Asc()))
+}
+
+// ReassignCustomer moves the notes of the merged customers to toID
+func (r *CustomerNoteRepository) ReassignCustomer(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) error {
+	query, args, err := r.db.Dialect.
+		Update("customer_notes").
+		Set(goqu.Record{"customer_id": toID}).
+		Where(goqu.Ex{"customer_id": fromIDs}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to move customer notes: %w", err)
+	}
+	return nil
+}
+
+// DeleteByCustomer deletes the notes of an anonymized customer, they may hold personal data
+func (r *CustomerNoteRepository) DeleteByCustomer(ctx context.Context, tx *sql.Tx, customerID int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("customer_notes").
+		Where(goqu.Ex{"customer_id": customerID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete customer notes: %w", err)
+	}
+	return nil
+}
+
+func (r *CustomerNoteRepository) queryNotes(ctx context.Context, query *goqu.SelectDataset) ([]*model.CustomerNote, error) {
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []*model.CustomerNote{}
+	for rows.Next() {
+		note, err := scanCustomerNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return notes, nil
+}
+
+func scanCustomerNote(row rowScanner) (*model.CustomerNote, error) {
+	var (
+		note     model.CustomerNote
+		authorID sql.NullInt64
+	)
+	if err := row.Scan(
+		&note.ID,
+		&note.CustomerID,
+		&authorID,
+		&note.AuthorName,
+		&note.Body,
+		&note.CreatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan customer note: %w", err)
+	}
+	if authorID.Valid {
+		note.AuthorID = &authorID.Int64
+	}
+	return &note, nil
+}
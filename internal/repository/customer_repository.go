@@ -4,12 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"simple-template/pkg/pagination"
+	"strings"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
+// AnonymizedCustomerName replaces the first name of anonymized customers
+const AnonymizedCustomerName = "Anonymized"
+
 type CustomerRepository struct {
 	db *database.DB
 }
@@ -36,6 +44,9 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *model.Custome
 
 	result, err := r.db.SQL.ExecContext(ctx, query, args...)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			return nil, apperror.Conflict("phone number %s already belongs to a customer", customer.PhoneNumber)
+		}
 		return nil, fmt.Errorf("failed to create customer: %w", err)
 	}
 
@@ -49,77 +60,110 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *model.Custome
 
 func (r *CustomerRepository) GetByID(ctx context.Context, id int64) (*model.Customer, error) {
 	query, args, err := r.db.Dialect.
-		Select("id",
-			"first_name",
-			"last_name",
-			"address", "email",
-			"phone_number",
-			"created_at",
-			"updated_at").From("customer").Where(goqu.Ex{"id": id}).ToSQL()
+		Select(customerColumns...).From("customer").Where(goqu.Ex{"id": id}).ToSQL()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query %w", err)
 	}
 
-	var customer model.Customer
-	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
-		&customer.ID,
-		&customer.FirstName,
-		&customer.LastName,
-		&customer.Address,
-		&customer.Email,
-		&customer.PhoneNumber,
-		&customer.CreatedAt,
-		&customer.UpdatedAt,
-	)
+	customer, err := scanCustomer(r.db.SQL.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("customer not found")
+			return nil, apperror.NotFound("customer not found")
 		}
-		return nil, fmt.Errorf("failed to get customer")
+		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
-	return &customer, nil
+	return customer, nil
 }
 
-func (r *CustomerRepository) GetAll(ctx context.Context) ([]*model.Customer, error) {
+// FindByPhone returns the customer with the phone number, or nil if there is none
+func (r *CustomerRepository) FindByPhone(ctx context.Context, phoneNumber string) (*model.Customer, error) {
+	return r.findOne(ctx, goqu.Ex{"phone_number": phoneNumber})
+}
+
+// FindByEmail returns the first customer with the email, or nil if there is none
+func (r *CustomerRepository) FindByEmail(ctx context.Context, email string) (*model.Customer, error) {
+	return r.findOne(ctx, goqu.Ex{"email": email})
+}
+
+func (r *CustomerRepository) findOne(ctx context.Context, where goqu.Ex) (*model.Customer, error) {
 	query, args, err := r.db.Dialect.
-		Select("id",
-			"first_name",
-			"last_name",
-			"address", "email",
-			"phone_number",
-			"created_at",
-			"updated_at").
-		From("customer").
-		Order(goqu.I("created_at").Desc()).
-		ToSQL()
+		Select(customerColumns...).From("customer").Where(where).Order(goqu.I("id").Asc()).Limit(1).ToSQL()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query %w", err)
 	}
 
-	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	customer, err := scanCustomer(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find customer: %w", err)
+	}
+	return customer, nil
+}
+
+// GetAllPaginated lists customers page by page, search matches the name, phone number or email
+// and the customers must have every tag of tagIDs
+func (r *CustomerRepository) GetAllPaginated(
+	ctx context.Context,
+	search string,
+	tagIDs []int64,
+	cursor string,
+	limit int,
+	order string,
+	sortBy string,
+) ([]*model.Customer, error) {
+	query := r.db.Dialect.
+		Select(customerColumns...).
+		From("customer")
+
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := likeContains(search)
+		conditions := []goqu.Expression{
+			goqu.L("CONCAT_WS(' ', first_name, last_name)").ILike(pattern),
+			goqu.I("email").ILike(pattern),
+			goqu.I("phone_number").ILike(pattern),
+		}
+		// "0901 234-567" matches 0901234567
+		if digits := onlyDigits(search); digits != "" && digits != search {
+			conditions = append(conditions, goqu.I("phone_number").ILike(likeContains(digits)))
+		}
+		query = query.Where(goqu.Or(conditions...))
+	}
+	if len(tagIDs) > 0 {
+		tagged := r.db.Dialect.
+			Select("customer_id").
+			From("customer_tags").
+			Where(goqu.Ex{"tag_id": tagIDs}).
+			GroupBy("customer_id").
+			Having(goqu.COUNT("tag_id").Eq(len(tagIDs)))
+		query = query.Where(goqu.Ex{"id": tagged})
+	}
+
+	queryBuilder := pagination.NewQueryBuilder()
+	query, err := queryBuilder.ApplyCursorPagination(query, cursor, limit, order, sortBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply cursor pagination: %w", err)
+	}
+
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get customer %w", err)
 	}
 	defer rows.Close()
 
 	var customers []*model.Customer
-
 	for rows.Next() {
-		var customer model.Customer
-		err := rows.Scan(
-			&customer.ID,
-			&customer.FirstName,
-			&customer.LastName,
-			&customer.Address,
-			&customer.Email,
-			&customer.PhoneNumber,
-			&customer.CreatedAt,
-			&customer.UpdatedAt,
-		)
+		customer, err := scanCustomer(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan customer %w", err)
+			return nil, err
 		}
-		customers = append(customers, &customer)
+		customers = append(customers, customer)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -145,7 +189,7 @@ func (r *CustomerRepository) Update(ctx context.Context, id int64, updates map[s
 		return fmt.Errorf("failed to get row effected: %w", err)
 	}
 	if rowsEffected == 0 {
-		return fmt.Errorf("customer not found")
+		return apperror.NotFound("customer not found")
 	}
 
 	return nil
@@ -168,7 +212,194 @@ func (r *CustomerRepository) Delete(ctx context.Context, id int64) error {
 		return fmt.Errorf("failed to get rows effected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("customer not found")
+		return apperror.NotFound("customer not found")
 	}
 	return nil
 }
+
+func (r *CustomerRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.SQL.BeginTx(ctx, nil)
+}
+
+// FindDuplicateCandidates returns the customers sharing the phone number (last 9 digits), the email
+// or the full name in any order with the customer, the collation ignores case and accents
+func (r *CustomerRepository) FindDuplicateCandidates(ctx context.Context, customer *model.Customer, limit uint) ([]*model.Customer, error) {
+	var conditions []goqu.Expression
+	if digits := onlyDigits(customer.PhoneNumber); len(digits) >= 9 {
+		conditions = append(conditions,
+			goqu.L("REGEXP_REPLACE(phone_number, '[^0-9]', '')").Like("%"+digits[len(digits)-9:]))
+	}
+	if email := strings.TrimSpace(customer.Email); email != "" {
+		conditions = append(conditions, goqu.Ex{"email": email})
+	}
+	if name := strings.Join(strings.Fields(customer.FirstName+" "+customer.LastName), " "); name != "" {
+		conditions = append(conditions,
+			goqu.L("CONCAT_WS(' ', first_name, last_name) = ?", name),
+			goqu.L("CONCAT_WS(' ', last_name, first_name) = ?", name),
+		)
+	}
+	if len(conditions) == 0 {
+		return []*model.Customer{}, nil
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(customerColumns...).
+		From("customer").
+		Where(
+			goqu.I("id").Neq(customer.ID),
+			goqu.I("anonymized_at").IsNull(),
+			goqu.Or(conditions...),
+		).
+		Order(goqu.I("id").Asc()).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate customers: %w", err)
+	}
+	defer rows.Close()
+
+	customers := []*model.Customer{}
+	for rows.Next() {
+		candidate, err := scanCustomer(rows)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return customers, nil
+}
+
+// Merge deletes the merged customers then applies the updates to the surviving customer
+// Orders and addresses must have been moved within the same transaction
+func (r *CustomerRepository) Merge(ctx context.Context, tx *sql.Tx, targetID int64, sourceIDs []int64, updates map[string]interface{}) error {
+	query, args, err := r.db.Dialect.
+		Delete("customer").
+		Where(goqu.Ex{"id": sourceIDs}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete merged customers: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected != int64(len(sourceIDs)) {
+		return apperror.Conflict("customers to merge were changed meanwhile")
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+	query, args, err = r.db.Dialect.
+		Update("customer").Set(updates).Where(goqu.Ex{"id": targetID}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update customer: %w", err)
+	}
+	return nil
+}
+
+// Anonymize scrubs the personal data of the customer, the row stays for the orders referencing it
+func (r *CustomerRepository) Anonymize(ctx context.Context, tx *sql.Tx, id int64, anonymizedAt time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("customer").
+		Set(goqu.Record{
+			"first_name":    AnonymizedCustomerName,
+			"last_name":     nil,
+			"address":       nil,
+			"email":         nil,
+			"phone_number":  nil,
+			"anonymized_at": anonymizedAt,
+		}).
+		Where(goqu.Ex{"id": id, "anonymized_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to anonymize customer: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.Conflict("customer not found or already anonymized")
+	}
+	return nil
+}
+
+var customerColumns = []interface{}{
+	"id", "first_name", "last_name", "address", "email", "phone_number", "anonymized_at", "created_at", "updated_at",
+}
+
+func scanCustomer(row rowScanner) (*model.Customer, error) {
+	var (
+		customer     model.Customer
+		firstName    sql.NullString
+		lastName     sql.NullString
+		address      sql.NullString
+		email        sql.NullString
+		phoneNumber  sql.NullString
+		anonymizedAt sql.NullTime
+	)
+	err := row.Scan(
+		&customer.ID,
+		&firstName,
+		&lastName,
+		&address,
+		&email,
+		&phoneNumber,
+		&anonymizedAt,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan customer %w", err)
+	}
+	customer.FirstName = utils.NullStringToString(firstName)
+	customer.LastName = utils.NullStringToString(lastName)
+	customer.Address = utils.NullStringToString(address)
+	customer.Email = utils.NullStringToString(email)
+	customer.PhoneNumber = utils.NullStringToString(phoneNumber)
+	if anonymizedAt.Valid {
+		customer.AnonymizedAt = &anonymizedAt.Time
+	}
+	return &customer, nil
+}
+
+// likeContains escapes the LIKE wildcards of term and wraps it in %
+func likeContains(term string) string {
+	return "%" + likeEscaper.Replace(term) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func onlyDigits(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the MySQL error number of unique key violations
+const mysqlDuplicateEntry = 1062
+
+// isDuplicateEntry reports whether err is a unique key violation, usecases check uniqueness
+// beforehand but concurrent requests can still race to it
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/pkg/pagination"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// loyaltySettingsID is the id of the single loyalty settings row
+const loyaltySettingsID = 1
+
+type LoyaltyRepository struct {
+	db *database.DB
+}
+
+func NewLoyaltyRepository(db *database.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{
+		db: db,
+	}
+}
+
+var loyaltyEntryColumns = []interface{}{
+	"id", "customer_id", "order_id", "type", "points", "remaining_points", "expires_at", "description", "created_at",
+}
+
+func (r *LoyaltyRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.SQL.BeginTx(ctx, nil)
+}
+
+func (r *LoyaltyRepository) GetSettings(ctx context.Context) (*model.LoyaltyConfig, error) {
+	query, args, err := r.db.Dialect.
+		Select("config").
+		From("loyalty_settings").
+		Where(goqu.Ex{"id": loyaltySettingsID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var raw string
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("loyalty settings not found")
+		}
+		return nil, fmt.Errorf("failed to get loyalty settings: %w", err)
+	}
+
+	var config model.LoyaltyConfig
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, fmt.Errorf("invalid loyalty settings: %w", err)
+	}
+	return &config, nil
+}
+
+func (r *LoyaltyRepository) UpdateSettings(ctx context.Context, config *model.LoyaltyConfig) error {
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode loyalty settings: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Update("loyalty_settings").
+		Set(goqu.Record{"config": string(encoded)}).
+		Where(goqu.Ex{"id": loyaltySettingsID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update loyalty settings: %w", err)
+	}
+	return nil
+}
+
+func (r *LoyaltyRepository) CreateEntry(ctx context.Context, tx *sql.Tx, entry *model.LoyaltyEntry) error {
+	query, args, err := r.db.Dialect.
+		Insert("loyalty_ledger").Rows(
+		goqu.Record{
+			"customer_id":      entry.CustomerID,
+			"order_id":         entry.OrderID,
+			"type":             entry.Type,
+			"points":           entry.Points,
+			"remaining_points": entry.RemainingPoints,
+			"expires_at":       entry.ExpiresAt,
+			"description":      entry.Description,
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create loyalty entry: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	entry.ID = id
+	return nil
+}
+
+func (r *LoyaltyRepository) SetRemaining(ctx context.Context, tx *sql.Tx, id int64, remaining int64) error {
+	query, args, err := r.db.Dialect.
+		Update("loyalty_ledger").
+		Set(goqu.Record{"remaining_points": remaining}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update loyalty entry: %w", err)
+	}
+	return nil
+}
+
+// LockCredits locks the unexpired credits of the customer with points left, expiring first
+func (r *LoyaltyRepository) LockCredits(ctx context.Context, tx *sql.Tx, customerID int64, now time.Time) ([]*model.LoyaltyEntry, error) {
+	query, args, err := r.db.Dialect.
+		Select(loyaltyEntryColumns...).
+		From("loyalty_ledger").
+		Where(
+			goqu.Ex{"customer_id": customerID},
+			goqu.I("remaining_points").Gt(0),
+			unexpired(now),
+		).
+		Order(goqu.L("expires_at IS NULL").Asc(), goqu.I("expires_at").Asc(), goqu.I("id").Asc()).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	return r.queryEntries(ctx, tx, query, args)
+}
+
+// LockExpiredCredits locks the expired credits with points left, of one customer or of all when customerID is 0
+func (r *LoyaltyRepository) LockExpiredCredits(ctx context.Context, tx *sql.Tx, customerID int64, now time.Time) ([]*model.LoyaltyEntry, error) {
+	where := []goqu.Expression{
+		goqu.I("remaining_points").Gt(0),
+		goqu.I("expires_at").Lte(now),
+	}
+	if customerID > 0 {
+		where = append(where, goqu.Ex{"customer_id": customerID})
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(loyaltyEntryColumns...).
+		From("loyalty_ledger").
+		Where(where...).
+		Order(goqu.I("id").Asc()).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	return r.queryEntries(ctx, tx, query, args)
+}
+
+// LockOrderEntries locks the ledger entries of an order
+func (r *LoyaltyRepository) LockOrderEntries(ctx context.Context, tx *sql.Tx, orderID int64) ([]*model.LoyaltyEntry, error) {
+	query, args, err := r.db.Dialect.
+		Select(loyaltyEntryColumns...).
+		From("loyalty_ledger").
+		Where(goqu.Ex{"order_id": orderID}).
+		Order(goqu.I("id").Asc()).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	return r.queryEntries(ctx, tx, query, args)
+}
+
+// GetBalance sums the points left on the unexpired credits of the customer,
+// with the earliest expiry date of those credits and the points expiring then
+func (r *LoyaltyRepository) GetBalance(ctx context.Context, customerID int64, now time.Time) (*model.CustomerLoyalty, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.L("COALESCE(SUM(remaining_points), 0)"),
+			goqu.MIN("expires_at"),
+		).
+		From("loyalty_ledger").
+		Where(
+			goqu.Ex{"customer_id": customerID},
+			goqu.I("remaining_points").Gt(0),
+			unexpired(now),
+		).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var (
+		balance    model.CustomerLoyalty
+		nextExpiry sql.NullTime
+	)
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&balance.Points, &nextExpiry); err != nil {
+		return nil, fmt.Errorf("failed to get loyalty balance: %w", err)
+	}
+	if !nextExpiry.Valid {
+		return &balance, nil
+	}
+	balance.NextExpiry = &nextExpiry.Time
+
+	query, args, err = r.db.Dialect.
+		Select(goqu.L("COALESCE(SUM(remaining_points), 0)")).
+		From("loyalty_ledger").
+		Where(
+			goqu.Ex{"customer_id": customerID, "expires_at": nextExpiry.Time},
+			goqu.I("remaining_points").Gt(0),
+		).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&balance.ExpiringPoints); err != nil {
+		return nil, fmt.Errorf("failed to get expiring points: %w", err)
+	}
+	return &balance, nil
+}
+
+// GetEntriesPaginated lists the ledger entries of a customer
+func (r *LoyaltyRepository) GetEntriesPaginated(
+	ctx context.Context,
+	customerID int64,
+	cursor string,
+	limit int,
+	order string,
+	sortBy string,
+) ([]*model.LoyaltyEntry, error) {
+	query := r.db.Dialect.
+		Select(loyaltyEntryColumns...).
+		From("loyalty_ledger").
+		Where(goqu.Ex{"customer_id": customerID})
+
+	queryBuilder := pagination.NewQueryBuilder()
+	query, err := queryBuilder.ApplyCursorPagination(query, cursor, limit, order, sortBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply cursor pagination: %w", err)
+	}
+
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty entries: %w", err)
+	}
+	defer rows.Close()
+	return scanLoyaltyEntries(rows)
+}
+
+// GetEntries lists every ledger entry of a customer, oldest first
+func (r *LoyaltyRepository) GetEntries(ctx context.Context, customerID int64) ([]*model.LoyaltyEntry, error) {
+	query, args, err := r.db.Dialect.
+		Select(loyaltyEntryColumns...).
+		From("loyalty_ledger").
+		Where(goqu.Ex{"customer_id": customerID}).
+		Order(goqu.I("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty entries: %w", err)
+	}
+	defer rows.Close()
+	return scanLoyaltyEntries(rows)
+}
+
+// ReassignCustomer moves the ledger of the merged customers to toID
+func (r *LoyaltyRepository) ReassignCustomer(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) error {
+	query, args, err := r.db.Dialect.
+		Update("loyalty_ledger").
+		Set(goqu.Record{"customer_id": toID}).
+		Where(goqu.Ex{"customer_id": fromIDs}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to move loyalty entries: %w", err)
+	}
+	return nil
+}
+
+func (r *LoyaltyRepository) queryEntries(ctx context.Context, tx *sql.Tx, query string, args []interface{}) ([]*model.LoyaltyEntry, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty entries: %w", err)
+	}
+	defer rows.Close()
+	return scanLoyaltyEntries(rows)
+}
+
+func unexpired(now time.Time) goqu.Expression {
+	return goqu.Or(goqu.I("expires_at").IsNull(), goqu.I("expires_at").Gt(now))
+}
+
+func scanLoyaltyEntries(rows *sql.Rows) ([]*model.LoyaltyEntry, error) {
+	entries := []*model.LoyaltyEntry{}
+	for rows.Next() {
+		var (
+			entry     model.LoyaltyEntry
+			orderID   sql.NullInt64
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.CustomerID,
+			&orderID,
+			&entry.Type,
+			&entry.Points,
+			&entry.RemainingPoints,
+			&expiresAt,
+			&entry.Description,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan loyalty entry: %w", err)
+		}
+		if orderID.Valid {
+			entry.OrderID = &orderID.Int64
+		}
+		if expiresAt.Valid {
+			entry.ExpiresAt = &expiresAt.Time
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return entries, nil
+}
@@ -3,9 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"simple-template/pkg/pagination"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 )
@@ -21,14 +26,31 @@ func NewOrdersRepository(db *database.DB) *OrdersRepository {
 }
 
 func (r *OrdersRepository) Create(ctx context.Context, tx *sql.Tx, orders *model.Orders) (*model.Orders, error) {
+	var shippingAddress interface{}
+	if orders.ShippingAddress != nil {
+		snapshot, err := json.Marshal(orders.ShippingAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode shipping address: %w", err)
+		}
+		shippingAddress = string(snapshot)
+	}
+
 	query, args, err := r.db.Dialect.
 		Insert("orders").Rows(
 		goqu.Record{
-			"payment_status":   orders.PaymentStatus,
-			"customer_id":      orders.CustomerID,
-			"platform_id":      orders.PlatformID,
-			"payment_id":       orders.PaymentID,
-			"retail_stores_id": orders.RetailStoreID,
+			"payment_status":      orders.PaymentStatus,
+			"customer_id":         utils.NullIfZero(orders.CustomerID),
+			"shipping_address_id": orders.ShippingAddressID,
+			"shipping_address":    shippingAddress,
+			"platform_id":         orders.PlatformID,
+			"payment_id":          orders.PaymentID,
+			"retail_stores_id":    orders.RetailStoreID,
+			"subtotal_amount":     orders.SubtotalAmount,
+			"discount_amount":     orders.DiscountAmount,
+			"total_amount":        orders.TotalAmount,
+			"commission_amount":   orders.CommissionAmount,
+			"channel_fee_amount":  orders.ChannelFeeAmount,
+			"cogs_amount":         orders.CogsAmount,
 		}).ToSQL()
 
 	if err != nil {
@@ -83,6 +105,42 @@ func (r *OrdersRepository) CreateItems(
 	return orderItems, nil
 }
 
+func (r *OrdersRepository) CreateFees(ctx context.Context, tx *sql.Tx, fees []*model.OrderFee) error {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	feeRecords := make([]interface{}, 0, len(fees))
+	for _, fee := range fees {
+		feeRecords = append(feeRecords, goqu.Record{
+			"order_id":      fee.OrderID,
+			"name":          fee.Name,
+			"type":          fee.Type,
+			"rate":          fee.Rate,
+			"amount":        fee.Amount,
+			"is_commission": fee.IsCommission,
+		})
+	}
+
+	query, args, err := r.db.Dialect.
+		Insert("order_fees").Rows(feeRecords...).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build order fees query: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to insert order fees: %w", err)
+	}
+	firstFeeID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last order fee: %w", err)
+	}
+	for i := range fees {
+		fees[i].ID = firstFeeID + int64(i)
+	}
+	return nil
+}
+
 func (r *OrdersRepository) CreateOrderStatus(
 	ctx context.Context,
 	tx *sql.Tx,
@@ -126,6 +184,8 @@ func (r *OrdersRepository) GetStocks(
 			goqu.I("product_variant_value.value").As("value"),
 			goqu.I("product_variant.name").As("name"),
 			goqu.I("price.status").As("status"),
+			goqu.I("price.price").As("price"),
+			goqu.L("COALESCE(price.cost_price, 0)").As("cost_price"),
 		).From("price").
 		LeftJoin(
 			goqu.T("product_variant"),
@@ -162,6 +222,8 @@ func (r *OrdersRepository) GetStocks(
 			&OP.Value,
 			&OP.Name,
 			&OP.Status,
+			&OP.Price,
+			&OP.CostPrice,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan: %w", err)
@@ -200,20 +262,63 @@ func (r *OrdersRepository) ReduceStocksBatch(
 		}
 
 		rowAffected, err := result.RowsAffected()
-		if err != nil || rowAffected == 0 {
-			return fmt.Errorf("failed to update")
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowAffected == 0 {
+			return apperror.NotFound("variant value %d not found", variantValueID)
 		}
 	}
 
 	return nil
 }
 
+// ReassignCustomer moves the orders of the merged customers to toID
+func (r *OrdersRepository) ReassignCustomer(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) (int64, error) {
+	query, args, err := r.db.Dialect.
+		Update("orders").
+		Set(goqu.Record{"customer_id": toID}).
+		Where(goqu.Ex{"customer_id": fromIDs}).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move orders: %w", err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return moved, nil
+}
+
+// SetPaymentStatus records whether the order is paid
+func (r *OrdersRepository) SetPaymentStatus(ctx context.Context, tx *sql.Tx, orderID int64, paymentStatus int8) error {
+	query, args, err := r.db.Dialect.
+		Update("orders").
+		Set(goqu.Record{"payment_status": paymentStatus}).
+		Where(goqu.Ex{"id": orderID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update payment status: %w", err)
+	}
+	return nil
+}
+
 // BeginTx starts a new transaction
 func (r *OrdersRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
 	return r.db.SQL.BeginTx(ctx, nil)
 }
 
-func (r *OrdersRepository) GetOrdersPage(ctx context.Context) ([]*model.OrdersPage, error) {
+// GetOrdersPage lists the orders, storeIDs restricts them to the orders of these stores, nil lists all of them
+func (r *OrdersRepository) GetOrdersPage(ctx context.Context, storeIDs []int64) ([]*model.OrdersPage, error) {
 	// Subquery: get latest order status
 	latestStatusSubquery := r.db.Dialect.
 		Select(
@@ -236,13 +341,13 @@ func (r *OrdersRepository) GetOrdersPage(ctx context.Context) ([]*model.OrdersPa
 		).
 		GroupBy("oi.order_id")
 
-	query, args, err := r.db.Dialect.
+	ds := r.db.Dialect.
 		Select(
 			goqu.I("orders.id"),
 			goqu.I("orders.payment_status"),
 			goqu.I("orders.created_at"),
-			goqu.I("customer.first_name"),
-			goqu.I("customer.last_name"),
+			goqu.L("COALESCE(customer.first_name, '')").As("first_name"),
+			goqu.L("COALESCE(customer.last_name, '')").As("last_name"),
 			goqu.I("platform.name").As("platform"),
 			goqu.I("payment_methods.name").As("payment_method"),
 			goqu.I("ls.status").As("order_status"),
@@ -270,8 +375,11 @@ func (r *OrdersRepository) GetOrdersPage(ctx context.Context) ([]*model.OrdersPa
 		).
 		LeftJoin(
 			orderTotalsSubquery.As("ot"),
-			goqu.On(goqu.Ex{"ot.order_id": goqu.I("orders.id")})).
-		ToSQL()
+			goqu.On(goqu.Ex{"ot.order_id": goqu.I("orders.id")}))
+	if storeIDs != nil {
+		ds = ds.Where(goqu.Ex{"orders.retail_stores_id": storeIDs})
+	}
+	query, args, err := ds.ToSQL()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders: %w", err)
 	}
@@ -329,7 +437,7 @@ func (r *OrdersRepository) UpdateOrderStatus(ctx context.Context, status int8, s
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("order status not found")
+		return apperror.NotFound("order status not found")
 	}
 
 	return nil
@@ -362,3 +470,542 @@ func (r *OrdersRepository) GetLatestStatus(ctx context.Context, orderStatusID in
 	}
 	return orderStatus, nil
 }
+
+// GetOrderRevenue returns the amounts and fees of one order
+func (r *OrdersRepository) GetOrderRevenue(ctx context.Context, orderID int64) (*model.OrderRevenue, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("orders.id"),
+			goqu.L("COALESCE(orders.platform_id, 0)"),
+			goqu.L("COALESCE(platform.name, '')"),
+			goqu.I("orders.subtotal_amount"),
+			goqu.I("orders.discount_amount"),
+			goqu.I("orders.total_amount"),
+			goqu.I("orders.commission_amount"),
+			goqu.I("orders.channel_fee_amount"),
+			goqu.I("orders.cogs_amount"),
+		).
+		From("orders").
+		LeftJoin(
+			goqu.T("platform"),
+			goqu.On(goqu.Ex{"orders.platform_id": goqu.I("platform.id")}),
+		).
+		Where(goqu.Ex{"orders.id": orderID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	revenue := &model.OrderRevenue{Fees: []*model.OrderFee{}}
+	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&revenue.OrderID,
+		&revenue.PlatformID,
+		&revenue.Platform,
+		&revenue.SubtotalAmount,
+		&revenue.DiscountAmount,
+		&revenue.TotalAmount,
+		&revenue.CommissionAmount,
+		&revenue.ChannelFeeAmount,
+		&revenue.CogsAmount,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order revenue: %w", err)
+	}
+	revenue.NetRevenue = revenue.OrderAmounts.NetRevenue()
+
+	fees, err := r.GetFees(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if len(fees) > 0 {
+		revenue.Fees = fees
+	}
+	return revenue, nil
+}
+
+func (r *OrdersRepository) GetFees(ctx context.Context, orderID int64) ([]*model.OrderFee, error) {
+	query, args, err := r.db.Dialect.
+		Select("id", "order_id", "name", "type", "rate", "amount", "is_commission").
+		From("order_fees").
+		Where(goqu.Ex{"order_id": orderID}).
+		Order(goqu.I("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order fees: %w", err)
+	}
+	defer rows.Close()
+
+	var fees []*model.OrderFee
+	for rows.Next() {
+		fee := &model.OrderFee{}
+		if err := rows.Scan(
+			&fee.ID,
+			&fee.OrderID,
+			&fee.Name,
+			&fee.Type,
+			&fee.Rate,
+			&fee.Amount,
+			&fee.IsCommission,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan order fee: %w", err)
+		}
+		fees = append(fees, fee)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return fees, nil
+}
+
+// GetRevenueByPlatform aggregates the amounts of the orders created in [from, to), canceled and returned orders excluded.
+// storeIDs restricts it to the orders of these stores, nil aggregates all of them
+func (r *OrdersRepository) GetRevenueByPlatform(ctx context.Context, from, to *time.Time, storeIDs []int64) ([]*model.PlatformRevenue, error) {
+	latestStatusSubquery := latestOrderStatusQuery(r.db)
+
+	where := []goqu.Expression{
+		goqu.Or(
+			goqu.I("ls.status").IsNull(),
+			goqu.I("ls.status").NotIn(int8(model.OrderStatusCanceled), int8(model.OrderStatusReturned)),
+		),
+	}
+	if from != nil {
+		where = append(where, goqu.I("orders.created_at").Gte(*from))
+	}
+	if to != nil {
+		where = append(where, goqu.I("orders.created_at").Lt(*to))
+	}
+	if storeIDs != nil {
+		where = append(where, goqu.Ex{"orders.retail_stores_id": storeIDs})
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.L("COALESCE(orders.platform_id, 0)").As("platform_id"),
+			goqu.L("COALESCE(MAX(platform.name), '')").As("platform"),
+			goqu.COUNT("orders.id").As("order_count"),
+			goqu.SUM("orders.subtotal_amount").As("subtotal_amount"),
+			goqu.SUM("orders.discount_amount").As("discount_amount"),
+			goqu.SUM("orders.total_amount").As("total_amount"),
+			goqu.SUM("orders.commission_amount").As("commission_amount"),
+			goqu.SUM("orders.channel_fee_amount").As("channel_fee_amount"),
+			goqu.SUM("orders.cogs_amount").As("cogs_amount"),
+		).
+		From("orders").
+		LeftJoin(
+			goqu.T("platform"),
+			goqu.On(goqu.Ex{"orders.platform_id": goqu.I("platform.id")}),
+		).
+		LeftJoin(
+			latestStatusSubquery.As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		Where(where...).
+		GroupBy(goqu.I("orders.platform_id")).
+		Order(goqu.I("platform_id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revenue: %w", err)
+	}
+	defer rows.Close()
+
+	revenues := []*model.PlatformRevenue{}
+	for rows.Next() {
+		revenue := &model.PlatformRevenue{}
+		if err := rows.Scan(
+			&revenue.PlatformID,
+			&revenue.Platform,
+			&revenue.OrderCount,
+			&revenue.SubtotalAmount,
+			&revenue.DiscountAmount,
+			&revenue.TotalAmount,
+			&revenue.CommissionAmount,
+			&revenue.ChannelFeeAmount,
+			&revenue.CogsAmount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan revenue: %w", err)
+		}
+		revenue.NetRevenue = revenue.OrderAmounts.NetRevenue()
+		revenues = append(revenues, revenue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return revenues, nil
+}
+
+// GetCustomerOrdersPaginated lists the orders of a customer with their latest status
+func (r *OrdersRepository) GetCustomerOrdersPaginated(
+	ctx context.Context,
+	customerID int64,
+	cursor string,
+	limit int,
+	order string,
+	sortBy string,
+) ([]*model.CustomerOrder, error) {
+	queryBuilder := pagination.NewQueryBuilder()
+	query, err := queryBuilder.ApplyCursorPaginationWithTablePrefix(r.customerOrdersQuery(customerID), cursor, limit, order, sortBy, "orders")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply cursor pagination: %w", err)
+	}
+	return r.queryCustomerOrders(ctx, query)
+}
+
+// GetAllCustomerOrders lists every order of a customer, oldest first
+func (r *OrdersRepository) GetAllCustomerOrders(ctx context.Context, customerID int64) ([]*model.CustomerOrder, error) {
+	return r.queryCustomerOrders(ctx, r.customerOrdersQuery(customerID).Order(goqu.I("orders.id").Asc()))
+}
+
+func (r *OrdersRepository) customerOrdersQuery(customerID int64) *goqu.SelectDataset {
+	itemCounts := r.db.Dialect.
+		Select(
+			goqu.I("order_id"),
+			goqu.SUM("quantity").As("item_count"),
+		).
+		From("order_items").
+		GroupBy("order_id")
+
+	return r.db.Dialect.
+		Select(
+			goqu.I("orders.id"),
+			goqu.L("COALESCE(orders.platform_id, 0)"),
+			goqu.L("COALESCE(platform.name, '')"),
+			goqu.L("COALESCE(orders.retail_stores_id, 0)"),
+			goqu.L("COALESCE(retail_stores.name, '')"),
+			goqu.L("COALESCE(payment_methods.name, '')"),
+			goqu.I("orders.payment_status"),
+			goqu.L("COALESCE(ls.status, 0)"),
+			goqu.L("COALESCE(ic.item_count, 0)"),
+			goqu.I("orders.subtotal_amount"),
+			goqu.I("orders.discount_amount"),
+			goqu.I("orders.total_amount"),
+			goqu.I("orders.commission_amount"),
+			goqu.I("orders.channel_fee_amount"),
+			goqu.I("orders.cogs_amount"),
+			goqu.I("orders.shipping_address"),
+			goqu.I("orders.created_at"),
+		).
+		From("orders").
+		LeftJoin(
+			goqu.T("platform"),
+			goqu.On(goqu.Ex{"orders.platform_id": goqu.I("platform.id")}),
+		).
+		LeftJoin(
+			goqu.T("retail_stores"),
+			goqu.On(goqu.Ex{"orders.retail_stores_id": goqu.I("retail_stores.id")}),
+		).
+		LeftJoin(
+			goqu.T("payment_methods"),
+			goqu.On(goqu.Ex{"orders.payment_id": goqu.I("payment_methods.id")}),
+		).
+		LeftJoin(
+			latestOrderStatusQuery(r.db).As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		LeftJoin(
+			itemCounts.As("ic"),
+			goqu.On(goqu.Ex{"ic.order_id": goqu.I("orders.id")}),
+		).
+		Where(goqu.Ex{"orders.customer_id": customerID})
+}
+
+func (r *OrdersRepository) queryCustomerOrders(ctx context.Context, query *goqu.SelectDataset) ([]*model.CustomerOrder, error) {
+	queryStr, args, err := query.ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, queryStr, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customer orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []*model.CustomerOrder
+	for rows.Next() {
+		var (
+			order           = &model.CustomerOrder{}
+			shippingAddress sql.NullString
+		)
+		if err := rows.Scan(
+			&order.ID,
+			&order.PlatformID,
+			&order.Platform,
+			&order.RetailStoreID,
+			&order.RetailStore,
+			&order.PaymentMethod,
+			&order.PaymentStatus,
+			&order.OrderStatus,
+			&order.ItemCount,
+			&order.SubtotalAmount,
+			&order.DiscountAmount,
+			&order.TotalAmount,
+			&order.CommissionAmount,
+			&order.ChannelFeeAmount,
+			&order.CogsAmount,
+			&shippingAddress,
+			&order.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan customer order: %w", err)
+		}
+		if shippingAddress.Valid {
+			if err := json.Unmarshal([]byte(shippingAddress.String), &order.ShippingAddress); err != nil {
+				return nil, fmt.Errorf("invalid shipping address of order %d: %w", order.ID, err)
+			}
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return orders, nil
+}
+
+// GetCustomerOrderLines returns the lines of every order of a customer by order id
+func (r *OrdersRepository) GetCustomerOrderLines(ctx context.Context, customerID int64) (map[int64][]*model.ReceiptLine, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("oi.order_id"),
+			goqu.I("product.name"),
+			goqu.I("pv.name"),
+			goqu.I("pvv.value"),
+			goqu.I("oi.quantity"),
+			goqu.I("p.price"),
+		).
+		From(goqu.T("order_items").As("oi")).
+		Join(
+			goqu.T("orders"),
+			goqu.On(goqu.Ex{"orders.id": goqu.I("oi.order_id")}),
+		).
+		Join(
+			goqu.T("price").As("p"),
+			goqu.On(goqu.Ex{"p.id": goqu.I("oi.price_id")}),
+		).
+		Join(
+			goqu.T("product_variant_value").As("pvv"),
+			goqu.On(goqu.Ex{"pvv.id": goqu.I("oi.variant_value_id")}),
+		).
+		Join(
+			goqu.T("product_variant").As("pv"),
+			goqu.On(goqu.Ex{"pv.id": goqu.I("pvv.attribute_id")}),
+		).
+		Join(
+			goqu.T("product"),
+			goqu.On(goqu.Ex{"product.id": goqu.I("pv.product_id")}),
+		).
+		Where(goqu.Ex{"orders.customer_id": customerID}).
+		Order(goqu.I("oi.order_id").Asc(), goqu.I("oi.id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := make(map[int64][]*model.ReceiptLine)
+	for rows.Next() {
+		var (
+			orderID int64
+			line    model.ReceiptLine
+		)
+		if err := rows.Scan(
+			&orderID,
+			&line.ProductName,
+			&line.VariantName,
+			&line.Value,
+			&line.Quantity,
+			&line.UnitPrice,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan order line: %w", err)
+		}
+		line.LineTotal = line.UnitPrice * float64(line.Quantity)
+		lines[orderID] = append(lines[orderID], &line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return lines, nil
+}
+
+// ScrubShippingAddresses removes the recipient, phone number and street from the shipping address
+// snapshots of the orders of a customer, the ward, district and province are kept
+func (r *OrdersRepository) ScrubShippingAddresses(ctx context.Context, tx *sql.Tx, customerID int64) error {
+	query, args, err := r.db.Dialect.
+		Update("orders").
+		Set(goqu.Record{
+			"shipping_address": goqu.L("JSON_REMOVE(shipping_address, '$.recipient_name', '$.phone_number', '$.street')"),
+		}).
+		Where(
+			goqu.Ex{"customer_id": customerID},
+			goqu.I("shipping_address").IsNotNull(),
+		).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to scrub shipping addresses: %w", err)
+	}
+	return nil
+}
+
+// GetCustomerStats computes the lifetime stats of a customer, canceled and returned orders are only counted
+func (r *OrdersRepository) GetCustomerStats(ctx context.Context, customerID int64) (*model.CustomerStats, error) {
+	canceled := goqu.L("COALESCE(ls.status, 0) IN ?", []int8{int8(model.OrderStatusCanceled), int8(model.OrderStatusReturned)})
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.L("COALESCE(SUM(CASE WHEN ? THEN 0 ELSE 1 END), 0)", canceled),
+			goqu.L("COALESCE(SUM(CASE WHEN ? THEN 1 ELSE 0 END), 0)", canceled),
+			goqu.L("COALESCE(SUM(CASE WHEN ? THEN 0 ELSE orders.total_amount END), 0)", canceled),
+			goqu.L("MIN(CASE WHEN ? THEN NULL ELSE orders.created_at END)", canceled),
+			goqu.L("MAX(CASE WHEN ? THEN NULL ELSE orders.created_at END)", canceled),
+		).
+		From("orders").
+		LeftJoin(
+			latestOrderStatusQuery(r.db).As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		Where(goqu.Ex{"orders.customer_id": customerID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var (
+		stats        model.CustomerStats
+		firstOrderAt sql.NullTime
+		lastOrderAt  sql.NullTime
+	)
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&stats.OrderCount,
+		&stats.CanceledOrderCount,
+		&stats.TotalSpent,
+		&firstOrderAt,
+		&lastOrderAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to get customer stats: %w", err)
+	}
+	if firstOrderAt.Valid {
+		stats.FirstOrderAt = &firstOrderAt.Time
+	}
+	if lastOrderAt.Valid {
+		stats.LastOrderAt = &lastOrderAt.Time
+	}
+	if stats.OrderCount > 0 {
+		stats.AverageOrderValue = utils.RoundMoney(stats.TotalSpent / float64(stats.OrderCount))
+	}
+	return &stats, nil
+}
+
+// GetByID returns the order without its items and fees
+func (r *OrdersRepository) GetByID(ctx context.Context, id int64) (*model.Orders, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			"id", "payment_status",
+			goqu.L("COALESCE(customer_id, 0)"),
+			goqu.L("COALESCE(platform_id, 0)"),
+			goqu.L("COALESCE(retail_stores_id, 0)"),
+			goqu.L("COALESCE(payment_id, 0)"),
+			"subtotal_amount", "discount_amount", "total_amount",
+			"commission_amount", "channel_fee_amount", "cogs_amount",
+			"created_at", "updated_at",
+		).
+		From("orders").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var order model.Orders
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&order.ID,
+		&order.PaymentStatus,
+		&order.CustomerID,
+		&order.PlatformID,
+		&order.RetailStoreID,
+		&order.PaymentID,
+		&order.SubtotalAmount,
+		&order.DiscountAmount,
+		&order.TotalAmount,
+		&order.CommissionAmount,
+		&order.ChannelFeeAmount,
+		&order.CogsAmount,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("order not found")
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return &order, nil
+}
+
+// GetCompletedSpend sums the total of the completed orders of a customer created since the given time
+func (r *OrdersRepository) GetCompletedSpend(ctx context.Context, customerID int64, since time.Time) (float64, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.L("COALESCE(SUM(orders.total_amount), 0)")).
+		From("orders").
+		Join(
+			latestOrderStatusQuery(r.db).As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		Where(
+			goqu.Ex{
+				"orders.customer_id": customerID,
+				"ls.status":          int8(model.OrderStatusCompleted),
+			},
+			goqu.I("orders.created_at").Gte(since),
+		).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var spend float64
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&spend); err != nil {
+		return 0, fmt.Errorf("failed to get customer spend: %w", err)
+	}
+	return spend, nil
+}
+
+// latestOrderStatusQuery ranks the status history of every order, rn = 1 is the current status
+func latestOrderStatusQuery(db *database.DB) *goqu.SelectDataset {
+	return db.Dialect.
+		Select(
+			goqu.I("order_id"),
+			goqu.I("status"),
+			goqu.L("ROW_NUMBER() OVER (PARTITION BY order_id ORDER BY created_at DESC, id DESC)").As("rn"),
+		).
+		From("order_status")
+}
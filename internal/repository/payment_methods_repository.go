@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
 	"simple-template/internal/utils"
+
+	"github.com/doug-martin/goqu/v9"
 )
 
 type PaymentMethodsRepository struct {
@@ -19,9 +22,42 @@ func NewPaymentMethodsRepository(db *database.DB) *PaymentMethodsRepository {
 	}
 }
 
+var paymentMethodColumns = []interface{}{"id", "name", "code", "description", "is_active", "created_at", "updated_at"}
+
+// BeginTx starts a new transaction
+func (r *PaymentMethodsRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.SQL.BeginTx(ctx, nil)
+}
+
+func (r *PaymentMethodsRepository) Create(ctx context.Context, tx *sql.Tx, paymentMethod *model.PaymentMethods) error {
+	query, args, err := r.db.Dialect.
+		Insert("payment_methods").Rows(
+		goqu.Record{
+			"name":        paymentMethod.Name,
+			"code":        paymentMethod.Code,
+			"description": utils.NullIfEmpty(paymentMethod.Description),
+			"is_active":   paymentMethod.IsActive,
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("fail to build insert query to create payment method %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create payment method: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert: %w", err)
+	}
+	paymentMethod.ID = id
+	return nil
+}
+
 func (r *PaymentMethodsRepository) GetAll(ctx context.Context) ([]*model.PaymentMethods, error) {
 	query, args, err := r.db.Dialect.
-		Select("id", "name", "code", "description", "is_active", "created_at", "updated_at").From("payment_methods").ToSQL()
+		Select(paymentMethodColumns...).From("payment_methods").Order(goqu.I("id").Asc()).ToSQL()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query get payment methods: %w", err)
@@ -35,28 +71,283 @@ func (r *PaymentMethodsRepository) GetAll(ctx context.Context) ([]*model.Payment
 
 	var PaymentMethods []*model.PaymentMethods
 	for rows.Next() {
-		var PaymentMethod model.PaymentMethods
-		var Description sql.NullString
-
-		err := rows.Scan(
-			&PaymentMethod.ID,
-			&PaymentMethod.Name,
-			&PaymentMethod.Code,
-			&Description,
-			&PaymentMethod.IsActive,
-			&PaymentMethod.CreatedAt,
-			&PaymentMethod.UpdatedAt,
-		)
+		PaymentMethod, err := scanPaymentMethod(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan retail store: %w", err)
+			return nil, err
 		}
-		PaymentMethod.Description = utils.NullStringToString(Description)
-
-		PaymentMethods = append(PaymentMethods, &PaymentMethod)
+		PaymentMethods = append(PaymentMethods, PaymentMethod)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
+	if err := r.loadRestrictions(ctx, PaymentMethods); err != nil {
+		return nil, err
+	}
 	return PaymentMethods, nil
 }
+
+func (r *PaymentMethodsRepository) GetByID(ctx context.Context, id int64) (*model.PaymentMethods, error) {
+	query, args, err := r.db.Dialect.
+		Select(paymentMethodColumns...).
+		From("payment_methods").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query get payment method: %w", err)
+	}
+
+	paymentMethod, err := scanPaymentMethod(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("payment method not found")
+		}
+		return nil, err
+	}
+
+	if err := r.loadRestrictions(ctx, []*model.PaymentMethods{paymentMethod}); err != nil {
+		return nil, err
+	}
+	return paymentMethod, nil
+}
+
+func (r *PaymentMethodsRepository) GetByCode(ctx context.Context, code string) (*model.PaymentMethods, error) {
+	query, args, err := r.db.Dialect.
+		Select(paymentMethodColumns...).
+		From("payment_methods").
+		Where(goqu.Ex{"code": code}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query get payment method: %w", err)
+	}
+
+	paymentMethod, err := scanPaymentMethod(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("payment method %s not found", code)
+		}
+		return nil, err
+	}
+
+	if err := r.loadRestrictions(ctx, []*model.PaymentMethods{paymentMethod}); err != nil {
+		return nil, err
+	}
+	return paymentMethod, nil
+}
+
+// ExistsByCode reports whether another payment method (excluding excludeID) already uses the code
+func (r *PaymentMethodsRepository) ExistsByCode(ctx context.Context, code string, excludeID int64) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("payment_methods").
+		Where(goqu.Ex{"code": code, "id": goqu.Op{"neq": excludeID}}).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check payment method code: %w", err)
+	}
+	return count > 0, nil
+}
+
+// CountOrders returns the number of orders paid with the method
+func (r *PaymentMethodsRepository) CountOrders(ctx context.Context, id int64) (int64, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("orders").
+		Where(goqu.Ex{"payment_id": id}).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int64
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PaymentMethodsRepository) Update(ctx context.Context, tx *sql.Tx, paymentMethod *model.PaymentMethods) error {
+	query, args, err := r.db.Dialect.
+		Update("payment_methods").
+		Set(goqu.Record{
+			"name":        paymentMethod.Name,
+			"code":        paymentMethod.Code,
+			"description": utils.NullIfEmpty(paymentMethod.Description),
+			"is_active":   paymentMethod.IsActive,
+		}).
+		Where(goqu.Ex{"id": paymentMethod.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update payment method: %w", err)
+	}
+	return nil
+}
+
+func (r *PaymentMethodsRepository) Delete(ctx context.Context, id int64) error {
+	query, args, err := r.db.Dialect.Delete("payment_methods").
+		Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete payment method: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows effected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("payment method not found")
+	}
+	return nil
+}
+
+// ReplacePlatforms replaces the platforms the method is restricted to
+func (r *PaymentMethodsRepository) ReplacePlatforms(ctx context.Context, tx *sql.Tx, paymentMethodID int64, platformIDs []int64) error {
+	return r.replaceRestrictions(ctx, tx, "payment_method_platforms", "platform_id", paymentMethodID, platformIDs)
+}
+
+// ReplaceRetailStores replaces the retail stores the method is restricted to
+func (r *PaymentMethodsRepository) ReplaceRetailStores(ctx context.Context, tx *sql.Tx, paymentMethodID int64, retailStoreIDs []int64) error {
+	return r.replaceRestrictions(ctx, tx, "payment_method_stores", "retail_store_id", paymentMethodID, retailStoreIDs)
+}
+
+func (r *PaymentMethodsRepository) replaceRestrictions(
+	ctx context.Context,
+	tx *sql.Tx,
+	table string,
+	column string,
+	paymentMethodID int64,
+	ids []int64,
+) error {
+	query, args, err := r.db.Dialect.
+		Delete(table).
+		Where(goqu.Ex{"payment_method_id": paymentMethodID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", table, err)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+	records := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, goqu.Record{
+			"payment_method_id": paymentMethodID,
+			column:              id,
+		})
+	}
+	query, args, err = r.db.Dialect.
+		Insert(table).Rows(records...).OnConflict(goqu.DoNothing()).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to insert %s: %w", table, err)
+	}
+	return nil
+}
+
+// loadRestrictions fills PlatformIDs and RetailStoreIDs of the payment methods
+func (r *PaymentMethodsRepository) loadRestrictions(ctx context.Context, paymentMethods []*model.PaymentMethods) error {
+	if len(paymentMethods) == 0 {
+		return nil
+	}
+	byID := make(map[int64]*model.PaymentMethods, len(paymentMethods))
+	var ids []int64
+	for _, paymentMethod := range paymentMethods {
+		paymentMethod.PlatformIDs = []int64{}
+		paymentMethod.RetailStoreIDs = []int64{}
+		byID[paymentMethod.ID] = paymentMethod
+		ids = append(ids, paymentMethod.ID)
+	}
+
+	platforms, err := r.getRestrictions(ctx, "payment_method_platforms", "platform_id", ids)
+	if err != nil {
+		return err
+	}
+	for paymentMethodID, platformIDs := range platforms {
+		byID[paymentMethodID].PlatformIDs = platformIDs
+	}
+
+	stores, err := r.getRestrictions(ctx, "payment_method_stores", "retail_store_id", ids)
+	if err != nil {
+		return err
+	}
+	for paymentMethodID, retailStoreIDs := range stores {
+		byID[paymentMethodID].RetailStoreIDs = retailStoreIDs
+	}
+	return nil
+}
+
+func (r *PaymentMethodsRepository) getRestrictions(ctx context.Context, table, column string, paymentMethodIDs []int64) (map[int64][]int64, error) {
+	query, args, err := r.db.Dialect.
+		Select("payment_method_id", column).
+		From(table).
+		Where(goqu.Ex{"payment_method_id": paymentMethodIDs}).
+		Order(goqu.I(column).Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	restrictions := make(map[int64][]int64)
+	for rows.Next() {
+		var paymentMethodID, id int64
+		if err := rows.Scan(&paymentMethodID, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		restrictions[paymentMethodID] = append(restrictions[paymentMethodID], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return restrictions, nil
+}
+
+func scanPaymentMethod(row rowScanner) (*model.PaymentMethods, error) {
+	var (
+		PaymentMethod model.PaymentMethods
+		Description   sql.NullString
+		IsActive      sql.NullBool
+	)
+	err := row.Scan(
+		&PaymentMethod.ID,
+		&PaymentMethod.Name,
+		&PaymentMethod.Code,
+		&Description,
+		&IsActive,
+		&PaymentMethod.CreatedAt,
+		&PaymentMethod.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan payment method: %w", err)
+	}
+	PaymentMethod.Description = utils.NullStringToString(Description)
+	PaymentMethod.IsActive = IsActive.Valid && IsActive.Bool
+	return &PaymentMethod, nil
+}
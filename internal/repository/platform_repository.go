@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
 	"simple-template/internal/utils"
+
+	"github.com/doug-martin/goqu/v9"
 )
 
 type PlatformRepository struct {
@@ -19,9 +23,40 @@ func NewPlatformRepository(db *database.DB) *PlatformRepository {
 	}
 }
 
+var platformColumns = []interface{}{"id", "name", "api_endpoint", "feature_struct", "created_at", "updated_at"}
+
+func (r *PlatformRepository) Create(ctx context.Context, platform *model.Platform) (*model.Platform, error) {
+	config, err := json.Marshal(platform.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode platform config: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Insert("platform").Rows(
+		goqu.Record{
+			"name":           platform.Name,
+			"api_endpoint":   utils.NullIfEmpty(platform.ApiEndpoint),
+			"feature_struct": string(config),
+		}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("fail to build insert query to create platform %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create platform: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
 func (r *PlatformRepository) GetAll(ctx context.Context) ([]*model.Platform, error) {
 	query, args, err := r.db.Dialect.
-		Select("id", "name", "api_endpoint", "feature_struct", "created_at", "updated_at").From("platform").ToSQL()
+		Select(platformColumns...).From("platform").Order(goqu.I("id").Asc()).ToSQL()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query get platform: %w", err)
@@ -35,25 +70,11 @@ func (r *PlatformRepository) GetAll(ctx context.Context) ([]*model.Platform, err
 
 	var platforms []*model.Platform
 	for rows.Next() {
-		var platform model.Platform
-		var ApiEndpoint sql.NullString
-		var FeatureStruct sql.NullString
-
-		err := rows.Scan(
-			&platform.ID,
-			&platform.Name,
-			&ApiEndpoint,
-			&FeatureStruct,
-			&platform.CreatedAt,
-			&platform.UpdatedAt,
-		)
+		platform, err := scanPlatform(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan platform: %w", err)
+			return nil, err
 		}
-		platform.ApiEndpoint = utils.NullStringToString(ApiEndpoint)
-		platform.FeatureStruct = utils.NullStringToString(FeatureStruct)
-
-		platforms = append(platforms, &platform)
+		platforms = append(platforms, platform)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
@@ -61,3 +82,143 @@ func (r *PlatformRepository) GetAll(ctx context.Context) ([]*model.Platform, err
 
 	return platforms, nil
 }
+
+func (r *PlatformRepository) GetByID(ctx context.Context, id int64) (*model.Platform, error) {
+	query, args, err := r.db.Dialect.
+		Select(platformColumns...).
+		From("platform").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query get platform: %w", err)
+	}
+
+	platform, err := scanPlatform(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("platform not found")
+		}
+		return nil, err
+	}
+	return platform, nil
+}
+
+func (r *PlatformRepository) GetByName(ctx context.Context, name string) (*model.Platform, error) {
+	query, args, err := r.db.Dialect.
+		Select(platformColumns...).
+		From("platform").
+		Where(goqu.Ex{"name": name}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query get platform: %w", err)
+	}
+
+	platform, err := scanPlatform(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("platform %s not found", name)
+		}
+		return nil, err
+	}
+	return platform, nil
+}
+
+// ExistsByName reports whether another platform (excluding excludeID) already uses the name
+func (r *PlatformRepository) ExistsByName(ctx context.Context, name string, excludeID int64) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("platform").
+		Where(goqu.Ex{"name": name, "id": goqu.Op{"neq": excludeID}}).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check platform name: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *PlatformRepository) Update(ctx context.Context, platform *model.Platform) error {
+	config, err := json.Marshal(platform.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encode platform config: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Update("platform").
+		Set(goqu.Record{
+			"name":           platform.Name,
+			"api_endpoint":   utils.NullIfEmpty(platform.ApiEndpoint),
+			"feature_struct": string(config),
+		}).
+		Where(goqu.Ex{"id": platform.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update platform: %w", err)
+	}
+	return nil
+}
+
+func (r *PlatformRepository) Delete(ctx context.Context, id int64) error {
+	query, args, err := r.db.Dialect.Delete("platform").
+		Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete platform: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows effected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("platform not found")
+	}
+	return nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPlatform scans a platform row and decodes its JSON config
+func scanPlatform(row rowScanner) (*model.Platform, error) {
+	var (
+		platform      model.Platform
+		ApiEndpoint   sql.NullString
+		FeatureStruct sql.NullString
+	)
+	err := row.Scan(
+		&platform.ID,
+		&platform.Name,
+		&ApiEndpoint,
+		&FeatureStruct,
+		&platform.CreatedAt,
+		&platform.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan platform: %w", err)
+	}
+	platform.ApiEndpoint = utils.NullStringToString(ApiEndpoint)
+
+	if config := utils.NullStringToString(FeatureStruct); config != "" {
+		if err := json.Unmarshal([]byte(config), &platform.Config); err != nil {
+			return nil, fmt.Errorf("invalid config of platform %d: %w", platform.ID, err)
+		}
+	}
+	return &platform, nil
+}
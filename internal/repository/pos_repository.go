@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type PosRepository struct {
+	db *database.DB
+}
+
+func NewPosRepository(db *database.DB) *PosRepository {
+	return &PosRepository{
+		db: db,
+	}
+}
+
+// FindItemsByCode returns the variant values whose SKU or barcode is code
+// Falls back to the product SKU or barcode, which matches every value of the product
+func (r *PosRepository) FindItemsByCode(ctx context.Context, code string) ([]*model.PosItem, error) {
+	items, err := r.findItems(ctx, goqu.Or(
+		goqu.Ex{"pvv.sku": code},
+		goqu.Ex{"pvv.barcode": code},
+	))
+	if err != nil || len(items) > 0 {
+		return items, err
+	}
+	return r.findItems(ctx, goqu.Or(
+		goqu.Ex{"product.sku": code},
+		goqu.Ex{"product.barcode": code},
+	))
+}
+
+func (r *PosRepository) GetItemsByVariantValueIDs(ctx context.Context, variantValueIDs []int64) ([]*model.PosItem, error) {
+	if len(variantValueIDs) == 0 {
+		return nil, nil
+	}
+	return r.findItems(ctx, goqu.Ex{"pvv.id": variantValueIDs})
+}
+
+func (r *PosRepository) findItems(ctx context.Context, where exp.Expression) ([]*model.PosItem, error) {
+	// Latest active price of the variant the value belongs to
+	activePrice := r.db.Dialect.
+		Select("id").
+		From("price").
+		Where(goqu.Ex{
+			"price.variant_id": goqu.I("pvv.attribute_id"),
+			"price.status":     1,
+		}).
+		Order(goqu.I("price.effective_from").Desc(), goqu.I("price.id").Desc()).
+		Limit(1)
+
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("pvv.id"),
+			goqu.L("COALESCE(p.id, 0)").As("price_id"),
+			goqu.I("product.id").As("product_id"),
+			goqu.I("product.name").As("product_name"),
+			goqu.I("pv.name").As("variant_name"),
+			goqu.I("pvv.value"),
+			goqu.L("COALESCE(pvv.sku, product.sku)").As("sku"),
+			goqu.L("COALESCE(pvv.barcode, product.barcode, '')").As("barcode"),
+			goqu.L("COALESCE(p.price, 0)").As("price"),
+			goqu.I("pvv.stock_quantity"),
+		).
+		From(goqu.T("product_variant_value").As("pvv")).
+		Join(
+			goqu.T("product_variant").As("pv"),
+			goqu.On(goqu.Ex{"pv.id": goqu.I("pvv.attribute_id")}),
+		).
+		Join(
+			goqu.T("product"),
+			goqu.On(goqu.Ex{"product.id": goqu.I("pv.product_id")}),
+		).
+		LeftJoin(
+			goqu.T("price").As("p"),
+			goqu.On(goqu.L("? = ?", goqu.I("p.id"), activePrice)),
+		).
+		Where(where).
+		Order(goqu.I("pv.display_order").Asc(), goqu.I("pvv.display_order").Asc(), goqu.I("pvv.id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pos items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*model.PosItem
+	for rows.Next() {
+		var item model.PosItem
+		err := rows.Scan(
+			&item.VariantValueID,
+			&item.PriceID,
+			&item.ProductID,
+			&item.ProductName,
+			&item.VariantName,
+			&item.Value,
+			&item.SKU,
+			&item.Barcode,
+			&item.Price,
+			&item.StockQuantity,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pos item: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return items, nil
+}
+
+func (r *PosRepository) CreatePayment(ctx context.Context, tx *sql.Tx, payment *model.PosPayment) error {
+	query, args, err := r.db.Dialect.
+		Insert("pos_payments").Rows(
+		goqu.Record{
+			"order_id":        payment.OrderID,
+			"payment_id":      payment.PaymentID,
+			"amount_due":      payment.AmountDue,
+			"amount_tendered": payment.AmountTendered,
+			"change_amount":   payment.ChangeAmount,
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to create pos payment: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	payment.ID = id
+	return nil
+}
+
+// GetReceipt loads the receipt of a POS order, the store fields are left to the caller
+func (r *PosRepository) GetReceipt(ctx context.Context, orderID int64) (*model.Receipt, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("orders.id"),
+			goqu.I("orders.retail_stores_id"),
+			goqu.L("COALESCE(customer.first_name, '')"),
+			goqu.L("COALESCE(customer.last_name, '')"),
+			goqu.I("payment_methods.name"),
+			goqu.I("orders.subtotal_amount"),
+			goqu.I("orders.discount_amount"),
+			goqu.I("orders.total_amount"),
+			goqu.I("pos_payments.amount_tendered"),
+			goqu.I("pos_payments.change_amount"),
+			goqu.I("pos_payments.created_at"),
+		).
+		From("pos_payments").
+		Join(
+			goqu.T("orders"),
+			goqu.On(goqu.Ex{"orders.id": goqu.I("pos_payments.order_id")}),
+		).
+		Join(
+			goqu.T("payment_methods"),
+			goqu.On(goqu.Ex{"payment_methods.id": goqu.I("pos_payments.payment_id")}),
+		).
+		LeftJoin(
+			goqu.T("customer"),
+			goqu.On(goqu.Ex{"customer.id": goqu.I("orders.customer_id")}),
+		).
+		Where(goqu.Ex{"pos_payments.order_id": orderID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var (
+		receipt   model.Receipt
+		firstName string
+		lastName  string
+	)
+	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&receipt.OrderID,
+		&receipt.RetailStoreID,
+		&firstName,
+		&lastName,
+		&receipt.PaymentMethod,
+		&receipt.SubtotalAmount,
+		&receipt.DiscountAmount,
+		&receipt.TotalAmount,
+		&receipt.AmountTendered,
+		&receipt.ChangeAmount,
+		&receipt.IssuedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("receipt not found")
+		}
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+	receipt.CustomerName = strings.TrimSpace(firstName + " " + lastName)
+	if receipt.CustomerName == "" {
+		receipt.CustomerName = model.WalkInCustomerName
+	}
+
+	lines, err := r.getReceiptLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Lines = lines
+	return &receipt, nil
+}
+
+func (r *PosRepository) getReceiptLines(ctx context.Context, orderID int64) ([]*model.ReceiptLine, error) {
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("product.name"),
+			goqu.I("pv.name"),
+			goqu.I("pvv.value"),
+			goqu.I("oi.quantity"),
+			goqu.I("p.price"),
+		).
+		From(goqu.T("order_items").As("oi")).
+		Join(
+			goqu.T("price").As("p"),
+			goqu.On(goqu.Ex{"p.id": goqu.I("oi.price_id")}),
+		).
+		Join(
+			goqu.T("product_variant_value").As("pvv"),
+			goqu.On(goqu.Ex{"pvv.id": goqu.I("oi.variant_value_id")}),
+		).
+		Join(
+			goqu.T("product_variant").As("pv"),
+			goqu.On(goqu.Ex{"pv.id": goqu.I("pvv.attribute_id")}),
+		).
+		Join(
+			goqu.T("product"),
+			goqu.On(goqu.Ex{"product.id": goqu.I("pv.product_id")}),
+		).
+		Where(goqu.Ex{"oi.order_id": orderID}).
+		Order(goqu.I("oi.id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt lines: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []*model.ReceiptLine{}
+	for rows.Next() {
+		var line model.ReceiptLine
+		if err := rows.Scan(
+			&line.ProductName,
+			&line.VariantName,
+			&line.Value,
+			&line.Quantity,
+			&line.UnitPrice,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan receipt line: %w", err)
+		}
+		line.LineTotal = line.UnitPrice * float64(line.Quantity)
+		lines = append(lines, &line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return lines, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
+	"simple-template/internal/utils"
 	"simple-template/pkg/pagination"
 	"time"
 
@@ -90,10 +92,12 @@ func (r *ProductRepository) Create(ctx context.Context, product *model.Product)
 		}
 
 		// variant value
-		for _, value := range variant.Values {
+		for j, value := range variant.Values {
 			queryValue, arg, err := r.db.Dialect.Insert("product_variant_value").Rows(goqu.Record{
 				"attribute_id":   variantID,
 				"value":          value.Value,
+				"sku":            utils.NullIfEmpty(value.SKU),
+				"barcode":        utils.NullIfEmpty(value.Barcode),
 				"display_order":  value.DisplayOrder,
 				"stock_quantity": value.StockQuantity,
 			}).ToSQL()
@@ -102,13 +106,16 @@ func (r *ProductRepository) Create(ctx context.Context, product *model.Product)
 			}
 			result, err = r.db.SQL.ExecContext(ctx, queryValue, arg...)
 			if err != nil {
+				if isDuplicateEntry(err) {
+					return apperror.Conflict("sku or barcode of %s %s is already used", variant.Name, value.Value)
+				}
 				return fmt.Errorf("fail: %w", err)
 			}
 			valueID, err := result.LastInsertId()
 			if err != nil {
 				return fmt.Errorf("fail: %w", err)
 			}
-			product.Variant[i].Values[i].ID = valueID
+			product.Variant[i].Values[j].ID = valueID
 		}
 	}
 
@@ -143,6 +150,8 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 		goqu.I("product_variant_value.id").As("value_id"),
 		goqu.I("product_variant_value.attribute_id"),
 		goqu.I("product_variant_value.value"),
+		goqu.I("product_variant_value.sku").As("value_sku"),
+		goqu.I("product_variant_value.barcode").As("value_barcode"),
 		goqu.I("product_variant_value.display_order").As("value_display_order"),
 		goqu.I("product_variant_value.stock_quantity"),
 		goqu.I("product_variant_value.created_at").As("value_created_at"),
@@ -207,6 +216,8 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 			valueID        sql.NullInt64
 			attributeID    sql.NullInt64
 			value          sql.NullString
+			valueSKU       sql.NullString
+			valueBarcode   sql.NullString
 			valueDispOrder sql.NullInt32
 			stockQuantity  sql.NullInt32
 			valueCreatedAt sql.NullTime
@@ -223,7 +234,7 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 			&weight, &brand, &material, &origin, &imgUrl, &categoryID,
 			&productCreatedAt, &productUpdatedAt,
 			&variantID, &variantName, &displayName, &variantDispOrder, &isRequired, &variantProductID,
-			&valueID, &attributeID, &value, &valueDispOrder, &stockQuantity,
+			&valueID, &attributeID, &value, &valueSKU, &valueBarcode, &valueDispOrder, &stockQuantity,
 			&valueCreatedAt, &valueUpdatedAt, &priceID, &price, &priceStatus, &effectiveFrom,
 		)
 		if err != nil {
@@ -280,6 +291,8 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 					ID:            valueID.Int64,
 					AttributeID:   attributeID.Int64,
 					Value:         value.String,
+					SKU:           valueSKU.String,
+					Barcode:       valueBarcode.String,
 					DisplayOrder:  int(valueDispOrder.Int32),
 					StockQuantity: int(stockQuantity.Int32),
 					CreatedAt:     valueCreatedAt.Time,
@@ -303,7 +316,7 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 	}
 
 	if product == nil {
-		return nil, fmt.Errorf("product not found")
+		return nil, apperror.NotFound("product not found")
 	}
 
 	return product, nil
@@ -311,7 +324,7 @@ func (r *ProductRepository) GetByID(ctx context.Context, id int64) (*model.Produ
 
 func (r *ProductRepository) GetVariantValuesByAttributeID(ctx context.Context, attributeIDs []string) ([]*model.ProductVariantValue, error) {
 	query, args, err := r.db.Dialect.
-		Select("id", "attribute_id", "display_order", "stock_quantity", "value", "created_at", "updated_at").
+		Select("id", "attribute_id", "display_order", "stock_quantity", "value", "sku", "barcode", "created_at", "updated_at").
 		From("product_variant_value").
 		Where(goqu.Ex{"attribute_id": attributeIDs}).
 		Order(goqu.I("created_at").Desc()).
@@ -326,19 +339,27 @@ func (r *ProductRepository) GetVariantValuesByAttributeID(ctx context.Context, a
 	}
 	var values []*model.ProductVariantValue
 	for rows.Next() {
-		var value model.ProductVariantValue
+		var (
+			value   model.ProductVariantValue
+			sku     sql.NullString
+			barcode sql.NullString
+		)
 		err := rows.Scan(
 			&value.ID,
 			&value.AttributeID,
 			&value.DisplayOrder,
 			&value.StockQuantity,
 			&value.Value,
+			&sku,
+			&barcode,
 			&value.CreatedAt,
 			&value.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan fail variant value: %w", err)
 		}
+		value.SKU = utils.NullStringToString(sku)
+		value.Barcode = utils.NullStringToString(barcode)
 		values = append(values, &value)
 	}
 	if err = rows.Err(); err != nil {
@@ -504,7 +525,7 @@ func (r *ProductRepository) DeletePriceByProductID(ctx context.Context, id int64
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return apperror.NotFound("product not found")
 	}
 	return nil
 }
@@ -525,7 +546,7 @@ func (r *ProductRepository) DeleteVariantValueByProductID(ctx context.Context, i
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return apperror.NotFound("product not found")
 	}
 	return nil
 }
@@ -547,7 +568,7 @@ func (r *ProductRepository) DeleteVariantByProductID(ctx context.Context, id int
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return apperror.NotFound("product not found")
 	}
 	return nil
 }
@@ -568,7 +589,7 @@ func (r *ProductRepository) DeleteProductByID(ctx context.Context, id int64) err
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("product not found")
+		return apperror.NotFound("product not found")
 	}
 
 	return nil
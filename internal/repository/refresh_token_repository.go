@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+type RefreshTokenRepository struct {
+	db *database.DB
+}
+
+func NewRefreshTokenRepository(db *database.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{
+		db: db,
+	}
+}
+
+var refreshTokenColumns = []interface{}{
+	"id", "user_id", "family_id", "token_hash", "expires_at", "revoked_at", "replaced_by_id", "user_agent", "ip_address", "created_at",
+}
+
+func (r *RefreshTokenRepository) BeginTx(ctx context.Context) (*sql.Tx, error) {
+	return r.db.SQL.BeginTx(ctx, nil)
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, tx *sql.Tx, token *model.RefreshToken) (int64, error) {
+	query, args, err := r.db.Dialect.
+		Insert("refresh_tokens").Rows(
+		goqu.Record{
+			"user_id":    token.UserID,
+			"family_id":  token.FamilyID,
+			"token_hash": token.TokenHash,
+			"expires_at": token.ExpiresAt,
+			"user_agent": utils.NullIfEmpty(token.UserAgent),
+			"ip_address": utils.NullIfEmpty(token.IPAddress),
+		}).ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// LockByHash gets the token with the hash and locks it until the transaction ends, nil when unknown
+func (r *RefreshTokenRepository) LockByHash(ctx context.Context, tx *sql.Tx, tokenHash string) (*model.RefreshToken, error) {
+	query, args, err := r.db.Dialect.
+		Select(refreshTokenColumns...).
+		From("refresh_tokens").
+		Where(goqu.Ex{"token_hash": tokenHash}).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	token, err := scanRefreshToken(tx.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// Revoke revokes the token, replacedByID is set when it was rotated
+func (r *RefreshTokenRepository) Revoke(ctx context.Context, tx *sql.Tx, id int64, replacedByID *int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("refresh_tokens").
+		Set(goqu.Record{"revoked_at": at, "replaced_by_id": replacedByID}).
+		Where(goqu.Ex{"id": id, "revoked_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// RevokeFamily revokes every token rotated from the same login
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, tx *sql.Tx, familyID string, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("refresh_tokens").
+		Set(goqu.Record{"revoked_at": at}).
+		Where(goqu.Ex{"family_id": familyID, "revoked_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser signs the user out of every session
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("refresh_tokens").
+		Set(goqu.Record{"revoked_at": at}).
+		Where(goqu.Ex{"user_id": userID, "revoked_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func scanRefreshToken(row rowScanner) (*model.RefreshToken, error) {
+	var (
+		token        model.RefreshToken
+		revokedAt    sql.NullTime
+		replacedByID sql.NullInt64
+		userAgent    sql.NullString
+		ipAddress    sql.NullString
+	)
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&revokedAt,
+		&replacedByID,
+		&userAgent,
+		&ipAddress,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	if replacedByID.Valid {
+		token.ReplacedByID = &replacedByID.Int64
+	}
+	token.UserAgent = utils.NullStringToString(userAgent)
+	token.IPAddress = utils.NullStringToString(ipAddress)
+	return &token, nil
+}
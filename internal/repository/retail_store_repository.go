@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/database"
 	"simple-template/internal/model"
 	"simple-template/internal/utils"
+
+	"github.com/doug-martin/goqu/v9"
 )
 
 type RetailStoreRepository struct {
@@ -19,9 +23,57 @@ func NewRetailStoreRepository(db *database.DB) *RetailStoreRepository {
 	}
 }
 
-func (r *RetailStoreRepository) GetAll(ctx context.Context) ([]*model.RetailStore, error) {
+var retailStoreColumns = []interface{}{
+	"id",
+	"name",
+	"phone_number",
+	"address",
+	"latitude",
+	"longitude",
+	"timezone",
+	"opening_hours",
+	"is_active",
+	"created_at",
+	"updated_at",
+}
+
+func (r *RetailStoreRepository) Create(ctx context.Context, retailStore *model.RetailStore) (*model.RetailStore, error) {
+	record, err := retailStoreRecord(retailStore)
+	if err != nil {
+		return nil, err
+	}
+	record["is_active"] = true
+
 	query, args, err := r.db.Dialect.
-		Select("id", "name", "phone_number", "created_at", "updated_at").From("retail_stores").ToSQL()
+		Insert("retail_stores").Rows(record).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("fail to build insert query to create retail store %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retail store: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// GetAll returns the retail stores, the inactive ones only when includeInactive is set.
+// storeIDs restricts the stores returned, nil returns all of them
+func (r *RetailStoreRepository) GetAll(ctx context.Context, includeInactive bool, storeIDs []int64) ([]*model.RetailStore, error) {
+	ds := r.db.Dialect.
+		Select(retailStoreColumns...).From("retail_stores").Order(goqu.I("id").Asc())
+	if !includeInactive {
+		ds = ds.Where(goqu.Ex{"is_active": true})
+	}
+	if storeIDs != nil {
+		ds = ds.Where(goqu.Ex{"id": storeIDs})
+	}
+	query, args, err := ds.ToSQL()
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query get retail store: %w", err)
@@ -35,22 +87,11 @@ func (r *RetailStoreRepository) GetAll(ctx context.Context) ([]*model.RetailStor
 
 	var retailStores []*model.RetailStore
 	for rows.Next() {
-		var retailStore model.RetailStore
-		var PhoneNumber sql.NullString
-
-		err := rows.Scan(
-			&retailStore.ID,
-			&retailStore.Name,
-			&PhoneNumber,
-			&retailStore.CreatedAt,
-			&retailStore.UpdatedAt,
-		)
+		retailStore, err := scanRetailStore(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan retail store: %w", err)
+			return nil, err
 		}
-		retailStore.PhoneNumber = utils.NullStringToString(PhoneNumber)
-
-		retailStores = append(retailStores, &retailStore)
+		retailStores = append(retailStores, retailStore)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
@@ -58,3 +99,290 @@ func (r *RetailStoreRepository) GetAll(ctx context.Context) ([]*model.RetailStor
 
 	return retailStores, nil
 }
+
+func (r *RetailStoreRepository) GetByID(ctx context.Context, id int64) (*model.RetailStore, error) {
+	query, args, err := r.db.Dialect.
+		Select(retailStoreColumns...).
+		From("retail_stores").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query get retail store: %w", err)
+	}
+
+	retailStore, err := scanRetailStore(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("retail store not found")
+		}
+		return nil, err
+	}
+	return retailStore, nil
+}
+
+func (r *RetailStoreRepository) Update(ctx context.Context, retailStore *model.RetailStore) error {
+	record, err := retailStoreRecord(retailStore)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := r.db.Dialect.
+		Update("retail_stores").
+		Set(record).
+		Where(goqu.Ex{"id": retailStore.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update retail store: %w", err)
+	}
+	return nil
+}
+
+func (r *RetailStoreRepository) SetActive(ctx context.Context, id int64, isActive bool) error {
+	query, args, err := r.db.Dialect.
+		Update("retail_stores").
+		Set(goqu.Record{"is_active": isActive}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update retail store: %w", err)
+	}
+	return nil
+}
+
+// AssignUser adds the user to the store staff with the role, the role is replaced when already assigned
+func (r *RetailStoreRepository) AssignUser(ctx context.Context, storeID, userID int64, role string) error {
+	query, args, err := r.db.Dialect.
+		Update("store_users").
+		Set(goqu.Record{"role": role}).
+		Where(goqu.Ex{"store_id": storeID, "user_id": userID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update store role: %w", err)
+	}
+
+	// No-op when the user was already assigned
+	query, args, err = r.db.Dialect.
+		Insert("store_users").
+		Rows(goqu.Record{
+			"store_id": storeID,
+			"user_id":  userID,
+			"role":     role,
+		}).
+		OnConflict(goqu.DoNothing()).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to assign user to retail store: %w", err)
+	}
+	return nil
+}
+
+func (r *RetailStoreRepository) UnassignUser(ctx context.Context, storeID, userID int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("store_users").
+		Where(goqu.Ex{"store_id": storeID, "user_id": userID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to unassign user from retail store: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows effected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("user is not assigned to this retail store")
+	}
+	return nil
+}
+
+// GetUsers lists the staff of the store with their role in it
+func (r *RetailStoreRepository) GetUsers(ctx context.Context, storeID int64) ([]*model.StoreUser, error) {
+	columns := make([]interface{}, 0, len(userColumns)+1)
+	for _, column := range userColumns {
+		columns = append(columns, goqu.I("u."+column.(string)))
+	}
+	columns = append(columns, goqu.I("su.role"))
+	query, args, err := r.db.Dialect.
+		Select(columns...).
+		From(goqu.T("store_users").As("su")).
+		Join(goqu.T("users").As("u"), goqu.On(goqu.Ex{"u.id": goqu.I("su.user_id")})).
+		Where(goqu.Ex{"su.store_id": storeID}).
+		Order(goqu.I("u.name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*model.StoreUser{}
+	for rows.Next() {
+		var storeRole string
+		user, err := scanUser(rows, &storeRole)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &model.StoreUser{User: user, StoreRole: storeRole})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return users, nil
+}
+
+// GetStoreIDsByUser returns the stores the user is assigned to
+func (r *RetailStoreRepository) GetStoreIDsByUser(ctx context.Context, userID int64) ([]int64, error) {
+	query, args, err := r.db.Dialect.
+		Select("store_id").
+		From("store_users").
+		Where(goqu.Ex{"user_id": userID}).
+		Order(goqu.I("store_id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user stores: %w", err)
+	}
+	defer rows.Close()
+
+	var storeIDs []int64
+	for rows.Next() {
+		var storeID int64
+		if err := rows.Scan(&storeID); err != nil {
+			return nil, fmt.Errorf("failed to scan store id: %w", err)
+		}
+		storeIDs = append(storeIDs, storeID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return storeIDs, nil
+}
+
+// GetStoreRolesByUser returns the role of the user in each store they are assigned to
+func (r *RetailStoreRepository) GetStoreRolesByUser(ctx context.Context, userID int64) (map[int64]string, error) {
+	query, args, err := r.db.Dialect.
+		Select("store_id", "role").
+		From("store_users").
+		Where(goqu.Ex{"user_id": userID}).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := make(map[int64]string)
+	for rows.Next() {
+		var (
+			storeID int64
+			role    string
+		)
+		if err := rows.Scan(&storeID, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan store role: %w", err)
+		}
+		roles[storeID] = role
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return roles, nil
+}
+
+func retailStoreRecord(retailStore *model.RetailStore) (goqu.Record, error) {
+	var openingHours interface{}
+	if len(retailStore.OpeningHours) > 0 {
+		encoded, err := json.Marshal(retailStore.OpeningHours)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode opening hours: %w", err)
+		}
+		openingHours = string(encoded)
+	}
+
+	return goqu.Record{
+		"name":          retailStore.Name,
+		"phone_number":  utils.NullIfEmpty(retailStore.PhoneNumber),
+		"address":       utils.NullIfEmpty(retailStore.Address),
+		"latitude":      retailStore.Latitude,
+		"longitude":     retailStore.Longitude,
+		"timezone":      retailStore.Timezone,
+		"opening_hours": openingHours,
+	}, nil
+}
+
+func scanRetailStore(row rowScanner) (*model.RetailStore, error) {
+	var (
+		retailStore  model.RetailStore
+		PhoneNumber  sql.NullString
+		Address      sql.NullString
+		Latitude     sql.NullFloat64
+		Longitude    sql.NullFloat64
+		OpeningHours sql.NullString
+	)
+	err := row.Scan(
+		&retailStore.ID,
+		&retailStore.Name,
+		&PhoneNumber,
+		&Address,
+		&Latitude,
+		&Longitude,
+		&retailStore.Timezone,
+		&OpeningHours,
+		&retailStore.IsActive,
+		&retailStore.CreatedAt,
+		&retailStore.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan retail store: %w", err)
+	}
+	retailStore.PhoneNumber = utils.NullStringToString(PhoneNumber)
+	retailStore.Address = utils.NullStringToString(Address)
+	if Latitude.Valid {
+		retailStore.Latitude = &Latitude.Float64
+	}
+	if Longitude.Valid {
+		retailStore.Longitude = &Longitude.Float64
+	}
+
+	retailStore.OpeningHours = []model.OpeningHours{}
+	if openingHours := utils.NullStringToString(OpeningHours); openingHours != "" {
+		if err := json.Unmarshal([]byte(openingHours), &retailStore.OpeningHours); err != nil {
+			return nil, fmt.Errorf("invalid opening hours of retail store %d: %w", retailStore.ID, err)
+		}
+	}
+	return &retailStore, nil
+}
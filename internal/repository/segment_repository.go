@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type SegmentRepository struct {
+	db *database.DB
+}
+
+func NewSegmentRepository(db *database.DB) *SegmentRepository {
+	return &SegmentRepository{
+		db: db,
+	}
+}
+
+var segmentColumns = []interface{}{"id", "name", "description", "rules", "created_at", "updated_at"}
+
+func (r *SegmentRepository) Create(ctx context.Context, segment *model.CustomerSegment) (*model.CustomerSegment, error) {
+	rules, err := json.Marshal(segment.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode segment rules: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Insert("customer_segments").Rows(
+		goqu.Record{
+			"name":        segment.Name,
+			"description": utils.NullIfEmpty(segment.Description),
+			"rules":       string(rules),
+		}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create segment: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *SegmentRepository) GetAll(ctx context.Context) ([]*model.CustomerSegment, error) {
+	query, args, err := r.db.Dialect.
+		Select(segmentColumns...).From("customer_segments").Order(goqu.I("name").Asc()).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segments: %w", err)
+	}
+	defer rows.Close()
+
+	segments := []*model.CustomerSegment{}
+	for rows.Next() {
+		segment, err := scanSegment(rows)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return segments, nil
+}
+
+func (r *SegmentRepository) GetByID(ctx context.Context, id int64) (*model.CustomerSegment, error) {
+	query, args, err := r.db.Dialect.
+		Select(segmentColumns...).From("customer_segments").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	segment, err := scanSegment(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("segment not found")
+		}
+		return nil, err
+	}
+	return segment, nil
+}
+
+// ExistsByName reports whether another segment (excluding excludeID) already uses the name
+func (r *SegmentRepository) ExistsByName(ctx context.Context, name string, excludeID int64) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("customer_segments").
+		Where(goqu.Ex{"name": name, "id": goqu.Op{"neq": excludeID}}).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check segment name: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *SegmentRepository) Update(ctx context.Context, segment *model.CustomerSegment) error {
+	rules, err := json.Marshal(segment.Rules)
+	if err != nil {
+		return fmt.Errorf("failed to encode segment rules: %w", err)
+	}
+
+	query, args, err := r.db.Dialect.
+		Update("customer_segments").
+		Set(goqu.Record{
+			"name":        segment.Name,
+			"description": utils.NullIfEmpty(segment.Description),
+			"rules":       string(rules),
+		}).
+		Where(goqu.Ex{"id": segment.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update segment: %w", err)
+	}
+	return nil
+}
+
+func (r *SegmentRepository) Delete(ctx context.Context, id int64) error {
+	query, args, err := r.db.Dialect.Delete("customer_segments").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete segment: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("segment not found")
+	}
+	return nil
+}
+
+// GetCustomerActivity returns, for every customer with an order on the platforms (all when empty),
+// the date of their last order and the count and total of their orders since the given time.
+// Canceled and returned orders, walk-in sales and anonymized customers are left out
+func (r *SegmentRepository) GetCustomerActivity(ctx context.Context, since time.Time, platformIDs []int64) ([]*model.CustomerRFM, error) {
+	where := []goqu.Expression{
+		goqu.L("COALESCE(ls.status, 0) NOT IN ?", []int8{int8(model.OrderStatusCanceled), int8(model.OrderStatusReturned)}),
+		goqu.I("customer.anonymized_at").IsNull(),
+	}
+	if len(platformIDs) > 0 {
+		where = append(where, goqu.Ex{"orders.platform_id": platformIDs})
+	}
+
+	query, args, err := r.db.Dialect.
+		Select(
+			goqu.I("customer.id"),
+			goqu.L("COALESCE(customer.first_name, '')"),
+			goqu.L("COALESCE(customer.last_name, '')"),
+			goqu.L("COALESCE(customer.email, '')"),
+			goqu.L("COALESCE(customer.phone_number, '')"),
+			goqu.MAX("orders.created_at"),
+			goqu.L("SUM(CASE WHEN orders.created_at >= ? THEN 1 ELSE 0 END)", since),
+			goqu.L("COALESCE(SUM(CASE WHEN orders.created_at >= ? THEN orders.total_amount ELSE 0 END), 0)", since),
+		).
+		From("orders").
+		Join(
+			goqu.T("customer"),
+			goqu.On(goqu.Ex{"customer.id": goqu.I("orders.customer_id")}),
+		).
+		LeftJoin(
+			latestOrderStatusQuery(r.db).As("ls"),
+			goqu.On(goqu.And(
+				goqu.Ex{"ls.order_id": goqu.I("orders.id")},
+				goqu.Ex{"ls.rn": 1},
+			)),
+		).
+		Where(where...).
+		GroupBy(goqu.I("customer.id")).
+		Order(goqu.I("customer.id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := []*model.CustomerRFM{}
+	for rows.Next() {
+		var customer model.CustomerRFM
+		if err := rows.Scan(
+			&customer.CustomerID,
+			&customer.FirstName,
+			&customer.LastName,
+			&customer.Email,
+			&customer.PhoneNumber,
+			&customer.LastOrderAt,
+			&customer.Frequency,
+			&customer.Monetary,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan customer activity: %w", err)
+		}
+		activity = append(activity, &customer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return activity, nil
+}
+
+func scanSegment(row rowScanner) (*model.CustomerSegment, error) {
+	var (
+		segment     model.CustomerSegment
+		description sql.NullString
+		rules       string
+	)
+	err := row.Scan(
+		&segment.ID,
+		&segment.Name,
+		&description,
+		&rules,
+		&segment.CreatedAt,
+		&segment.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan segment: %w", err)
+	}
+	segment.Description = utils.NullStringToString(description)
+	if err := json.Unmarshal([]byte(rules), &segment.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules of segment %d: %w", segment.ID, err)
+	}
+	return &segment, nil
+}
@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/utils"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type TagRepository struct {
+	db *database.DB
+}
+
+func NewTagRepository(db *database.DB) *TagRepository {
+	return &TagRepository{
+		db: db,
+	}
+}
+
+var tagColumns = []interface{}{"id", "name", "color", "created_at", "updated_at"}
+
+func (r *TagRepository) Create(ctx context.Context, tag *model.Tag) (*model.Tag, error) {
+	query, args, err := r.db.Dialect.
+		Insert("tags").Rows(
+		goqu.Record{
+			"name":  tag.Name,
+			"color": utils.NullIfEmpty(tag.Color),
+		}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *TagRepository) GetAll(ctx context.Context) ([]*model.Tag, error) {
+	query, args, err := r.db.Dialect.
+		Select(tagColumns...).From("tags").Order(goqu.I("name").Asc()).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tags, nil
+}
+
+func (r *TagRepository) GetByID(ctx context.Context, id int64) (*model.Tag, error) {
+	query, args, err := r.db.Dialect.
+		Select(tagColumns...).From("tags").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tag, err := scanTag(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperror.NotFound("tag not found")
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+// ExistsByName reports whether another tag (excluding excludeID) already uses the name
+func (r *TagRepository) ExistsByName(ctx context.Context, name string, excludeID int64) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("tags").
+		Where(goqu.Ex{"name": name, "id": goqu.Op{"neq": excludeID}}).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check tag name: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *TagRepository) Update(ctx context.Context, tag *model.Tag) error {
+	query, args, err := r.db.Dialect.
+		Update("tags").
+		Set(goqu.Record{
+			"name":  tag.Name,
+			"color": utils.NullIfEmpty(tag.Color),
+		}).
+		Where(goqu.Ex{"id": tag.ID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the tag, it is removed from the customers having it
+func (r *TagRepository) Delete(ctx context.Context, id int64) error {
+	query, args, err := r.db.Dialect.Delete("tags").Where(goqu.Ex{"id": id}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("tag not found")
+	}
+	return nil
+}
+
+// AddToCustomer tags the customer, tagging twice is a no-op
+func (r *TagRepository) AddToCustomer(ctx context.Context, customerID, tagID int64) error {
+	query, args, err := r.db.Dialect.
+		Insert("customer_tags").
+		Rows(goqu.Record{"customer_id": customerID, "tag_id": tagID}).
+		OnConflict(goqu.DoNothing()).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to tag customer: %w", err)
+	}
+	return nil
+}
+
+func (r *TagRepository) RemoveFromCustomer(ctx context.Context, customerID, tagID int64) error {
+	query, args, err := r.db.Dialect.
+		Delete("customer_tags").
+		Where(goqu.Ex{"customer_id": customerID, "tag_id": tagID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to untag customer: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.NotFound("customer doesn't have the tag")
+	}
+	return nil
+}
+
+// GetByCustomers returns the tags of each customer, by customer id
+func (r *TagRepository) GetByCustomers(ctx context.Context, customerIDs []int64) (map[int64][]*model.Tag, error) {
+	tags := make(map[int64][]*model.Tag)
+	if len(customerIDs) == 0 {
+		return tags, nil
+	}
+
+	columns := []interface{}{goqu.I("ct.customer_id")}
+	for _, column := range tagColumns {
+		columns = append(columns, goqu.I("tags."+column.(string)))
+	}
+	query, args, err := r.db.Dialect.
+		Select(columns...).
+		From(goqu.T("customer_tags").As("ct")).
+		Join(
+			goqu.T("tags"),
+			goqu.On(goqu.Ex{"tags.id": goqu.I("ct.tag_id")}),
+		).
+		Where(goqu.Ex{"ct.customer_id": customerIDs}).
+		Order(goqu.I("tags.name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	rows, err := r.db.SQL.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			customerID int64
+			tag        model.Tag
+			color      sql.NullString
+		)
+		if err := rows.Scan(&customerID, &tag.ID, &tag.Name, &color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan customer tag: %w", err)
+		}
+		tag.Color = utils.NullStringToString(color)
+		tags[customerID] = append(tags[customerID], &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	return tags, nil
+}
+
+// CopyToCustomer gives the tags of the merged customers to toID, before they are deleted
+func (r *TagRepository) CopyToCustomer(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) error {
+	query, args, err := r.db.Dialect.
+		Insert("customer_tags").
+		Cols("customer_id", "tag_id").
+		FromQuery(
+			r.db.Dialect.
+				Select(goqu.V(toID), goqu.I("tag_id")).
+				From("customer_tags").
+				Where(goqu.Ex{"customer_id": fromIDs}),
+		).
+		OnConflict(goqu.DoNothing()).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to move customer tags: %w", err)
+	}
+	return nil
+}
+
+func scanTag(row rowScanner) (*model.Tag, error) {
+	var (
+		tag   model.Tag
+		color sql.NullString
+	)
+	if err := row.Scan(&tag.ID, &tag.Name, &color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan tag: %w", err)
+	}
+	tag.Color = utils.NullStringToString(color)
+	return &tag, nil
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type TwoFactorRepository struct {
+	db *database.DB
+}
+
+func NewTwoFactorRepository(db *database.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{
+		db: db,
+	}
+}
+
+var twoFactorColumns = []interface{}{
+	"user_id", "secret", "last_step", "enabled_at", "failed_attempts", "locked_until", "created_at",
+}
+
+// GetByUser returns the second factor of the user, nil when they never set one up
+func (r *TwoFactorRepository) GetByUser(ctx context.Context, userID int64) (*model.TwoFactor, error) {
+	query, args, err := r.db.Dialect.
+		Select(twoFactorColumns...).From("user_totp").Where(goqu.Ex{"user_id": userID}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var (
+		twoFactor   model.TwoFactor
+		enabledAt   sql.NullTime
+		lockedUntil sql.NullTime
+	)
+	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&twoFactor.LastStep,
+		&enabledAt,
+		&twoFactor.FailedAttempts,
+		&lockedUntil,
+		&twoFactor.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	if enabledAt.Valid {
+		twoFactor.EnabledAt = &enabledAt.Time
+	}
+	if lockedUntil.Valid {
+		twoFactor.LockedUntil = &lockedUntil.Time
+	}
+	return &twoFactor, nil
+}
+
+// SetPending replaces the second factor of the user by a pending one with the secret
+func (r *TwoFactorRepository) SetPending(ctx context.Context, userID int64, secret string) error {
+	tx, err := r.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.delete(ctx, tx, userID); err != nil {
+		return err
+	}
+	query, args, err := r.db.Dialect.
+		Insert("user_totp").
+		Rows(goqu.Record{"user_id": userID, "secret": secret}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Enable turns the pending second factor on with its first recovery codes, step is the one of the code
+// that confirmed the setup
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID, step int64, codeHashes []string, at time.Time) error {
+	tx, err := r.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query, args, err := r.db.Dialect.
+		Update("user_totp").
+		Set(goqu.Record{"enabled_at": at, "last_step": step, "failed_attempts": 0, "locked_until": nil}).
+		Where(goqu.Ex{"user_id": userID, "enabled_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return apperror.Conflict("two-factor authentication is not being set up")
+	}
+	if err := r.replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UseStep records the step of an accepted code, false when a code of this step or a later one
+// was already accepted
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Update("user_totp").
+		Set(goqu.Record{"last_step": step, "failed_attempts": 0, "locked_until": nil}).
+		Where(goqu.Ex{"user_id": userID}, goqu.I("last_step").Lt(step)).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code use: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// SetFailedAttempts records the invalid codes in a row, lockedUntil refuses every code until then
+func (r *TwoFactorRepository) SetFailedAttempts(ctx context.Context, userID int64, attempts int, lockedUntil *time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("user_totp").
+		Set(goqu.Record{"failed_attempts": attempts, "locked_until": lockedUntil}).
+		Where(goqu.Ex{"user_id": userID}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to record invalid two-factor code: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the second factor of the user and their recovery codes
+func (r *TwoFactorRepository) Delete(ctx context.Context, userID int64) error {
+	tx, err := r.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.delete(ctx, tx, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) delete(ctx context.Context, tx *sql.Tx, userID int64) error {
+	for _, table := range []string{"user_recovery_codes", "user_totp"} {
+		query, args, err := r.db.Dialect.Delete(table).Where(goqu.Ex{"user_id": userID}).ToSQL()
+		if err != nil {
+			return fmt.Errorf("failed to build query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to delete two-factor settings: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes replaces every recovery code of the user, used or not
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	tx, err := r.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *TwoFactorRepository) replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int64, codeHashes []string) error {
+	query, args, err := r.db.Dialect.Delete("user_recovery_codes").Where(goqu.Ex{"user_id": userID}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	rows := make([]interface{}, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		rows[i] = goqu.Record{"user_id": userID, "code_hash": codeHash}
+	}
+	query, args, err = r.db.Dialect.Insert("user_recovery_codes").Rows(rows...).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+	return nil
+}
+
+// UseRecoveryCode marks the unused recovery code of the user as used, false when there is none
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, at time.Time) (bool, error) {
+	query, args, err := r.db.Dialect.
+		Update("user_recovery_codes").
+		Set(goqu.Record{"used_at": at}).
+		Where(goqu.Ex{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes of the user still usable
+func (r *TwoFactorRepository) CountUnusedRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("user_recovery_codes").
+		Where(goqu.Ex{"user_id": userID, "used_at": nil}).
+		ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
+	"time"
 
 	"simple-template/internal/database"
 	"simple-template/internal/model"
+	"simple-template/internal/utils"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
+// userColumns are the columns scanned by scanUser, the password hash is only read by GetCredentialsByEmail
+var userColumns = []interface{}{
+	"id", "name", "email", "is_active", "email_verified_at", "role", "last_login_at", "created_at", "updated_at",
+}
+
 // UserRepository handles all database operations related to users
 type UserRepository struct {
 	db *database.DB
@@ -29,8 +37,9 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	query, args, err := r.db.Dialect.
 		Insert("users").
 		Rows(goqu.Record{
-			"name":  user.Name,
-			"email": user.Email,
+			"name":          user.Name,
+			"email":         user.Email,
+			"password_hash": utils.NullIfEmpty(user.PasswordHash),
 		}).
 		ToSQL()
 
@@ -41,6 +50,9 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 	// Thực thi query
 	result, err := r.db.SQL.ExecContext(ctx, query, args...)
 	if err != nil {
+		if isDuplicateEntry(err) {
+			return apperror.Conflict("a user with this email already exists")
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -58,7 +70,7 @@ func (r *UserRepository) Create(ctx context.Context, user *model.User) error {
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	// Build select query with goqu
 	query, args, err := r.db.Dialect.
-		Select("id", "name", "email", "created_at", "updated_at").
+		Select(userColumns...).
 		From("users").
 		Where(goqu.Ex{"id": id}).
 		ToSQL()
@@ -68,30 +80,22 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, er
 	}
 
 	// Execute query
-	var user model.User
-	err = r.db.SQL.QueryRowContext(ctx, query, args...).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
+	user, err := scanUser(r.db.SQL.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, apperror.NotFound("user not found")
 		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // GetAll gets a list of all users
 func (r *UserRepository) GetAll(ctx context.Context) ([]*model.User, error) {
 	// Build select query with goqu
 	query, args, err := r.db.Dialect.
-		Select("id", "name", "email", "created_at", "updated_at").
+		Select(userColumns...).
 		From("users").
 		Order(goqu.I("created_at").Desc()).
 		ToSQL()
@@ -110,18 +114,11 @@ func (r *UserRepository) GetAll(ctx context.Context) ([]*model.User, error) {
 	// Read results
 	var users []*model.User
 	for rows.Next() {
-		var user model.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %w", err)
+			return nil, err
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -156,7 +153,7 @@ func (r *UserRepository) Update(ctx context.Context, id int64, updates map[strin
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperror.NotFound("user not found")
 	}
 
 	return nil
@@ -187,8 +184,196 @@ func (r *UserRepository) Delete(ctx context.Context, id int64) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperror.NotFound("user not found")
 	}
 
 	return nil
 }
+
+// GetCredentialsByEmail gets the user with their password hash, nil when no user has the email
+func (r *UserRepository) GetCredentialsByEmail(ctx context.Context, email string) (*model.User, error) {
+	query, args, err := r.db.Dialect.
+		Select(append([]interface{}{"password_hash"}, userColumns...)...).
+		From("users").
+		Where(goqu.Ex{"email": email}).
+		ToSQL()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	return scanUserCredentials(r.db.SQL.QueryRowContext(ctx, query, args...))
+}
+
+// GetCredentialsByID gets the user with their password hash
+func (r *UserRepository) GetCredentialsByID(ctx context.Context, id int64) (*model.User, error) {
+	query, args, err := r.db.Dialect.
+		Select(append([]interface{}{"password_hash"}, userColumns...)...).
+		From("users").
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	user, err := scanUserCredentials(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apperror.NotFound("user not found")
+	}
+	return user, nil
+}
+
+// scanUserCredentials scans the password hash followed by userColumns, nil when there is no row
+func scanUserCredentials(row rowScanner) (*model.User, error) {
+	var (
+		user            model.User
+		passwordHash    sql.NullString
+		emailVerifiedAt sql.NullTime
+		role            sql.NullString
+		lastLoginAt     sql.NullTime
+	)
+	err := row.Scan(
+		&passwordHash,
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.IsActive,
+		&emailVerifiedAt,
+		&role,
+		&lastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.PasswordHash = utils.NullStringToString(passwordHash)
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	user.Role = utils.NullStringToString(role)
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	return &user, nil
+}
+
+// SetPassword replaces the password hash of the user
+func (r *UserRepository) SetPassword(ctx context.Context, id int64, passwordHash string) error {
+	return r.Update(ctx, id, map[string]interface{}{"password_hash": passwordHash})
+}
+
+// SetRole sets the role the user has in every store, empty removes it
+func (r *UserRepository) SetRole(ctx context.Context, id int64, role string) error {
+	query, args, err := r.db.Dialect.
+		Update("users").
+		Set(goqu.Record{"role": utils.NullIfEmpty(role)}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	return nil
+}
+
+// UpdateLastLogin records a successful sign in
+func (r *UserRepository) UpdateLastLogin(ctx context.Context, id int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("users").
+		Set(goqu.Record{"last_login_at": at}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to update last login: %w", err)
+	}
+	return nil
+}
+
+// SetEmailVerified records the user proved they own their email
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id int64, at time.Time) error {
+	query, args, err := r.db.Dialect.
+		Update("users").
+		Set(goqu.Record{"email_verified_at": at}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+
+	if err != nil {
+		return fmt.Errorf("failed to build update query: %w", err)
+	}
+
+	if _, err := r.db.SQL.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to set email verified: %w", err)
+	}
+	return nil
+}
+
+// CountWithPassword counts the users able to sign in
+func (r *UserRepository) CountWithPassword(ctx context.Context) (int, error) {
+	query, args, err := r.db.Dialect.
+		Select(goqu.COUNT("id")).
+		From("users").
+		Where(goqu.I("password_hash").IsNotNull()).
+		ToSQL()
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to build select query: %w", err)
+	}
+
+	var count int
+	if err := r.db.SQL.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// scanUser scans userColumns, followed by the extra columns of the query into extra
+func scanUser(row rowScanner, extra ...interface{}) (*model.User, error) {
+	var (
+		user            model.User
+		emailVerifiedAt sql.NullTime
+		role            sql.NullString
+		lastLoginAt     sql.NullTime
+	)
+	dest := []interface{}{
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.IsActive,
+		&emailVerifiedAt,
+		&role,
+		&lastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan user: %w", err)
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	user.Role = utils.NullStringToString(role)
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	return &user, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type UserTokenRepository struct {
+	db *database.DB
+}
+
+func NewUserTokenRepository(db *database.DB) *UserTokenRepository {
+	return &UserTokenRepository{
+		db: db,
+	}
+}
+
+var userTokenColumns = []interface{}{
+	"id", "user_id", "purpose", "email", "token_hash", "expires_at", "used_at", "created_by", "created_at",
+}
+
+// Replace deletes the unused tokens of the user with the same purpose and stores the new one,
+// so only the last token sent works
+func (r *UserTokenRepository) Replace(ctx context.Context, token *model.UserToken) error {
+	tx, err := r.db.SQL.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query, args, err := r.db.Dialect.
+		Delete("user_tokens").
+		Where(goqu.Ex{"user_id": token.UserID, "purpose": token.Purpose, "used_at": nil}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to delete user tokens: %w", err)
+	}
+
+	query, args, err = r.db.Dialect.
+		Insert("user_tokens").Rows(
+		goqu.Record{
+			"user_id":    token.UserID,
+			"purpose":    token.Purpose,
+			"email":      token.Email,
+			"token_hash": token.TokenHash,
+			"expires_at": token.ExpiresAt,
+			"created_by": token.CreatedBy,
+		}).ToSQL()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to create user token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Consume marks the token as used and returns it, nil when it is unknown, used or expired.
+// Only one of concurrent calls with the same token gets it
+func (r *UserTokenRepository) Consume(ctx context.Context, purpose, tokenHash string, at time.Time) (*model.UserToken, error) {
+	query, args, err := r.db.Dialect.
+		Update("user_tokens").
+		Set(goqu.Record{"used_at": at}).
+		Where(
+			goqu.Ex{"token_hash": tokenHash, "purpose": purpose, "used_at": nil},
+			goqu.I("expires_at").Gt(at),
+		).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	result, err := r.db.SQL.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use user token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, nil
+	}
+
+	query, args, err = r.db.Dialect.
+		Select(userTokenColumns...).From("user_tokens").Where(goqu.Ex{"token_hash": tokenHash}).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+	token, err := scanUserToken(r.db.SQL.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+func scanUserToken(row rowScanner) (*model.UserToken, error) {
+	var (
+		token     model.UserToken
+		usedAt    sql.NullTime
+		createdBy sql.NullInt64
+	)
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Purpose,
+		&token.Email,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&usedAt,
+		&createdBy,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan user token: %w", err)
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if createdBy.Valid {
+		token.CreatedBy = &createdBy.Int64
+	}
+	return &token, nil
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"simple-template/internal/apperror"
+	"simple-template/internal/mailer"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"strings"
+	"time"
+)
+
+// AccountSettings configures the emails sent by AccountUsecase
+type AccountSettings struct {
+	// AppURL is the frontend the links in the emails point to
+	AppURL               string
+	InvitationTTL        time.Duration
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+}
+
+// AccountUsecase handles the flows driven by one-time tokens sent by email:
+// invitations, email verification and password reset
+type AccountUsecase struct {
+	userRepo         *repository.UserRepository
+	userTokenRepo    *repository.UserTokenRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	auditUsecase     *AuditUsecase
+	mailer           mailer.Mailer
+	settings         AccountSettings
+}
+
+func NewAccountUsecase(
+	userRepo *repository.UserRepository,
+	userTokenRepo *repository.UserTokenRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	auditUsecase *AuditUsecase,
+	mailer mailer.Mailer,
+	settings AccountSettings,
+) *AccountUsecase {
+	return &AccountUsecase{
+		userRepo:         userRepo,
+		userTokenRepo:    userTokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		auditUsecase:     auditUsecase,
+		mailer:           mailer,
+		settings:         settings,
+	}
+}
+
+// Invite creates a user without a password and emails them a link to choose one
+func (u *AccountUsecase) Invite(ctx context.Context, req *model.InviteUserRequest) (*model.User, error) {
+	name := strings.TrimSpace(req.Name)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if name == "" {
+		return nil, apperror.InvalidField("name", "required", "name is required")
+	}
+	if !strings.Contains(email, "@") {
+		return nil, apperror.InvalidField("email", "email", "invalid email format")
+	}
+	if req.Role != "" && !model.IsValidRole(req.Role) {
+		return nil, apperror.InvalidField("role", "oneof", "invalid role %s", req.Role)
+	}
+	existing, err := u.userRepo.GetCredentialsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, apperror.Conflict("a user with this email already exists")
+	}
+
+	user := &model.User{Name: name, Email: email}
+	if err := u.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	if req.Role != "" {
+		if err := u.userRepo.SetRole(ctx, user.ID, req.Role); err != nil {
+			return nil, err
+		}
+	}
+	createdUser, err := u.userRepo.GetByID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditUsecase.Record(ctx, model.AuditActionCreate, model.AuditEntityUser, user.ID, nil, createdUser)
+
+	if err := u.sendInvitation(ctx, user); err != nil {
+		return nil, fmt.Errorf("user %d was created but the invitation could not be sent, resend it: %w", user.ID, err)
+	}
+	return createdUser, nil
+}
+
+// ResendInvitation sends a new invitation to a user who hasn't accepted theirs, the previous link stops working
+func (u *AccountUsecase) ResendInvitation(ctx context.Context, userID int64) error {
+	user, err := u.userRepo.GetCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != "" {
+		return apperror.Conflict("user has already set a password")
+	}
+	if !user.IsActive {
+		return apperror.Forbidden("user is deactivated")
+	}
+	return u.sendInvitation(ctx, user)
+}
+
+// AcceptInvitation sets the password of the invited user, the invitation also verifies their email
+func (u *AccountUsecase) AcceptInvitation(ctx context.Context, req *model.AcceptInvitationRequest) (*model.User, error) {
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	user, err := u.consumeToken(ctx, model.TokenPurposeInvitation, req.Token, now)
+	if err != nil {
+		return nil, err
+	}
+	if user.PasswordHash != "" {
+		return nil, apperror.Conflict("invitation has already been accepted")
+	}
+	if !user.IsActive {
+		return nil, apperror.Forbidden("user is deactivated")
+	}
+
+	if err := u.userRepo.SetPassword(ctx, user.ID, passwordHash); err != nil {
+		return nil, err
+	}
+	if err := u.userRepo.SetEmailVerified(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	return u.userRepo.GetByID(ctx, user.ID)
+}
+
+// SendEmailVerification emails the signed in user a link proving they own their email
+func (u *AccountUsecase) SendEmailVerification(ctx context.Context, userID int64) error {
+	if err := requireSession(ctx); err != nil {
+		return err
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return apperror.Conflict("email is already verified")
+	}
+
+	token, expiresAt, err := u.issueToken(ctx, user, model.TokenPurposeEmailVerification, u.settings.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen this link to verify your email:\n%s\n\nThe link expires on %s.\n",
+			user.Name, u.link("/verify-email", token), expiresAt.Format(time.RFC1123),
+		),
+	})
+}
+
+// VerifyEmail marks the email of the token as verified, the token is refused once the email changed
+func (u *AccountUsecase) VerifyEmail(ctx context.Context, req *model.VerifyEmailRequest) (*model.User, error) {
+	now := time.Now()
+	user, err := u.consumeToken(ctx, model.TokenPurposeEmailVerification, req.Token, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.userRepo.SetEmailVerified(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	return u.userRepo.GetByID(ctx, user.ID)
+}
+
+// ForgotPassword emails a password reset link when an active user with a password has the email.
+// It never tells whether the email is known, failing to send is only logged
+func (u *AccountUsecase) ForgotPassword(ctx context.Context, req *model.ForgotPasswordRequest) error {
+	user, err := u.userRepo.GetCredentialsByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return err
+	}
+	// Invited users who haven't set a password yet use their invitation
+	if user == nil || !user.IsActive || user.PasswordHash == "" {
+		return nil
+	}
+
+	token, expiresAt, err := u.issueToken(ctx, user, model.TokenPurposePasswordReset, u.settings.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	err = u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nSomeone asked to reset your password. Open this link to choose a new one:\n%s\n\n"+
+				"The link expires on %s. Ignore this email if you didn't ask for it, your password stays the same.\n",
+			user.Name, u.link("/reset-password", token), expiresAt.Format(time.RFC1123),
+		),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to send password reset email", "target_user_id", user.ID, "error", err.Error())
+	}
+	return nil
+}
+
+// ResetPassword replaces the password of the token's user and ends all their sessions
+func (u *AccountUsecase) ResetPassword(ctx context.Context, req *model.ResetPasswordRequest) error {
+	passwordHash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user, err := u.consumeToken(ctx, model.TokenPurposePasswordReset, req.Token, now)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return apperror.Forbidden("user is deactivated")
+	}
+
+	if err := u.userRepo.SetPassword(ctx, user.ID, passwordHash); err != nil {
+		return err
+	}
+	// The link was received at the email, which proves the user owns it
+	if user.EmailVerifiedAt == nil {
+		if err := u.userRepo.SetEmailVerified(ctx, user.ID, now); err != nil {
+			return err
+		}
+	}
+	return u.refreshTokenRepo.RevokeAllForUser(ctx, user.ID, now)
+}
+
+func (u *AccountUsecase) sendInvitation(ctx context.Context, user *model.User) error {
+	token, expiresAt, err := u.issueToken(ctx, user, model.TokenPurposeInvitation, u.settings.InvitationTTL)
+	if err != nil {
+		return err
+	}
+	return u.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "You have been invited",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nAn account has been created for you. Open this link to choose your password:\n%s\n\n"+
+				"The link expires on %s.\n",
+			user.Name, u.link("/accept-invitation", token), expiresAt.Format(time.RFC1123),
+		),
+	})
+}
+
+// issueToken stores a new token for the user's current email, replacing the unused ones with the same purpose
+func (u *AccountUsecase) issueToken(ctx context.Context, user *model.User, purpose string, ttl time.Duration) (string, time.Time, error) {
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	userToken := &model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if principal := model.PrincipalFromContext(ctx); principal != nil {
+		userToken.CreatedBy = &principal.UserID
+	}
+	if err := u.userTokenRepo.Replace(ctx, userToken); err != nil {
+		return "", time.Time{}, err
+	}
+	return token, userToken.ExpiresAt, nil
+}
+
+// consumeToken uses the token and returns its user with their password hash,
+// the token is refused when the user changed their email since it was sent
+func (u *AccountUsecase) consumeToken(ctx context.Context, purpose, token string, now time.Time) (*model.User, error) {
+	invalid := apperror.Validation("invalid or expired %s token", strings.ReplaceAll(purpose, "_", " "))
+	userToken, err := u.userTokenRepo.Consume(ctx, purpose, hashToken(token), now)
+	if err != nil {
+		return nil, err
+	}
+	if userToken == nil {
+		return nil, invalid
+	}
+	user, err := u.userRepo.GetCredentialsByID(ctx, userToken.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != userToken.Email {
+		return nil, invalid
+	}
+	return user, nil
+}
+
+func (u *AccountUsecase) link(path, token string) string {
+	return u.settings.AppURL + path + "?token=" + url.QueryEscape(token)
+}
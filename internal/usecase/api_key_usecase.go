@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+// apiKeyPrefixLength is how much of the key is kept in clear to tell the keys apart
+const apiKeyPrefixLength = len(model.APIKeyPrefix) + 8
+
+type APIKeyUsecase struct {
+	apiKeyRepo *repository.APIKeyRepository
+	userRepo   *repository.UserRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo *repository.APIKeyRepository, userRepo *repository.UserRepository) *APIKeyUsecase {
+	return &APIKeyUsecase{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// GetByUser lists the keys of the user, revoked and expired ones included
+func (u *APIKeyUsecase) GetByUser(ctx context.Context, userID int64) ([]*model.APIKey, error) {
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return u.apiKeyRepo.GetByUser(ctx, userID)
+}
+
+// Create issues a key to the signed in user, scoped to permissions they have. The key is only returned here
+func (u *APIKeyUsecase) Create(ctx context.Context, userID int64, req *model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error) {
+	if err := requireSession(ctx); err != nil {
+		return nil, err
+	}
+	principal := model.PrincipalFromContext(ctx)
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.InvalidField("name", "required", "name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, apperror.InvalidField("scopes", "min", "at least one scope is required")
+	}
+	scopes := []model.Permission{}
+	for i, scope := range req.Scopes {
+		if !model.IsValidPermission(scope) {
+			return nil, apperror.InvalidField(fmt.Sprintf("scopes[%d]", i), "oneof", "invalid scope %s", scope)
+		}
+		if principal != nil && !principal.Can(scope) {
+			return nil, apperror.Forbidden("you don't have the %s permission", scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, apperror.InvalidField("expires_at", "future", "expires_at must be in the future")
+	}
+
+	secret, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	key := model.APIKeyPrefix + secret
+	created, err := u.apiKeyRepo.Create(ctx, &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    key[:apiKeyPrefixLength],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.CreatedAPIKey{APIKey: created, Key: key}, nil
+}
+
+// Revoke revokes a key of the user, it stops working right away
+func (u *APIKeyUsecase) Revoke(ctx context.Context, userID, id int64) error {
+	key, err := u.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.UserID != userID {
+		return apperror.NotFound("api key not found")
+	}
+	return u.apiKeyRepo.Revoke(ctx, userID, id, time.Now())
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/pkg/pagination"
+	"slices"
+	"strings"
+	"time"
+)
+
+// auditIgnoredFields change on every write and would only add noise to the diffs
+var auditIgnoredFields = []string{"created_at", "updated_at"}
+
+// auditPersonalFields are recorded as changed without their values, the audit logs outlive the erasure of customers
+var auditPersonalFields = map[string][]string{
+	model.AuditEntityCustomer: model.CustomerPersonalFields,
+	model.AuditEntityOrder:    model.OrderPersonalFields,
+}
+
+var auditSortFields = []string{"created_at", "id"}
+
+var auditActions = []string{model.AuditActionCreate, model.AuditActionUpdate, model.AuditActionDelete}
+
+var auditEntityTypes = []string{model.AuditEntityUser, model.AuditEntityCustomer, model.AuditEntityProduct, model.AuditEntityOrder}
+
+type AuditUsecase struct {
+	auditRepo         *repository.AuditRepository
+	paginationService *pagination.Service
+}
+
+func NewAuditUsecase(auditRepo *repository.AuditRepository) *AuditUsecase {
+	return &AuditUsecase{
+		auditRepo:         auditRepo,
+		paginationService: pagination.NewService(),
+	}
+}
+
+// Record logs the change of the entity by the user of the request, before is nil for a create
+// and after is nil for a delete. Updates changing nothing are skipped.
+// The change is already done, failing to record it is only logged
+func (u *AuditUsecase) Record(ctx context.Context, action, entityType string, entityID int64, before, after interface{}) {
+	changes, err := auditDiff(before, after)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to audit change", "action", action, "entity_type", entityType, "entity_id", entityID, "error", err.Error())
+		return
+	}
+	model.RedactAuditChanges(changes, auditPersonalFields[entityType])
+	if action == model.AuditActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	entry := &model.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}
+	if principal := model.PrincipalFromContext(ctx); principal != nil {
+		entry.ActorUserID = &principal.UserID
+		if principal.APIKeyID != 0 {
+			entry.ActorAPIKeyID = &principal.APIKeyID
+		}
+	}
+	if info := model.RequestInfoFromContext(ctx); info != nil {
+		entry.IPAddress = truncate(info.IPAddress, 45)
+		entry.RequestID = info.RequestID
+	}
+	if err := u.auditRepo.Create(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Failed to audit change", "action", action, "entity_type", entityType, "entity_id", entityID, "error", err.Error())
+	}
+}
+
+// RedactCustomer replaces the personal data of the customer in the audit logs, in the transaction anonymizing it.
+// Record redacts it already, this scrubs the logs recorded before it did
+func (u *AuditUsecase) RedactCustomer(ctx context.Context, tx *sql.Tx, customerID int64) error {
+	return u.auditRepo.RedactCustomer(ctx, tx, customerID)
+}
+
+// GetAll lists the audit logs page by page, newest first by default
+func (u *AuditUsecase) GetAll(ctx context.Context, req *pagination.Request, filter *model.AuditFilter) (*pagination.Response, error) {
+	u.paginationService.ValidateAndNormalize(req)
+	if !slices.Contains(auditSortFields, req.SortBy) {
+		return nil, apperror.InvalidField("sort_by", "oneof", "invalid sort_by, expected one of %s", strings.Join(auditSortFields, ", "))
+	}
+	if filter.Action != "" && !slices.Contains(auditActions, filter.Action) {
+		return nil, apperror.InvalidField("action", "oneof", "invalid action, expected one of %s", strings.Join(auditActions, ", "))
+	}
+	if filter.EntityType != "" && !slices.Contains(auditEntityTypes, filter.EntityType) {
+		return nil, apperror.InvalidField("entity_type", "oneof", "invalid entity_type, expected one of %s", strings.Join(auditEntityTypes, ", "))
+	}
+	from, to, err := parseDateRange(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, effectiveOrder := u.paginationService.GetNavigationParams(*req)
+	fetchLimit := u.paginationService.CalculateFetchLimit(req.Limit)
+	entries, err := u.auditRepo.GetAllPaginated(ctx, filter, from, to, cursor, fetchLimit, effectiveOrder, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		items[i] = entry
+	}
+	response := u.paginationService.BuildResponse(
+		items,
+		req,
+		func(e interface{}) (time.Time, int64) {
+			entry := e.(*model.AuditLog)
+			return entry.CreatedAt, entry.ID
+		},
+	)
+	return &response, nil
+}
+
+// auditDiff compares the JSON of before and after field by field, a nil side has no fields
+func auditDiff(before, after interface{}) (map[string]*model.AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]*model.AuditChange{}
+	for field, value := range beforeFields {
+		if other, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes[field] = &model.AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = &model.AuditChange{After: value}
+		}
+	}
+	for _, field := range auditIgnoredFields {
+		delete(changes, field)
+	}
+	return changes, nil
+}
+
+// auditFields returns the top level JSON fields of the value, fields tagged json:"-" are left out
+func auditFields(value interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Pointer && reflect.ValueOf(value).IsNil() {
+		return fields, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audited value: %w", err)
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("audited value is not a JSON object: %w", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,586 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AuthSettings configures the tokens issued by AuthUsecase
+type AuthSettings struct {
+	JWTSecret       []byte
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// RequireTwoFactorForAdmin withholds every permission of admins until they enable two-factor authentication
+	RequireTwoFactorForAdmin bool
+}
+
+// AccessClaims are the claims of an access token, the subject is the user id
+// and SessionID the family of the refresh token issued with it
+type AccessClaims struct {
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// twoFactorChallengeTTL is how long a user has to enter their code after their password
+const twoFactorChallengeTTL = 5 * time.Minute
+
+// twoFactorChallengeAudience tells the challenge tokens apart, they are also signed with their own key
+const twoFactorChallengeAudience = "2fa-challenge"
+
+// dummyPasswordHash is compared against when the email is unknown,
+// so a login takes as long whether the user exists or not
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type AuthUsecase struct {
+	userRepo         *repository.UserRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	retailStoreRepo  *repository.RetailStoreRepository
+	apiKeyRepo       *repository.APIKeyRepository
+	twoFactorRepo    *repository.TwoFactorRepository
+	settings         AuthSettings
+}
+
+func NewAuthUsecase(
+	userRepo *repository.UserRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	retailStoreRepo *repository.RetailStoreRepository,
+	apiKeyRepo *repository.APIKeyRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	settings AuthSettings,
+) *AuthUsecase {
+	return &AuthUsecase{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		retailStoreRepo:  retailStoreRepo,
+		apiKeyRepo:       apiKeyRepo,
+		twoFactorRepo:    twoFactorRepo,
+		settings:         settings,
+	}
+}
+
+// Login checks the credentials and opens a new session. Users with two-factor authentication get a challenge
+// instead, to complete with LoginTwoFactor
+func (u *AuthUsecase) Login(ctx context.Context, req *model.LoginRequest, client model.ClientInfo) (*model.LoginResponse, error) {
+	user, err := u.userRepo.GetCredentialsByEmail(ctx, strings.ToLower(strings.TrimSpace(req.Email)))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.PasswordHash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, apperror.Unauthorized("invalid email or password")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, apperror.Unauthorized("invalid email or password")
+	}
+	if !user.IsActive {
+		return nil, apperror.Forbidden("user is deactivated")
+	}
+
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		challenge, err := u.signTwoFactorChallenge(user.ID, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return &model.LoginResponse{
+			TwoFactorRequired:  true,
+			ChallengeToken:     challenge,
+			ChallengeExpiresIn: int64(twoFactorChallengeTTL.Seconds()),
+		}, nil
+	}
+
+	tokens, err := u.openSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResponse{TokenPair: tokens}, nil
+}
+
+// LoginTwoFactor completes the login challenge with a code of the authenticator app or a recovery code
+func (u *AuthUsecase) LoginTwoFactor(ctx context.Context, req *model.LoginTwoFactorRequest, client model.ClientInfo) (*model.TokenPair, error) {
+	userID, err := u.parseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, apperror.Forbidden("user is deactivated")
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, apperror.Unauthorized("two-factor authentication is not enabled, log in again")
+	}
+
+	if err := verifySecondFactor(ctx, u.twoFactorRepo, twoFactor, req.Code, true, time.Now()); err != nil {
+		return nil, err
+	}
+	return u.openSession(ctx, user, client)
+}
+
+// openSession issues the tokens of a new session of the user who just proved who they are
+func (u *AuthUsecase) openSession(ctx context.Context, user *model.User, client model.ClientInfo) (*model.TokenPair, error) {
+	now := time.Now()
+	familyID, err := randomToken(16, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := u.refreshTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	refreshToken, _, expiresAt, err := u.issueRefreshToken(ctx, tx, user.ID, familyID, client, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if err := u.userRepo.UpdateLastLogin(ctx, user.ID, now); err != nil {
+		return nil, err
+	}
+	user.LastLoginAt = &now
+	return u.tokenPair(user, familyID, refreshToken, expiresAt, now)
+}
+
+// Refresh exchanges a refresh token for a new pair, the token can't be used again.
+// Using a token that was already rotated revokes the whole session, as it was likely stolen
+func (u *AuthUsecase) Refresh(ctx context.Context, req *model.RefreshTokenRequest, client model.ClientInfo) (*model.TokenPair, error) {
+	now := time.Now()
+	tx, err := u.refreshTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := u.refreshTokenRepo.LockByHash(ctx, tx, hashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, apperror.Unauthorized("invalid refresh token")
+	}
+	if current.RevokedAt != nil {
+		if current.ReplacedByID != nil {
+			if err := u.refreshTokenRepo.RevokeFamily(ctx, tx, current.FamilyID, now); err != nil {
+				return nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, fmt.Errorf("failed to commit transaction: %w", err)
+			}
+		}
+		return nil, apperror.Unauthorized("refresh token has been revoked")
+	}
+	if !current.ExpiresAt.After(now) {
+		return nil, apperror.Unauthorized("refresh token has expired")
+	}
+
+	user, err := u.userRepo.GetByID(ctx, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, apperror.Forbidden("user is deactivated")
+	}
+
+	refreshToken, id, expiresAt, err := u.issueRefreshToken(ctx, tx, user.ID, current.FamilyID, client, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.refreshTokenRepo.Revoke(ctx, tx, current.ID, &id, now); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return u.tokenPair(user, current.FamilyID, refreshToken, expiresAt, now)
+}
+
+// Logout ends the session of the refresh token, the access tokens already issued
+// stay valid until they expire
+func (u *AuthUsecase) Logout(ctx context.Context, userID int64, req *model.RefreshTokenRequest) error {
+	if err := requireSession(ctx); err != nil {
+		return err
+	}
+	tx, err := u.refreshTokenRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	token, err := u.refreshTokenRepo.LockByHash(ctx, tx, hashToken(req.RefreshToken))
+	if err != nil {
+		return err
+	}
+	if token == nil || token.UserID != userID {
+		return apperror.Unauthorized("invalid refresh token")
+	}
+	if err := u.refreshTokenRepo.RevokeFamily(ctx, tx, token.FamilyID, time.Now()); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// LogoutAll ends every session of the user
+func (u *AuthUsecase) LogoutAll(ctx context.Context, userID int64) error {
+	if err := requireSession(ctx); err != nil {
+		return err
+	}
+	return u.refreshTokenRepo.RevokeAllForUser(ctx, userID, time.Now())
+}
+
+// Me returns the signed in user with their roles and permissions
+func (u *AuthUsecase) Me(ctx context.Context, userID int64) (*model.CurrentUser, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	storeRoles, err := u.retailStoreRepo.GetStoreRolesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	principal := &model.Principal{UserID: user.ID, Role: user.Role, StoreRoles: storeRoles}
+
+	current := &model.CurrentUser{User: user, StoreRoles: []*model.StoreRole{}, Permissions: []model.Permission{}}
+	for storeID, role := range storeRoles {
+		current.StoreRoles = append(current.StoreRoles, &model.StoreRole{StoreID: storeID, Role: role})
+	}
+	slices.SortFunc(current.StoreRoles, func(a, b *model.StoreRole) int {
+		return cmp.Compare(a.StoreID, b.StoreID)
+	})
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	current.TwoFactorEnabled = twoFactor.IsEnabled()
+	if principal.TwoFactorSetupRequired, err = u.twoFactorSetupRequired(ctx, user); err != nil {
+		return nil, err
+	}
+	current.TwoFactorSetupRequired = principal.TwoFactorSetupRequired
+	for _, permission := range model.RolePermissions[model.RoleAdmin] {
+		if principal.Can(permission) {
+			current.Permissions = append(current.Permissions, permission)
+		}
+	}
+	return current, nil
+}
+
+// ChangePassword replaces the password of the user and ends all their sessions
+func (u *AuthUsecase) ChangePassword(ctx context.Context, userID int64, req *model.ChangePasswordRequest) error {
+	if err := requireSession(ctx); err != nil {
+		return err
+	}
+	user, err := u.userRepo.GetCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" ||
+		bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
+		return apperror.Unauthorized("current password is incorrect")
+	}
+
+	passwordHash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := u.userRepo.SetPassword(ctx, userID, passwordHash); err != nil {
+		return err
+	}
+	return u.refreshTokenRepo.RevokeAllForUser(ctx, userID, time.Now())
+}
+
+// Authenticate verifies the access token and loads the roles of its user
+func (u *AuthUsecase) Authenticate(ctx context.Context, token string) (*model.Principal, error) {
+	userID, err := u.parseAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return u.loadPrincipal(ctx, userID)
+}
+
+// AuthenticateAPIKey verifies the API key and loads the roles of its user, limited to the key scopes
+func (u *AuthUsecase) AuthenticateAPIKey(ctx context.Context, key string) (*model.Principal, error) {
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, apperror.Unauthorized("invalid api key")
+	}
+	apiKey, err := u.apiKeyRepo.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey == nil || !apiKey.IsUsable(now) {
+		return nil, apperror.Unauthorized("invalid, revoked or expired api key")
+	}
+
+	principal, err := u.loadPrincipal(ctx, apiKey.UserID)
+	if err != nil {
+		return nil, err
+	}
+	principal.APIKeyID = apiKey.ID
+	principal.Scopes = apiKey.Scopes
+	if err := u.apiKeyRepo.Touch(ctx, apiKey.ID, now); err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+func (u *AuthUsecase) loadPrincipal(ctx context.Context, userID int64) (*model.Principal, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if apperror.CodeOf(err) == apperror.CodeNotFound {
+		// The credentials outlived their user
+		return nil, apperror.Wrap(apperror.CodeUnauthorized, err, "user of the credentials no longer exists")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, apperror.Forbidden("user is deactivated")
+	}
+	storeRoles, err := u.retailStoreRepo.GetStoreRolesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	principal := &model.Principal{UserID: user.ID, Role: user.Role, StoreRoles: storeRoles}
+	if principal.TwoFactorSetupRequired, err = u.twoFactorSetupRequired(ctx, user); err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+// twoFactorSetupRequired reports whether the user must enable two-factor authentication before anything else
+func (u *AuthUsecase) twoFactorSetupRequired(ctx context.Context, user *model.User) (bool, error) {
+	if !u.settings.RequireTwoFactorForAdmin || user.Role != model.RoleAdmin {
+		return false, nil
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	return !twoFactor.IsEnabled(), nil
+}
+
+// signTwoFactorChallenge returns the token proving the password of the user was checked
+func (u *AuthUsecase) signTwoFactorChallenge(userID int64, now time.Time) (string, error) {
+	claims := jwt.RegisteredClaims{
+		Issuer:    u.settings.Issuer,
+		Subject:   strconv.FormatInt(userID, 10),
+		Audience:  jwt.ClaimStrings{twoFactorChallengeAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(twoFactorChallengeTTL)),
+	}
+	challenge, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(u.twoFactorChallengeKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign two-factor challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// parseTwoFactorChallenge verifies the challenge token and returns the id of its user
+func (u *AuthUsecase) parseTwoFactorChallenge(challenge string) (int64, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(challenge, &claims, func(t *jwt.Token) (interface{}, error) {
+		return u.twoFactorChallengeKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(u.settings.Issuer),
+		jwt.WithAudience(twoFactorChallengeAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, apperror.Unauthorized("invalid or expired two-factor challenge, log in again")
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, apperror.Unauthorized("invalid two-factor challenge subject")
+	}
+	return userID, nil
+}
+
+// twoFactorChallengeKey is derived from the JWT secret, so a challenge can't be used as an access token
+func (u *AuthUsecase) twoFactorChallengeKey() []byte {
+	mac := hmac.New(sha256.New, u.settings.JWTSecret)
+	mac.Write([]byte(twoFactorChallengeAudience))
+	return mac.Sum(nil)
+}
+
+// parseAccessToken verifies the access token and returns the id of its user
+func (u *AuthUsecase) parseAccessToken(token string) (int64, error) {
+	var claims AccessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return u.settings.JWTSecret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(u.settings.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		// Expired, malformed or wrongly signed tokens are the client's to renew
+		return 0, apperror.Wrap(apperror.CodeUnauthorized, err, "invalid access token")
+	}
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, apperror.Unauthorized("invalid access token subject")
+	}
+	return userID, nil
+}
+
+// Bootstrap gives a password and the admin role to the admin user when no user can sign in yet,
+// the user is created when no user has the email
+func (u *AuthUsecase) Bootstrap(ctx context.Context, email, password string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || password == "" {
+		return nil
+	}
+	count, err := u.userRepo.CountWithPassword(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user, err := u.userRepo.GetCredentialsByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		user = &model.User{
+			Name:         "Administrator",
+			Email:        email,
+			PasswordHash: passwordHash,
+		}
+		if err := u.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+	} else if err := u.userRepo.SetPassword(ctx, user.ID, passwordHash); err != nil {
+		return err
+	}
+	return u.userRepo.SetRole(ctx, user.ID, model.RoleAdmin)
+}
+
+// HashPassword hashes the password with bcrypt
+func HashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", apperror.Validation("password must be at least 8 characters")
+	}
+	// bcrypt ignores what comes after 72 bytes
+	if len(password) > 72 {
+		return "", apperror.Validation("password must be at most 72 bytes")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// issueRefreshToken stores a new refresh token of the session and returns it with its id and expiry
+func (u *AuthUsecase) issueRefreshToken(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID int64,
+	familyID string,
+	client model.ClientInfo,
+	now time.Time,
+) (string, int64, time.Time, error) {
+	token, err := randomToken(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+	expiresAt := now.Add(u.settings.RefreshTokenTTL)
+	id, err := u.refreshTokenRepo.Create(ctx, tx, &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+		UserAgent: truncate(client.UserAgent, 255),
+		IPAddress: truncate(client.IPAddress, 45),
+	})
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+	return token, id, expiresAt, nil
+}
+
+func (u *AuthUsecase) tokenPair(user *model.User, familyID, refreshToken string, refreshExpiresAt, now time.Time) (*model.TokenPair, error) {
+	claims := AccessClaims{
+		SessionID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    u.settings.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(u.settings.AccessTokenTTL)),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(u.settings.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(u.settings.AccessTokenTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// randomToken returns size random bytes encoded with encode
+func randomToken(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return encode(b), nil
+}
+
+// hashToken is how refresh tokens are stored, they are random enough not to need a salt
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
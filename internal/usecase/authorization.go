@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"context"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+)
+
+// Requests reaching the usecases went through middleware.Permit, so the principal has the permission
+// in at least one store. These helpers narrow it down to the stores. Without principal (calls made by
+// the server itself) every store is allowed
+
+// authorizeStore checks the principal has the permission in the store, storeID 0 (orders without store)
+// needs a role for every store
+func authorizeStore(ctx context.Context, permission model.Permission, storeID int64) error {
+	principal := model.PrincipalFromContext(ctx)
+	if principal == nil || principal.CanInStore(permission, storeID) {
+		return nil
+	}
+	if storeID == 0 {
+		return apperror.Forbidden("%s permission in every retail store is required", permission)
+	}
+	return apperror.Forbidden("%s permission in retail store %d is required", permission, storeID)
+}
+
+// storeScope returns the stores in which the principal has the permission, nil when it is all of them
+func storeScope(ctx context.Context, permission model.Permission) []int64 {
+	principal := model.PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil
+	}
+	storeIDs, all := principal.StoreScope(permission)
+	if all {
+		return nil
+	}
+	return storeIDs
+}
+
+// requireSession rejects the requests authenticated with an API key, for what only a logged in user may do
+func requireSession(ctx context.Context) error {
+	if principal := model.PrincipalFromContext(ctx); principal != nil && principal.APIKeyID != 0 {
+		return apperror.Forbidden("api keys can't be used for this, log in instead")
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/internal/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+type CashShiftUsecase struct {
+	shiftRepo       *repository.CashShiftRepository
+	retailStoreRepo *repository.RetailStoreRepository
+	userRepo        *repository.UserRepository
+	platformRepo    *repository.PlatformRepository
+	paymentRepo     *repository.PaymentMethodsRepository
+}
+
+func NewCashShiftUsecase(
+	shiftRepo *repository.CashShiftRepository,
+	retailStoreRepo *repository.RetailStoreRepository,
+	userRepo *repository.UserRepository,
+	platformRepo *repository.PlatformRepository,
+	paymentRepo *repository.PaymentMethodsRepository,
+) *CashShiftUsecase {
+	return &CashShiftUsecase{
+		shiftRepo:       shiftRepo,
+		retailStoreRepo: retailStoreRepo,
+		userRepo:        userRepo,
+		platformRepo:    platformRepo,
+		paymentRepo:     paymentRepo,
+	}
+}
+
+// Open starts a shift on the store drawer, a store has at most one open shift
+func (u *CashShiftUsecase) Open(ctx context.Context, req *model.OpenCashShiftRequest) (*model.CashShift, error) {
+	if err := authorizeStore(ctx, model.PermissionShiftsWrite, req.RetailStoreID); err != nil {
+		return nil, err
+	}
+	retailStore, err := u.retailStoreRepo.GetByID(ctx, req.RetailStoreID)
+	if err != nil {
+		return nil, err
+	}
+	if !retailStore.IsActive {
+		return nil, apperror.Conflict("retail store %s is inactive", retailStore.Name)
+	}
+	if err := u.ensureCashAccepted(ctx, retailStore); err != nil {
+		return nil, err
+	}
+
+	if _, err := u.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	storeIDs, err := u.retailStoreRepo.GetStoreIDsByUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(storeIDs, retailStore.ID) {
+		return nil, apperror.InvalidField("user_id", "store_user", "user %d is not assigned to %s", req.UserID, retailStore.Name)
+	}
+
+	openShift, err := u.shiftRepo.GetOpenByStore(ctx, retailStore.ID)
+	if err != nil {
+		return nil, err
+	}
+	if openShift != nil {
+		return nil, apperror.Conflict("shift %d is still open on %s, close it first", openShift.ID, retailStore.Name)
+	}
+
+	shift := &model.CashShift{
+		RetailStoreID: retailStore.ID,
+		UserID:        req.UserID,
+		OpeningFloat:  utils.RoundMoney(req.OpeningFloat),
+		OpeningNote:   strings.TrimSpace(req.Note),
+	}
+	if err := u.shiftRepo.Create(ctx, shift); err != nil {
+		return nil, err
+	}
+	return u.GetByID(ctx, shift.ID)
+}
+
+// GetByID returns the shift with its movements, the amounts of an open shift are computed up to now
+func (u *CashShiftUsecase) GetByID(ctx context.Context, id int64) (*model.CashShift, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	shift, err := u.shiftRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeStore(ctx, model.PermissionShiftsRead, shift.RetailStoreID); err != nil {
+		return nil, err
+	}
+	movements, err := u.shiftRepo.GetMovements(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shift.Movements = movements
+
+	if shift.Status == model.CashShiftStatusOpen {
+		if err := u.computeAmounts(ctx, shift, nil); err != nil {
+			return nil, err
+		}
+	}
+	return shift, nil
+}
+
+// AddMovement records cash put in or taken out of the drawer of an open shift
+func (u *CashShiftUsecase) AddMovement(ctx context.Context, shiftID int64, req *model.CreateCashMovementRequest) (*model.CashShift, error) {
+	shift, err := u.GetByID(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeStore(ctx, model.PermissionShiftsWrite, shift.RetailStoreID); err != nil {
+		return nil, err
+	}
+	if shift.Status != model.CashShiftStatusOpen {
+		return nil, apperror.Conflict("cash shift is closed")
+	}
+
+	amount := utils.RoundMoney(req.Amount)
+	if amount <= 0 {
+		return nil, apperror.InvalidField("amount", "gt", "amount must be greater than 0")
+	}
+	if req.Type == model.CashMovementTypeOut && amount > shift.ExpectedAmount {
+		return nil, apperror.InvalidField("amount", "lte", "only %.2f expected in the drawer, can't take out %.2f", shift.ExpectedAmount, amount)
+	}
+
+	if err := u.shiftRepo.CreateMovement(ctx, &model.CashMovement{
+		ShiftID: shift.ID,
+		Type:    req.Type,
+		Amount:  amount,
+		Reason:  strings.TrimSpace(req.Reason),
+	}); err != nil {
+		return nil, err
+	}
+	return u.GetByID(ctx, shift.ID)
+}
+
+// Close freezes the expected cash of the shift and records the counted amount and the difference
+func (u *CashShiftUsecase) Close(ctx context.Context, shiftID int64, req *model.CloseCashShiftRequest) (*model.CashShift, error) {
+	shift, err := u.GetByID(ctx, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeStore(ctx, model.PermissionShiftsWrite, shift.RetailStoreID); err != nil {
+		return nil, err
+	}
+	if shift.Status != model.CashShiftStatusOpen {
+		return nil, apperror.Conflict("cash shift is already closed")
+	}
+
+	closedAt := time.Now().UTC()
+	if err := u.computeAmounts(ctx, shift, &closedAt); err != nil {
+		return nil, err
+	}
+	counted := utils.RoundMoney(*req.CountedAmount)
+	difference := utils.RoundMoney(counted - shift.ExpectedAmount)
+	shift.CountedAmount = &counted
+	shift.Difference = &difference
+	shift.ClosingNote = strings.TrimSpace(req.Note)
+	shift.ClosedAt = &closedAt
+
+	if err := u.shiftRepo.Close(ctx, shift); err != nil {
+		return nil, err
+	}
+	return u.GetByID(ctx, shift.ID)
+}
+
+// GetReport lists the shifts of a store opened between two dates with their totals
+func (u *CashShiftUsecase) GetReport(ctx context.Context, filter *model.CashShiftReportFilter) (*model.CashShiftReport, error) {
+	if err := authorizeStore(ctx, model.PermissionShiftsRead, filter.RetailStoreID); err != nil {
+		return nil, err
+	}
+	if _, err := u.retailStoreRepo.GetByID(ctx, filter.RetailStoreID); err != nil {
+		return nil, err
+	}
+	from, to, err := parseDateRange(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	shifts, err := u.shiftRepo.GetByStore(ctx, filter.RetailStoreID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.CashShiftReport{
+		RetailStoreID: filter.RetailStoreID,
+		ShiftCount:    len(shifts),
+		Shifts:        shifts,
+	}
+	for _, shift := range shifts {
+		if shift.Status == model.CashShiftStatusOpen {
+			if err := u.computeAmounts(ctx, shift, nil); err != nil {
+				return nil, err
+			}
+			report.OpenShiftCount++
+		}
+		report.OrderCount += shift.OrderCount
+		report.TotalCashSales += shift.CashSales
+		report.TotalCashIn += shift.CashIn
+		report.TotalCashOut += shift.CashOut
+		report.TotalExpected += shift.ExpectedAmount
+		if shift.CountedAmount != nil {
+			report.TotalCounted += *shift.CountedAmount
+		}
+		if shift.Difference != nil {
+			report.TotalDifference += *shift.Difference
+		}
+	}
+	report.TotalCashSales = utils.RoundMoney(report.TotalCashSales)
+	report.TotalCashIn = utils.RoundMoney(report.TotalCashIn)
+	report.TotalCashOut = utils.RoundMoney(report.TotalCashOut)
+	report.TotalExpected = utils.RoundMoney(report.TotalExpected)
+	report.TotalCounted = utils.RoundMoney(report.TotalCounted)
+	report.TotalDifference = utils.RoundMoney(report.TotalDifference)
+	return report, nil
+}
+
+// computeAmounts fills the cash sales, movements and expected cash of a shift up to closedAt (nil for now)
+func (u *CashShiftUsecase) computeAmounts(ctx context.Context, shift *model.CashShift, closedAt *time.Time) error {
+	sales, err := u.shiftRepo.GetCashSales(ctx, shift.RetailStoreID, shift.OpenedAt, closedAt)
+	if err != nil {
+		return err
+	}
+	shift.CashSales = utils.RoundMoney(sales.Amount)
+	shift.OrderCount = sales.OrderCount
+
+	movements := shift.Movements
+	if movements == nil {
+		movements, err = u.shiftRepo.GetMovements(ctx, shift.ID)
+		if err != nil {
+			return err
+		}
+	}
+	shift.CashIn, shift.CashOut = 0, 0
+	for _, movement := range movements {
+		switch movement.Type {
+		case model.CashMovementTypeIn:
+			shift.CashIn += movement.Amount
+		case model.CashMovementTypeOut:
+			shift.CashOut += movement.Amount
+		}
+	}
+	shift.CashIn = utils.RoundMoney(shift.CashIn)
+	shift.CashOut = utils.RoundMoney(shift.CashOut)
+	shift.ComputeExpected()
+	shift.ExpectedAmount = utils.RoundMoney(shift.ExpectedAmount)
+	return nil
+}
+
+// ensureCashAccepted checks the Cash payment method can be used in the store
+func (u *CashShiftUsecase) ensureCashAccepted(ctx context.Context, retailStore *model.RetailStore) error {
+	cash, err := u.paymentRepo.GetByCode(ctx, model.PaymentMethodCodeCash)
+	if err != nil {
+		return err
+	}
+	platform, err := u.platformRepo.GetByName(ctx, model.PlatformOffline)
+	if err != nil {
+		return err
+	}
+	if !cash.IsActive || !cash.AllowedFor(platform.ID, retailStore.ID) {
+		return apperror.Validation("%s doesn't accept cash payments", retailStore.Name)
+	}
+	return nil
+}
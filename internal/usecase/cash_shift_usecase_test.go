@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"simple-template/internal/channel"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"testing"
+)
+
+func TestCashSalesCountOrdersPaidThroughTheirStatus(t *testing.T) {
+	// The sync fixture brings a store and a product in stock
+	f := newSyncFixture(t, channel.PlatformShopee)
+	ctx := context.Background()
+	storeID := f.request.RetailStoreID
+
+	result, err := f.db.SQL.Exec("INSERT INTO users (name, email) VALUES (?, ?)", "Cashier "+f.suffix, "cashier-"+f.suffix+"@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, _ := result.LastInsertId()
+	if _, err := f.db.SQL.Exec("INSERT INTO store_users (store_id, user_id) VALUES (?, ?)", storeID, userID); err != nil {
+		t.Fatal(err)
+	}
+
+	platformRepo := repository.NewPlatformRepository(f.db)
+	paymentRepo := repository.NewPaymentMethodsRepository(f.db)
+	shifts := NewCashShiftUsecase(
+		repository.NewCashShiftRepository(f.db),
+		repository.NewRetailStoreRepository(f.db),
+		repository.NewUserRepository(f.db),
+		platformRepo,
+		paymentRepo,
+	)
+	shift, err := shifts.Open(ctx, &model.OpenCashShiftRequest{RetailStoreID: storeID, UserID: userID, OpeningFloat: 500000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offline, err := platformRepo.GetByName(ctx, model.PlatformOffline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cash, err := paymentRepo.GetByCode(ctx, model.PaymentMethodCodeCash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	customer, err := f.usecase.customerUsecase.CreateCustomer(ctx, &model.CreateCustomerRequest{
+		FirstName:   "Nguyen",
+		PhoneNumber: f.phone(2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var priceID int64
+	if err := f.db.SQL.QueryRow("SELECT p.id FROM price p JOIN product_variant_value v ON v.attribute_id = p.variant_id WHERE v.id = ?",
+		f.variant).Scan(&priceID); err != nil {
+		t.Fatal(err)
+	}
+
+	orders := f.usecase.orderUsecase
+	order, err := orders.CreateOrders(ctx, &model.CreateOrders{
+		CustomerID:    customer.ID,
+		PlatformID:    offline.ID,
+		RetailStoreID: storeID,
+		PaymentID:     cash.ID,
+		Items:         []model.CreateOrderItems{{Quantity: 2, ProductVariantID: f.variant, PriceID: priceID}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shift, err = shifts.GetByID(ctx, shift.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shift.CashSales != 0 || shift.ExpectedAmount != 500000 {
+		t.Fatalf("got cash sales %v and expected %v before payment, want 0 and 500000", shift.CashSales, shift.ExpectedAmount)
+	}
+
+	if err := orders.UpdateOrderStatus(ctx, int8(model.OrderStatusPaid), order.ID); err != nil {
+		t.Fatal(err)
+	}
+	shift, err = shifts.GetByID(ctx, shift.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shift.CashSales != order.TotalAmount || shift.OrderCount != 1 {
+		t.Errorf("got cash sales %v of %d orders, want %v of 1", shift.CashSales, shift.OrderCount, order.TotalAmount)
+	}
+	if want := 500000 + order.TotalAmount; shift.ExpectedAmount != want {
+		t.Errorf("got expected cash %v, want %v", shift.ExpectedAmount, want)
+	}
+}
@@ -0,0 +1,513 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"simple-template/internal/apperror"
+	"simple-template/internal/channel"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"strings"
+	"time"
+)
+
+// defaultOrderLookback is used for the first order sync of a platform
+const defaultOrderLookback = 24 * time.Hour
+
+// syncRunHistoryLimit is the number of sync runs returned by GetSyncRuns
+const syncRunHistoryLimit = 50
+
+// ChannelSyncUsecase synchronizes orders, stock and prices with the marketplaces
+type ChannelSyncUsecase struct {
+	channelRepo     *repository.ChannelRepository
+	platformRepo    *repository.PlatformRepository
+	customerRepo    *repository.CustomerRepository
+	customerUsecase *CustomerUsecase
+	orderUsecase    *OrderUsecase
+	registry        *channel.Registry
+	settings        channel.Settings
+}
+
+func NewChannelSyncUsecase(
+	channelRepo *repository.ChannelRepository,
+	platformRepo *repository.PlatformRepository,
+	customerRepo *repository.CustomerRepository,
+	customerUsecase *CustomerUsecase,
+	orderUsecase *OrderUsecase,
+	registry *channel.Registry,
+	settings channel.Settings,
+) *ChannelSyncUsecase {
+	return &ChannelSyncUsecase{
+		channelRepo:     channelRepo,
+		platformRepo:    platformRepo,
+		customerRepo:    customerRepo,
+		customerUsecase: customerUsecase,
+		orderUsecase:    orderUsecase,
+		registry:        registry,
+		settings:        settings,
+	}
+}
+
+// SyncOrders pulls the marketplace orders and imports the new ones through OrderUsecase.CreateImportedOrder
+// One failing order doesn't stop the others, failures are reported in the result and the run is partial:
+// the next sync resumes from the oldest failed order so it is pulled and imported again
+func (u *ChannelSyncUsecase) SyncOrders(ctx context.Context, platformID int64, req *model.SyncOrdersRequest) (*model.SyncOrdersResult, error) {
+	platform, adapter, err := u.adapterFor(ctx, platformID, model.PlatformFeatureOrderSync)
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := u.orderSyncSince(ctx, platform.ID, req.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := u.startRun(ctx, platform.ID, model.ChannelSyncKindOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	externalOrders, err := adapter.PullOrders(ctx, since)
+	if err != nil {
+		u.finishRun(ctx, run, err)
+		return nil, apperror.Upstream(err, "failed to pull orders from %s", platform.Name)
+	}
+
+	var externalIDs []string
+	for _, externalOrder := range externalOrders {
+		externalIDs = append(externalIDs, externalOrder.ExternalID)
+	}
+	imported, err := u.channelRepo.GetImportedExternalIDs(ctx, platform.ID, externalIDs)
+	if err != nil {
+		u.finishRun(ctx, run, err)
+		return nil, err
+	}
+
+	// Without failures the next sync starts where this one started
+	resumeFrom := run.StartedAt
+	result := &model.SyncOrdersResult{
+		RunID:    run.ID,
+		OrderIDs: []int64{},
+		Failures: []model.SyncOrderFailure{},
+	}
+	for _, externalOrder := range externalOrders {
+		// Already imported or canceled before we saw it
+		if imported[externalOrder.ExternalID] || externalOrder.Status == channel.ExternalStatusCanceled {
+			result.Skipped++
+			continue
+		}
+
+		order, err := u.importOrder(ctx, platform, req, externalOrder)
+		if err != nil {
+			result.Failures = append(result.Failures, model.SyncOrderFailure{
+				ExternalOrderID: externalOrder.ExternalID,
+				Reason:          err.Error(),
+			})
+			// An order without its creation time is pulled again from where this run started
+			failedAt := externalOrder.CreatedAt
+			if failedAt.IsZero() {
+				failedAt = since
+			}
+			if failedAt.Before(resumeFrom) {
+				resumeFrom = failedAt
+			}
+			continue
+		}
+		imported[externalOrder.ExternalID] = true
+		result.Imported++
+		result.OrderIDs = append(result.OrderIDs, order.ID)
+	}
+
+	run.Processed = result.Imported
+	run.Skipped = result.Skipped
+	run.Failed = len(result.Failures)
+	run.ResumeFrom = &resumeFrom
+	u.finishRun(ctx, run, nil)
+
+	return result, nil
+}
+
+// importOrder maps the marketplace SKUs to our variant values and creates the order
+func (u *ChannelSyncUsecase) importOrder(
+	ctx context.Context,
+	platform *model.Platform,
+	req *model.SyncOrdersRequest,
+	externalOrder channel.ExternalOrder,
+) (*model.Orders, error) {
+	if len(externalOrder.Items) == 0 {
+		return nil, apperror.Validation("order has no items")
+	}
+
+	var skus []string
+	for _, item := range externalOrder.Items {
+		skus = append(skus, item.SKU)
+	}
+	listings, err := u.channelRepo.GetListings(ctx, platform.ID, skus)
+	if err != nil {
+		return nil, err
+	}
+	listingBySKU := make(map[string]*model.ChannelListing, len(listings))
+	for _, listing := range listings {
+		listingBySKU[listing.ExternalSKU] = listing
+	}
+
+	// Several marketplace lines can point to the same variant value
+	var items []model.CreateOrderItems
+	itemIndex := make(map[int64]int)
+	for _, item := range externalOrder.Items {
+		listing, exists := listingBySKU[item.SKU]
+		if !exists {
+			return nil, apperror.Validation("sku %s is not mapped to a variant value", item.SKU)
+		}
+		if listing.PriceID == 0 {
+			return nil, apperror.Validation("sku %s has no active price", item.SKU)
+		}
+		if index, seen := itemIndex[listing.VariantValueID]; seen {
+			items[index].Quantity += item.Quantity
+			continue
+		}
+		itemIndex[listing.VariantValueID] = len(items)
+		items = append(items, model.CreateOrderItems{
+			Quantity:         item.Quantity,
+			ProductVariantID: listing.VariantValueID,
+			PriceID:          listing.PriceID,
+		})
+	}
+
+	customer, err := u.resolveCustomer(ctx, externalOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	createOrder := &model.CreateOrders{
+		CustomerID:    customer.ID,
+		PlatformID:    platform.ID,
+		RetailStoreID: req.RetailStoreID,
+		PaymentID:     req.PaymentID,
+		Items:         items,
+	}
+	// Marketplaces send a single line address, it's kept as is on the order
+	if street := strings.TrimSpace(externalOrder.ShippingAddress); street != "" {
+		createOrder.ShippingAddress = &model.AddressFields{
+			RecipientName: strings.TrimSpace(externalOrder.BuyerName),
+			PhoneNumber:   normalizeMarketplacePhone(externalOrder.BuyerPhone),
+			Street:        street,
+		}
+	}
+
+	// The link is written with the order, an order is never left unlinked and imported again by the next sync
+	status := importedOrderStatus(externalOrder.Status)
+	return u.orderUsecase.CreateImportedOrder(ctx, createOrder, status, func(ctx context.Context, tx *sql.Tx, order *model.Orders) error {
+		return u.channelRepo.CreateChannelOrder(ctx, tx, &model.ChannelOrder{
+			PlatformID:      platform.ID,
+			ExternalOrderID: externalOrder.ExternalID,
+			OrderID:         order.ID,
+			ExternalStatus:  externalOrder.Status,
+		})
+	})
+}
+
+// resolveCustomer finds the buyer by phone, then by email, or creates a new customer
+func (u *ChannelSyncUsecase) resolveCustomer(ctx context.Context, externalOrder channel.ExternalOrder) (*model.Customer, error) {
+	phone := normalizeMarketplacePhone(externalOrder.BuyerPhone)
+	email := strings.ToLower(strings.TrimSpace(externalOrder.BuyerEmail))
+
+	if phone != "" {
+		customer, err := u.customerRepo.FindByPhone(ctx, phone)
+		if err != nil {
+			return nil, err
+		}
+		if customer != nil {
+			return customer, nil
+		}
+	}
+	// The buyer may have given us another phone number, or the marketplace masked it
+	if email != "" {
+		customer, err := u.customerRepo.FindByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if customer != nil {
+			return customer, nil
+		}
+	}
+
+	if phone == "" {
+		return nil, apperror.Validation("buyer has no usable phone number")
+	}
+
+	firstName, lastName := splitBuyerName(externalOrder.BuyerName)
+	return u.customerUsecase.CreateCustomer(ctx, &model.CreateCustomerRequest{
+		FirstName:   firstName,
+		LastName:    lastName,
+		Address:     externalOrder.ShippingAddress,
+		Email:       email,
+		PhoneNumber: phone,
+	})
+}
+
+// PushStock sends the stock of every mapped SKU to the marketplace
+func (u *ChannelSyncUsecase) PushStock(ctx context.Context, platformID int64) (*model.SyncPushResult, error) {
+	platform, adapter, err := u.adapterFor(ctx, platformID, model.PlatformFeatureStockSync)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := u.channelRepo.GetListings(ctx, platform.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := u.startRun(ctx, platform.ID, model.ChannelSyncKindStock)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []channel.StockUpdate
+	for _, listing := range listings {
+		updates = append(updates, channel.StockUpdate{
+			SKU:               listing.ExternalSKU,
+			ExternalProductID: listing.ExternalProductID,
+			ExternalVariantID: listing.ExternalVariantID,
+			Quantity:          listing.StockQuantity,
+		})
+	}
+
+	if err := adapter.PushStock(ctx, updates); err != nil {
+		u.finishRun(ctx, run, err)
+		return nil, apperror.Upstream(err, "failed to push stock to %s", platform.Name)
+	}
+
+	run.Processed = len(updates)
+	u.finishRun(ctx, run, nil)
+	return &model.SyncPushResult{RunID: run.ID, Pushed: len(updates)}, nil
+}
+
+// PushPrices sends the active price of every mapped SKU to the marketplace
+func (u *ChannelSyncUsecase) PushPrices(ctx context.Context, platformID int64) (*model.SyncPushResult, error) {
+	platform, adapter, err := u.adapterFor(ctx, platformID, model.PlatformFeaturePriceSync)
+	if err != nil {
+		return nil, err
+	}
+
+	listings, err := u.channelRepo.GetListings(ctx, platform.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := u.startRun(ctx, platform.ID, model.ChannelSyncKindPrices)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []channel.PriceUpdate
+	skipped := 0
+	for _, listing := range listings {
+		// Never push a zero price for a SKU without an active price
+		if listing.PriceID == 0 {
+			skipped++
+			continue
+		}
+		updates = append(updates, channel.PriceUpdate{
+			SKU:               listing.ExternalSKU,
+			ExternalProductID: listing.ExternalProductID,
+			ExternalVariantID: listing.ExternalVariantID,
+			Price:             listing.Price,
+		})
+	}
+
+	if err := adapter.PushPrices(ctx, updates); err != nil {
+		u.finishRun(ctx, run, err)
+		return nil, apperror.Upstream(err, "failed to push prices to %s", platform.Name)
+	}
+
+	run.Processed = len(updates)
+	run.Skipped = skipped
+	u.finishRun(ctx, run, nil)
+	return &model.SyncPushResult{RunID: run.ID, Pushed: len(updates)}, nil
+}
+
+// AcknowledgeShipment tells the marketplace an imported order has been shipped
+func (u *ChannelSyncUsecase) AcknowledgeShipment(ctx context.Context, orderID int64, req *model.AcknowledgeShipmentRequest) (*model.ChannelOrder, error) {
+	if orderID <= 0 {
+		return nil, apperror.Validation("invalid order id")
+	}
+	trackingNumber := strings.TrimSpace(req.TrackingNumber)
+	if trackingNumber == "" {
+		return nil, apperror.InvalidField("tracking_number", "required", "tracking number is required")
+	}
+
+	channelOrder, err := u.channelRepo.GetChannelOrderByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if channelOrder.ShippedAt != nil {
+		return nil, apperror.Conflict("shipment already acknowledged")
+	}
+
+	platform, adapter, err := u.adapterFor(ctx, channelOrder.PlatformID, model.PlatformFeatureShipmentSync)
+	if err != nil {
+		return nil, err
+	}
+
+	carrier := strings.TrimSpace(req.Carrier)
+	if err := adapter.AcknowledgeShipment(ctx, channel.Shipment{
+		ExternalOrderID: channelOrder.ExternalOrderID,
+		TrackingNumber:  trackingNumber,
+		Carrier:         carrier,
+	}); err != nil {
+		return nil, apperror.Upstream(err, "failed to acknowledge shipment on %s", platform.Name)
+	}
+
+	if err := u.channelRepo.MarkShipped(ctx, channelOrder.ID, trackingNumber, carrier); err != nil {
+		return nil, err
+	}
+	return u.channelRepo.GetChannelOrderByOrderID(ctx, orderID)
+}
+
+func (u *ChannelSyncUsecase) CreateSkuMapping(ctx context.Context, platformID int64, req *model.CreateChannelSkuMappingRequest) (*model.ChannelSkuMapping, error) {
+	if _, err := u.platformRepo.GetByID(ctx, platformID); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.ExternalSKU) == "" {
+		return nil, apperror.InvalidField("external_sku", "required", "external sku is required")
+	}
+	if req.VariantValueID <= 0 {
+		return nil, apperror.InvalidField("variant_value_id", "gt", "invalid variant value id")
+	}
+
+	mapping := &model.ChannelSkuMapping{
+		PlatformID:        platformID,
+		ExternalSKU:       strings.TrimSpace(req.ExternalSKU),
+		ExternalProductID: strings.TrimSpace(req.ExternalProductID),
+		ExternalVariantID: strings.TrimSpace(req.ExternalVariantID),
+		VariantValueID:    req.VariantValueID,
+	}
+	if err := u.channelRepo.CreateSkuMapping(ctx, mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (u *ChannelSyncUsecase) GetListings(ctx context.Context, platformID int64) ([]*model.ChannelListing, error) {
+	return u.channelRepo.GetListings(ctx, platformID, nil)
+}
+
+func (u *ChannelSyncUsecase) DeleteSkuMapping(ctx context.Context, platformID, id int64) error {
+	if id <= 0 {
+		return apperror.Validation("invalid id")
+	}
+	return u.channelRepo.DeleteSkuMapping(ctx, platformID, id)
+}
+
+func (u *ChannelSyncUsecase) GetSyncRuns(ctx context.Context, platformID int64) ([]*model.ChannelSyncRun, error) {
+	return u.channelRepo.GetSyncRuns(ctx, platformID, syncRunHistoryLimit)
+}
+
+// adapterFor loads the platform, checks the feature is enabled and builds its adapter from the registry
+func (u *ChannelSyncUsecase) adapterFor(ctx context.Context, platformID int64, feature string) (*model.Platform, channel.Adapter, error) {
+	if platformID <= 0 {
+		return nil, nil, apperror.Validation("invalid platform id")
+	}
+	platform, err := u.platformRepo.GetByID(ctx, platformID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !platform.Config.HasFeature(feature) {
+		return nil, nil, apperror.Conflict("%s is not enabled for platform %s", feature, platform.Name)
+	}
+	if platform.Config.CredentialsRef == "" {
+		return nil, nil, apperror.Conflict("platform %s has no credentials reference", platform.Name)
+	}
+
+	adapter, err := u.registry.New(platform.Name, u.settings.ConfigFor(platform.Config.CredentialsRef, platform.ApiEndpoint))
+	if err != nil {
+		return nil, nil, err
+	}
+	return platform, adapter, nil
+}
+
+// orderSyncSince picks where the order sync starts, failed runs are ignored as nothing was imported
+func (u *ChannelSyncUsecase) orderSyncSince(ctx context.Context, platformID int64, requested *time.Time) (time.Time, error) {
+	if requested != nil {
+		return *requested, nil
+	}
+	lastRun, err := u.channelRepo.GetLastFinishedRun(ctx, platformID, model.ChannelSyncKindOrders)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if lastRun != nil && lastRun.ResumeFrom != nil {
+		return *lastRun.ResumeFrom, nil
+	}
+	// Runs recorded before resume_from existed only finished as succeeded
+	if lastRun != nil {
+		return lastRun.StartedAt, nil
+	}
+	return time.Now().Add(-defaultOrderLookback), nil
+}
+
+func (u *ChannelSyncUsecase) startRun(ctx context.Context, platformID int64, kind string) (*model.ChannelSyncRun, error) {
+	run := &model.ChannelSyncRun{
+		PlatformID: platformID,
+		Kind:       kind,
+		Status:     model.ChannelSyncStatusRunning,
+		StartedAt:  time.Now(),
+	}
+	if err := u.channelRepo.CreateSyncRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// finishRun records the outcome of a run, a failure to record it must not hide the sync result
+// A run that finished with failed items is partial
+func (u *ChannelSyncUsecase) finishRun(ctx context.Context, run *model.ChannelSyncRun, runErr error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	switch {
+	case runErr != nil:
+		run.Status = model.ChannelSyncStatusFailed
+		run.ErrorMessage = runErr.Error()
+	case run.Failed > 0:
+		run.Status = model.ChannelSyncStatusPartial
+	default:
+		run.Status = model.ChannelSyncStatusSucceeded
+	}
+	_ = u.channelRepo.FinishSyncRun(ctx, run)
+}
+
+// importedOrderStatus maps the marketplace status of an order to the status it is imported with
+func importedOrderStatus(externalStatus string) model.OrderStatusItem {
+	switch externalStatus {
+	case channel.ExternalStatusPaid:
+		return model.OrderStatusPaid
+	case channel.ExternalStatusShipped:
+		return model.OrderStatusShipped
+	case channel.ExternalStatusCompleted:
+		return model.OrderStatusCompleted
+	default:
+		return model.OrderStatusPending
+	}
+}
+
+// normalizeMarketplacePhone formats a buyer phone as E.164 like customer phone numbers
+// Returns an empty string when the number is masked or can't identify a customer
+func normalizeMarketplacePhone(phone string) string {
+	if strings.Contains(phone, "*") {
+		return ""
+	}
+	normalized, err := normalizePhone(phone)
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
+
+// splitBuyerName splits "Nguyen Van A" into first name "Nguyen" and last name "Van A"
+func splitBuyerName(name string) (string, string) {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "Marketplace buyer", ""
+	}
+	return parts[0], strings.Join(parts[1:], " ")
+}
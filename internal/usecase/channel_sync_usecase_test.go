@@ -0,0 +1,394 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"simple-template/internal/apperror"
+	"simple-template/internal/channel"
+	"simple-template/internal/channel/channeltest"
+	"simple-template/internal/database"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// testDatabaseDSNEnv names the DSN of a migrated database the tests may write to,
+// e.g. root:secret@tcp(localhost:3306)/simple_golang_db?parseTime=true
+const testDatabaseDSNEnv = "TEST_DATABASE_DSN"
+
+// testDB connects to the database of TEST_DATABASE_DSN, the test is skipped without it
+func testDB(t *testing.T) *database.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseDSNEnv)
+	}
+	sqlDB, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := sqlDB.Ping(); err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	return &database.DB{SQL: sqlDB, Dialect: goqu.Dialect("mysql")}
+}
+
+// syncFixture is a marketplace stand-in with its own platform, store, payment method and mapped SKU,
+// names carry a unique suffix so runs don't collide on the shared test database
+type syncFixture struct {
+	db       *database.DB
+	usecase  *ChannelSyncUsecase
+	market   *channeltest.Marketplace
+	platform int64
+	request  *model.SyncOrdersRequest
+	suffix   string
+	sku      string
+	variant  int64
+}
+
+func newSyncFixture(t *testing.T, platform string) *syncFixture {
+	t.Helper()
+	db := testDB(t)
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	market, err := channeltest.New(platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := market.Start()
+	t.Cleanup(server.Close)
+
+	exec := func(query string, args ...interface{}) int64 {
+		t.Helper()
+		result, err := db.SQL.ExecContext(ctx, query, args...)
+		if err != nil {
+			t.Fatalf("failed to insert fixture: %v", err)
+		}
+		id, _ := result.LastInsertId()
+		return id
+	}
+	categoryID := exec("INSERT INTO category (name) VALUES (?)", "Sync test "+suffix)
+	productID := exec("INSERT INTO product (name, category_id, status, sku) VALUES (?, ?, 1, ?)",
+		"Sync test "+suffix, categoryID, "SYNC-"+suffix)
+	variantID := exec("INSERT INTO product_variant (name, display_name, product_id) VALUES ('size', 'Size', ?)", productID)
+	variantValueID := exec("INSERT INTO product_variant_value (attribute_id, value, stock_quantity) VALUES (?, 'M', 1000)", variantID)
+	exec("INSERT INTO price (variant_id, price, cost_price, status) VALUES (?, 100000, 60000, 1)", variantID)
+	storeID := exec("INSERT INTO retail_stores (name) VALUES (?)", "Sync test "+suffix)
+	paymentID := exec("INSERT INTO payment_methods (name, code) VALUES (?, ?)", "Sync test "+suffix, "S"+suffix)
+
+	// The platform gets its own name in the registry, the seeded marketplaces stay untouched
+	platformName := "sync test " + suffix
+	platformID := exec("INSERT INTO platform (name, api_endpoint, feature_struct) VALUES (?, ?, ?)",
+		platformName, server.URL,
+		`{"commission_rate": 0, "credentials_ref": "TEST", "sync_intervals": {"orders": 0, "stock": 0, "prices": 0}, "features": ["order_sync"]}`)
+	sku := "SKU-" + suffix
+	exec("INSERT INTO channel_sku_mappings (platform_id, external_sku, variant_value_id) VALUES (?, ?, ?)",
+		platformID, sku, variantValueID)
+
+	registry := channel.NewRegistry()
+	switch channel.NormalizeName(platform) {
+	case channel.PlatformShopee:
+		registry.Register(platformName, channel.NewShopeeAdapter)
+	case channel.PlatformLazada:
+		registry.Register(platformName, channel.NewLazadaAdapter)
+	case channel.PlatformTikTokShop:
+		registry.Register(platformName, channel.NewTikTokAdapter)
+	}
+	settings := channel.Settings{Credentials: map[string]channel.Credentials{
+		"TEST": {AppKey: "1001", AppSecret: "secret", AccessToken: "token", ShopID: "2002"},
+	}}
+
+	channelRepo := repository.NewChannelRepository(db)
+	platformRepo := repository.NewPlatformRepository(db)
+	customerRepo := repository.NewCustomerRepository(db)
+	ordersRepo := repository.NewOrdersRepository(db)
+	addressRepo := repository.NewCustomerAddressRepository(db)
+	auditUsecase := NewAuditUsecase(repository.NewAuditRepository(db))
+	loyaltyUsecase := NewLoyaltyUsecase(repository.NewLoyaltyRepository(db), ordersRepo, customerRepo)
+	customerUsecase := NewCustomerUsecase(
+		customerRepo,
+		ordersRepo,
+		addressRepo,
+		repository.NewTagRepository(db),
+		repository.NewCustomerNoteRepository(db),
+		loyaltyUsecase,
+		auditUsecase,
+	)
+	orderUsecase := NewOrderUseCase(
+		ordersRepo,
+		platformRepo,
+		repository.NewRetailStoreRepository(db),
+		repository.NewPaymentMethodsRepository(db),
+		addressRepo,
+		loyaltyUsecase,
+		auditUsecase,
+	)
+
+	since := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	return &syncFixture{
+		db:       db,
+		usecase:  NewChannelSyncUsecase(channelRepo, platformRepo, customerRepo, customerUsecase, orderUsecase, registry, settings),
+		market:   market,
+		platform: platformID,
+		request:  &model.SyncOrdersRequest{RetailStoreID: storeID, PaymentID: paymentID, Since: &since},
+		suffix:   suffix,
+		sku:      sku,
+		variant:  variantValueID,
+	}
+}
+
+// phone returns a Vietnamese mobile number unique to the fixture
+func (f *syncFixture) phone(n int) string {
+	id, _ := strconv.ParseInt(f.suffix, 36, 64)
+	return fmt.Sprintf("09%08d", (id/1000+int64(n))%100000000)
+}
+
+// orders returns n paid orders of the mapped SKU created a minute apart, with numeric ids as Lazada needs
+func (f *syncFixture) orders(n int) []channel.ExternalOrder {
+	start := f.request.Since.Add(time.Hour)
+	orders := make([]channel.ExternalOrder, n)
+	for i := range orders {
+		orders[i] = channel.ExternalOrder{
+			ExternalID:      strconv.Itoa(700000 + i),
+			Status:          channel.ExternalStatusPaid,
+			BuyerName:       "Nguyen Van A",
+			BuyerPhone:      f.phone(0),
+			ShippingAddress: "1 Le Loi, District 1",
+			CreatedAt:       start.Add(time.Duration(i) * time.Minute),
+			Items:           []channel.ExternalOrderItem{{SKU: f.sku, Quantity: 1, UnitPrice: 100000}},
+		}
+	}
+	return orders
+}
+
+// importedCount is the number of orders linked to the platform
+func (f *syncFixture) importedCount(t *testing.T) int {
+	t.Helper()
+	var count int
+	err := f.db.SQL.QueryRow("SELECT COUNT(*) FROM channel_orders WHERE platform_id = ?", f.platform).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// orderCount is the number of orders of the platform, linked or not
+func (f *syncFixture) orderCount(t *testing.T) int {
+	t.Helper()
+	var count int
+	err := f.db.SQL.QueryRow("SELECT COUNT(*) FROM orders WHERE platform_id = ?", f.platform).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestSyncOrdersImportsEveryPage(t *testing.T) {
+	// Shopee lists 50 orders per page
+	f := newSyncFixture(t, channel.PlatformShopee)
+	f.market.AddOrders(f.orders(55)...)
+
+	result, err := f.usecase.SyncOrders(context.Background(), f.platform, f.request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 55 || result.Skipped != 0 || len(result.Failures) != 0 {
+		t.Fatalf("got %d imported, %d skipped, failures %+v, want 55 imported", result.Imported, result.Skipped, result.Failures)
+	}
+	if count := f.importedCount(t); count != 55 {
+		t.Errorf("got %d linked orders, want 55", count)
+	}
+
+	var stock int
+	if err := f.db.SQL.QueryRow("SELECT stock_quantity FROM product_variant_value WHERE id = ?", f.variant).Scan(&stock); err != nil {
+		t.Fatal(err)
+	}
+	if stock != 1000-55 {
+		t.Errorf("got stock %d, want %d", stock, 1000-55)
+	}
+}
+
+func TestSyncOrdersSkipsImportedOrders(t *testing.T) {
+	f := newSyncFixture(t, channel.PlatformLazada)
+	f.market.AddOrders(f.orders(3)...)
+	ctx := context.Background()
+
+	if _, err := f.usecase.SyncOrders(ctx, f.platform, f.request); err != nil {
+		t.Fatal(err)
+	}
+	result, err := f.usecase.SyncOrders(ctx, f.platform, f.request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 0 || result.Skipped != 3 || len(result.Failures) != 0 {
+		t.Fatalf("got %d imported, %d skipped, failures %+v, want 3 skipped", result.Imported, result.Skipped, result.Failures)
+	}
+	if count := f.orderCount(t); count != 3 {
+		t.Errorf("got %d orders, want 3", count)
+	}
+}
+
+func TestImportOrderTwiceCreatesOneOrder(t *testing.T) {
+	// Two syncs running at once both miss the order in GetImportedExternalIDs
+	f := newSyncFixture(t, channel.PlatformShopee)
+	ctx := context.Background()
+	platform, err := repository.NewPlatformRepository(f.db).GetByID(ctx, f.platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := f.orders(1)[0]
+
+	if _, err := f.usecase.importOrder(ctx, platform, f.request, order); err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.usecase.importOrder(ctx, platform, f.request, order)
+	if apperror.CodeOf(err) != apperror.CodeConflict {
+		t.Fatalf("got error %v, want a conflict", err)
+	}
+	if count := f.orderCount(t); count != 1 {
+		t.Errorf("got %d orders, want 1", count)
+	}
+}
+
+func TestSyncOrdersReportsUnmappedSKU(t *testing.T) {
+	f := newSyncFixture(t, channel.PlatformTikTokShop)
+	orders := f.orders(2)
+	orders[1].Items = append(orders[1].Items, channel.ExternalOrderItem{SKU: "UNMAPPED-" + f.suffix, Quantity: 1, UnitPrice: 1000})
+	f.market.AddOrders(orders...)
+
+	result, err := f.usecase.SyncOrders(context.Background(), f.platform, f.request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || len(result.Failures) != 1 {
+		t.Fatalf("got %d imported, failures %+v, want 1 imported and 1 failure", result.Imported, result.Failures)
+	}
+	failure := result.Failures[0]
+	if failure.ExternalOrderID != orders[1].ExternalID || !strings.Contains(failure.Reason, "UNMAPPED-"+f.suffix) {
+		t.Errorf("got failure %+v, want the unmapped sku of order %s", failure, orders[1].ExternalID)
+	}
+	if count := f.orderCount(t); count != 1 {
+		t.Errorf("got %d orders, want 1", count)
+	}
+}
+
+func TestSyncOrdersMatchesBuyerByEmail(t *testing.T) {
+	// TikTok Shop is the marketplace sending the buyer email
+	f := newSyncFixture(t, channel.PlatformTikTokShop)
+	ctx := context.Background()
+	email := "buyer-" + f.suffix + "@example.com"
+	customer, err := f.usecase.customerUsecase.CreateCustomer(ctx, &model.CreateCustomerRequest{
+		FirstName:   "Nguyen",
+		Email:       email,
+		PhoneNumber: f.phone(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := f.orders(1)[0]
+	order.BuyerEmail = strings.ToUpper(email)
+	f.market.AddOrders(order)
+
+	result, err := f.usecase.SyncOrders(ctx, f.platform, f.request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 {
+		t.Fatalf("got %d imported, failures %+v, want 1 imported", result.Imported, result.Failures)
+	}
+	var customerID int64
+	if err := f.db.SQL.QueryRow("SELECT customer_id FROM orders WHERE id = ?", result.OrderIDs[0]).Scan(&customerID); err != nil {
+		t.Fatal(err)
+	}
+	if customerID != customer.ID {
+		t.Errorf("order of customer %d, want the customer %d of the buyer email", customerID, customer.ID)
+	}
+}
+
+func TestSyncOrdersRetriesFailedOrders(t *testing.T) {
+	f := newSyncFixture(t, channel.PlatformShopee)
+	ctx := context.Background()
+	unmapped := "UNMAPPED-" + f.suffix
+	orders := f.orders(3)
+	orders[1].Items = []channel.ExternalOrderItem{{SKU: unmapped, Quantity: 1, UnitPrice: 100000}}
+	f.market.AddOrders(orders...)
+	// Both syncs start where the previous one stopped
+	request := *f.request
+	request.Since = nil
+
+	result, err := f.usecase.SyncOrders(ctx, f.platform, &request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 || len(result.Failures) != 1 {
+		t.Fatalf("got %d imported, failures %+v, want 2 imported and 1 failure", result.Imported, result.Failures)
+	}
+	runs, err := f.usecase.GetSyncRuns(ctx, f.platform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs[0].Status != model.ChannelSyncStatusPartial {
+		t.Errorf("got run status %s, want %s", runs[0].Status, model.ChannelSyncStatusPartial)
+	}
+
+	_, err = f.db.SQL.Exec("INSERT INTO channel_sku_mappings (platform_id, external_sku, variant_value_id) VALUES (?, ?, ?)",
+		f.platform, unmapped, f.variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = f.usecase.SyncOrders(ctx, f.platform, &request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || len(result.Failures) != 0 {
+		t.Fatalf("got %d imported, failures %+v, want the failed order imported", result.Imported, result.Failures)
+	}
+	if count := f.importedCount(t); count != 3 {
+		t.Errorf("got %d linked orders, want 3", count)
+	}
+}
+
+func TestSyncOrdersImportsMarketplaceStatus(t *testing.T) {
+	f := newSyncFixture(t, channel.PlatformLazada)
+	orders := f.orders(2)
+	orders[0].Status = channel.ExternalStatusPending
+	orders[1].Status = channel.ExternalStatusShipped
+	f.market.AddOrders(orders...)
+
+	result, err := f.usecase.SyncOrders(context.Background(), f.platform, f.request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 2 {
+		t.Fatalf("got %d imported, failures %+v, want 2 imported", result.Imported, result.Failures)
+	}
+
+	want := map[string][2]int8{
+		orders[0].ExternalID: {int8(model.OrderStatusPending), model.PaymentStatusUnpaid},
+		orders[1].ExternalID: {int8(model.OrderStatusShipped), model.PaymentStatusPaid},
+	}
+	for externalID, statuses := range want {
+		var status, paymentStatus int8
+		err := f.db.SQL.QueryRow(`SELECT s.status, o.payment_status FROM channel_orders c
+			JOIN orders o ON o.id = c.order_id
+			JOIN order_status s ON s.order_id = o.id
+			WHERE c.platform_id = ? AND c.external_order_id = ?`, f.platform, externalID).Scan(&status, &paymentStatus)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != statuses[0] || paymentStatus != statuses[1] {
+			t.Errorf("order %s got status %d and payment status %d, want %d and %d",
+				externalID, status, paymentStatus, statuses[0], statuses[1])
+		}
+	}
+}
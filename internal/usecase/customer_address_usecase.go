@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"strings"
+)
+
+type CustomerAddressUsecase struct {
+	addressRepo  *repository.CustomerAddressRepository
+	customerRepo *repository.CustomerRepository
+}
+
+func NewCustomerAddressUsecase(
+	addressRepo *repository.CustomerAddressRepository,
+	customerRepo *repository.CustomerRepository,
+) *CustomerAddressUsecase {
+	return &CustomerAddressUsecase{
+		addressRepo:  addressRepo,
+		customerRepo: customerRepo,
+	}
+}
+
+func (u *CustomerAddressUsecase) GetAll(ctx context.Context, customerID int64) ([]*model.CustomerAddress, error) {
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return u.addressRepo.GetByCustomer(ctx, customerID)
+}
+
+// Create adds an address to the customer, the first one becomes the default shipping and billing address
+func (u *CustomerAddressUsecase) Create(ctx context.Context, customerID int64, req *model.CreateCustomerAddressRequest) (*model.CustomerAddress, error) {
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.AnonymizedAt != nil {
+		return nil, apperror.Conflict("customer %d is anonymized, addresses can't be added", customerID)
+	}
+	existing, err := u.addressRepo.GetByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	address := &model.CustomerAddress{
+		CustomerID:        customerID,
+		Label:             strings.TrimSpace(req.Label),
+		AddressFields:     trimAddressFields(req.AddressFields),
+		IsDefaultShipping: req.IsDefaultShipping || len(existing) == 0,
+		IsDefaultBilling:  req.IsDefaultBilling || len(existing) == 0,
+	}
+	if err := u.save(ctx, address, false); err != nil {
+		return nil, err
+	}
+	return u.addressRepo.GetByID(ctx, customerID, address.ID)
+}
+
+func (u *CustomerAddressUsecase) Update(ctx context.Context, customerID, id int64, req *model.UpdateCustomerAddressRequest) (*model.CustomerAddress, error) {
+	address, err := u.addressRepo.GetByID(ctx, customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		address.Label = *req.Label
+	}
+	if req.RecipientName != nil {
+		address.RecipientName = *req.RecipientName
+	}
+	if req.PhoneNumber != nil {
+		address.PhoneNumber = *req.PhoneNumber
+	}
+	if req.Street != nil {
+		address.Street = *req.Street
+	}
+	if req.Ward != nil {
+		address.Ward = *req.Ward
+	}
+	if req.District != nil {
+		address.District = *req.District
+	}
+	if req.Province != nil {
+		address.Province = *req.Province
+	}
+	if req.PostalCode != nil {
+		address.PostalCode = *req.PostalCode
+	}
+	if req.IsDefaultShipping != nil {
+		address.IsDefaultShipping = *req.IsDefaultShipping
+	}
+	if req.IsDefaultBilling != nil {
+		address.IsDefaultBilling = *req.IsDefaultBilling
+	}
+	address.Label = strings.TrimSpace(address.Label)
+	address.AddressFields = trimAddressFields(address.AddressFields)
+	var missing []apperror.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"recipient_name", address.RecipientName},
+		{"phone_number", address.PhoneNumber},
+		{"street", address.Street},
+	} {
+		if field.value == "" {
+			missing = append(missing, apperror.Field(field.name, "required", "%s is required", field.name))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperror.Invalid(missing...)
+	}
+
+	if err := u.save(ctx, address, true); err != nil {
+		return nil, err
+	}
+	return u.addressRepo.GetByID(ctx, customerID, id)
+}
+
+// Delete removes an address, a default flag it held moves to the most recent remaining address
+// Orders shipped to it keep their snapshot
+func (u *CustomerAddressUsecase) Delete(ctx context.Context, customerID, id int64) error {
+	address, err := u.addressRepo.GetByID(ctx, customerID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := u.addressRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := u.addressRepo.Delete(ctx, tx, customerID, id); err != nil {
+		return err
+	}
+	if address.IsDefaultShipping {
+		if err := u.addressRepo.PromoteDefault(ctx, tx, customerID, repository.AddressDefaultShipping); err != nil {
+			return err
+		}
+	}
+	if address.IsDefaultBilling {
+		if err := u.addressRepo.PromoteDefault(ctx, tx, customerID, repository.AddressDefaultBilling); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// save writes the address and clears the default flags it takes from the other addresses of the customer
+func (u *CustomerAddressUsecase) save(ctx context.Context, address *model.CustomerAddress, exists bool) error {
+	tx, err := u.addressRepo.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if exists {
+		err = u.addressRepo.Update(ctx, tx, address)
+	} else {
+		err = u.addressRepo.Create(ctx, tx, address)
+	}
+	if err != nil {
+		return err
+	}
+	if address.IsDefaultShipping {
+		if err := u.addressRepo.ClearDefault(ctx, tx, address.CustomerID, repository.AddressDefaultShipping, address.ID); err != nil {
+			return err
+		}
+	}
+	if address.IsDefaultBilling {
+		if err := u.addressRepo.ClearDefault(ctx, tx, address.CustomerID, repository.AddressDefaultBilling, address.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func trimAddressFields(fields model.AddressFields) model.AddressFields {
+	return model.AddressFields{
+		RecipientName: strings.TrimSpace(fields.RecipientName),
+		PhoneNumber:   strings.TrimSpace(fields.PhoneNumber),
+		Street:        strings.TrimSpace(fields.Street),
+		Ward:          strings.TrimSpace(fields.Ward),
+		District:      strings.TrimSpace(fields.District),
+		Province:      strings.TrimSpace(fields.Province),
+		PostalCode:    strings.TrimSpace(fields.PostalCode),
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/pkg/pagination"
+	"strings"
+	"time"
+)
+
+type CustomerNoteUsecase struct {
+	noteRepo          *repository.CustomerNoteRepository
+	customerRepo      *repository.CustomerRepository
+	userRepo          *repository.UserRepository
+	paginationService *pagination.Service
+}
+
+func NewCustomerNoteUsecase(
+	noteRepo *repository.CustomerNoteRepository,
+	customerRepo *repository.CustomerRepository,
+	userRepo *repository.UserRepository,
+) *CustomerNoteUsecase {
+	return &CustomerNoteUsecase{
+		noteRepo:          noteRepo,
+		customerRepo:      customerRepo,
+		userRepo:          userRepo,
+		paginationService: pagination.NewService(),
+	}
+}
+
+// GetTimeline lists the notes of the customer page by page, newest first by default
+func (u *CustomerNoteUsecase) GetTimeline(ctx context.Context, customerID int64, req *pagination.Request) (*pagination.Response, error) {
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	u.paginationService.ValidateAndNormalize(req)
+	if req.SortBy != "created_at" && req.SortBy != "id" {
+		return nil, apperror.InvalidField("sort_by", "oneof", "invalid sort_by, expected created_at or id")
+	}
+
+	cursor, effectiveOrder := u.paginationService.GetNavigationParams(*req)
+	fetchLimit := u.paginationService.CalculateFetchLimit(req.Limit)
+	notes, err := u.noteRepo.GetPaginated(ctx, customerID, cursor, fetchLimit, effectiveOrder, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(notes))
+	for i, note := range notes {
+		items[i] = note
+	}
+	response := u.paginationService.BuildResponse(
+		items,
+		req,
+		func(n interface{}) (time.Time, int64) {
+			note := n.(*model.CustomerNote)
+			return note.CreatedAt, note.ID
+		},
+	)
+	return &response, nil
+}
+
+// Create appends a note to the timeline of the customer, signed by the author
+func (u *CustomerNoteUsecase) Create(ctx context.Context, customerID, authorID int64, req *model.CreateCustomerNoteRequest) (*model.CustomerNote, error) {
+	if authorID <= 0 {
+		return nil, apperror.Unauthorized("notes need an authenticated author")
+	}
+	customer, err := u.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.AnonymizedAt != nil {
+		return nil, apperror.Conflict("customer %d is anonymized, notes can't be added", customerID)
+	}
+	author, err := u.userRepo.GetByID(ctx, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("author: %w", err)
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, apperror.InvalidField("body", "required", "body is required")
+	}
+
+	return u.noteRepo.Create(ctx, &model.CustomerNote{
+		CustomerID: customerID,
+		AuthorID:   &author.ID,
+		AuthorName: author.Name,
+		Body:       body,
+	})
+}
@@ -1,20 +1,53 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
+	"simple-template/pkg/pagination"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 type CustomerUsecase struct {
-	customerRepo *repository.CustomerRepository
+	customerRepo      *repository.CustomerRepository
+	orderRepo         *repository.OrdersRepository
+	addressRepo       *repository.CustomerAddressRepository
+	tagRepo           *repository.TagRepository
+	noteRepo          *repository.CustomerNoteRepository
+	loyaltyUsecase    *LoyaltyUsecase
+	auditUsecase      *AuditUsecase
+	paginationService *pagination.Service
 }
 
-func NewCustomerUsecase(customerRepo *repository.CustomerRepository) *CustomerUsecase {
+func NewCustomerUsecase(
+	customerRepo *repository.CustomerRepository,
+	orderRepo *repository.OrdersRepository,
+	addressRepo *repository.CustomerAddressRepository,
+	tagRepo *repository.TagRepository,
+	noteRepo *repository.CustomerNoteRepository,
+	loyaltyUsecase *LoyaltyUsecase,
+	auditUsecase *AuditUsecase,
+) *CustomerUsecase {
 	return &CustomerUsecase{
-		customerRepo: customerRepo,
+		customerRepo:      customerRepo,
+		orderRepo:         orderRepo,
+		addressRepo:       addressRepo,
+		tagRepo:           tagRepo,
+		noteRepo:          noteRepo,
+		loyaltyUsecase:    loyaltyUsecase,
+		auditUsecase:      auditUsecase,
+		paginationService: pagination.NewService(),
 	}
 }
 
@@ -23,75 +56,178 @@ func (u *CustomerUsecase) CreateCustomer(ctx context.Context, req *model.CreateC
 	if err != nil {
 		return nil, err
 	}
+	phone, err := normalizePhone(req.PhoneNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.ensurePhoneAvailable(ctx, phone, 0); err != nil {
+		return nil, err
+	}
 
 	customer := &model.Customer{
 		FirstName:   strings.TrimSpace(req.FirstName),
 		LastName:    strings.TrimSpace(req.LastName),
 		Address:     strings.TrimSpace(req.Address),
 		Email:       strings.ToLower(strings.TrimSpace(req.Email)),
-		PhoneNumber: req.PhoneNumber,
+		PhoneNumber: phone,
 	}
 
 	result, err := u.customerRepo.Create(ctx, customer)
 	if err != nil {
 		return nil, err
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionCreate, model.AuditEntityCustomer, result.ID, nil, result)
 
 	return result, nil
 }
 
 func (u *CustomerUsecase) validateCreateCustomer(req *model.CreateCustomerRequest) error {
 	if strings.TrimSpace(req.FirstName) == "" {
-		return fmt.Errorf("name is required")
-	}
-	if len(req.PhoneNumber) < 10 || len(req.PhoneNumber) > 11 {
-		return fmt.Errorf("invalid phone number")
+		return apperror.InvalidField("first_name", "required", "name is required")
 	}
 	return nil
 }
 
-func (u *CustomerUsecase) validateUpdateCustomer(req *model.UpdateCustomerRequest) error {
-	if req.PhoneNumber != nil && (len(*req.PhoneNumber) < 10 || len(*req.PhoneNumber) > 11) {
-		return fmt.Errorf("invalid phone number")
+// ensurePhoneAvailable checks no other customer than exceptID has the phone number
+func (u *CustomerUsecase) ensurePhoneAvailable(ctx context.Context, phone string, exceptID int64) error {
+	owner, err := u.customerRepo.FindByPhone(ctx, phone)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.ID != exceptID {
+		return apperror.Conflict("phone number %s already belongs to customer %d, merge the customers instead", phone, owner.ID)
 	}
 	return nil
 }
 
 func (u *CustomerUsecase) GetCustomerByID(ctx context.Context, id int64) (*model.Customer, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid id")
+		return nil, apperror.Validation("invalid id")
 	}
 	customer, err := u.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	customer.Addresses, err = u.addressRepo.GetByCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	customer.Loyalty, err = u.loyaltyUsecase.GetCustomerLoyalty(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := u.tagRepo.GetByCustomers(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	customer.Tags = tags[id]
 
 	return customer, nil
 }
 
-func (u *CustomerUsecase) GetAllCustomer(ctx context.Context) ([]*model.Customer, error) {
-	customer, err := u.customerRepo.GetAll(ctx)
+// GetAllCustomer lists customers page by page with their tags, search matches the name, phone number or email
+// and the customers must have every tag of tagIDs
+func (u *CustomerUsecase) GetAllCustomer(ctx context.Context, req *pagination.Request, search string, tagIDs []int64) (*pagination.Response, error) {
+	u.paginationService.ValidateAndNormalize(req)
+	if !slices.Contains(customerSortFields, req.SortBy) {
+		return nil, apperror.InvalidField("sort_by", "oneof", "invalid sort_by, expected one of %s", strings.Join(customerSortFields, ", "))
+	}
+
+	cursor, effectiveOrder := u.paginationService.GetNavigationParams(*req)
+	fetchLimit := u.paginationService.CalculateFetchLimit(req.Limit)
+
+	customers, err := u.customerRepo.GetAllPaginated(ctx, search, tagIDs, cursor, fetchLimit, effectiveOrder, req.SortBy)
 	if err != nil {
 		return nil, err
 	}
 
-	return customer, nil
+	customerIDs := make([]int64, len(customers))
+	for i, customer := range customers {
+		customerIDs[i] = customer.ID
+	}
+	tags, err := u.tagRepo.GetByCustomers(ctx, customerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(customers))
+	for i, customer := range customers {
+		customer.Tags = tags[customer.ID]
+		items[i] = customer
+	}
+	response := u.paginationService.BuildResponse(
+		items,
+		req,
+		func(c interface{}) (time.Time, int64) {
+			customer := c.(*model.Customer)
+			if req.SortBy == "updated_at" {
+				return customer.UpdatedAt, customer.ID
+			}
+			return customer.CreatedAt, customer.ID
+		},
+	)
+	return &response, nil
+}
+
+var customerSortFields = []string{"created_at", "updated_at", "id"}
+
+// GetOrderHistory returns the lifetime stats of the customer and a page of their orders
+func (u *CustomerUsecase) GetOrderHistory(ctx context.Context, id int64, req *pagination.Request) (*model.CustomerOrderHistory, error) {
+	if _, err := u.GetCustomerByID(ctx, id); err != nil {
+		return nil, err
+	}
+	u.paginationService.ValidateAndNormalize(req)
+	if req.SortBy != "created_at" && req.SortBy != "id" {
+		return nil, apperror.InvalidField("sort_by", "oneof", "invalid sort_by, expected created_at or id")
+	}
+
+	stats, err := u.orderRepo.GetCustomerStats(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, effectiveOrder := u.paginationService.GetNavigationParams(*req)
+	fetchLimit := u.paginationService.CalculateFetchLimit(req.Limit)
+	orders, err := u.orderRepo.GetCustomerOrdersPaginated(ctx, id, cursor, fetchLimit, effectiveOrder, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(orders))
+	for i, order := range orders {
+		items[i] = order
+	}
+	return &model.CustomerOrderHistory{
+		CustomerID: id,
+		Stats:      stats,
+		Orders: u.paginationService.BuildResponse(
+			items,
+			req,
+			func(o interface{}) (time.Time, int64) {
+				order := o.(*model.CustomerOrder)
+				return order.CreatedAt, order.ID
+			},
+		),
+	}, nil
 }
 
 func (u *CustomerUsecase) UpdateCustomer(ctx context.Context, id int64, req *model.UpdateCustomerRequest) (*model.Customer, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid id")
+		return nil, apperror.Validation("invalid id")
 	}
-	if err := u.validateUpdateCustomer(req); err != nil {
+	customer, err := u.customerRepo.GetByID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
-
+	if customer.AnonymizedAt != nil {
+		return nil, apperror.Conflict("customer %d is anonymized and can't be updated", id)
+	}
 	updates := make(map[string]interface{})
 	if req.Address != nil && strings.TrimSpace(*req.Address) != "" {
 		updates["address"] = strings.TrimSpace(*req.Address)
 	}
 	if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
-		updates["email"] = strings.TrimSpace(*req.Email)
+		updates["email"] = strings.ToLower(strings.TrimSpace(*req.Email))
 	}
 	if req.FirstName != nil && strings.TrimSpace(*req.FirstName) != "" {
 		updates["first_name"] = strings.TrimSpace(*req.FirstName)
@@ -100,7 +236,14 @@ func (u *CustomerUsecase) UpdateCustomer(ctx context.Context, id int64, req *mod
 		updates["last_name"] = strings.TrimSpace(*req.LastName)
 	}
 	if req.PhoneNumber != nil {
-		updates["phone_number"] = strings.TrimSpace(*req.PhoneNumber)
+		phone, err := normalizePhone(*req.PhoneNumber)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.ensurePhoneAvailable(ctx, phone, id); err != nil {
+			return nil, err
+		}
+		updates["phone_number"] = phone
 	}
 
 	if err := u.customerRepo.Update(ctx, id, updates); err != nil {
@@ -111,17 +254,408 @@ func (u *CustomerUsecase) UpdateCustomer(ctx context.Context, id int64, req *mod
 	if err != nil {
 		return nil, err
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityCustomer, id, customer, updatedCustomer)
 
 	return updatedCustomer, nil
 }
 
+// DeleteCustomer deletes a customer without orders, customers with orders can only be anonymized
 func (u *CustomerUsecase) DeleteCustomer(ctx context.Context, id int64) error {
 	if id <= 0 {
-		return fmt.Errorf("invalid id")
+		return apperror.Validation("invalid id")
+	}
+	customer, err := u.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	stats, err := u.orderRepo.GetCustomerStats(ctx, id)
+	if err != nil {
+		return err
+	}
+	if orderCount := stats.OrderCount + stats.CanceledOrderCount; orderCount > 0 {
+		return apperror.Conflict("customer %d has %d orders, anonymize it instead", id, orderCount)
 	}
 
 	if err := u.customerRepo.Delete(ctx, id); err != nil {
 		return err
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionDelete, model.AuditEntityCustomer, id, customer, nil)
 	return nil
 }
+
+// ExportCustomer gathers everything held about the customer: profile, addresses, loyalty, tags,
+// orders with their lines and shipping addresses, the loyalty ledger and the notes
+func (u *CustomerUsecase) ExportCustomer(ctx context.Context, id int64) (*model.CustomerExport, error) {
+	customer, err := u.GetCustomerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := u.orderRepo.GetCustomerStats(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := u.orderRepo.GetAllCustomerOrders(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := u.orderRepo.GetCustomerOrderLines(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	ledger, err := u.loyaltyUsecase.GetAllEntries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := u.noteRepo.GetByCustomer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.CustomerExport{
+		ExportedAt:    time.Now(),
+		Customer:      customer,
+		Stats:         stats,
+		Orders:        make([]*model.CustomerExportOrder, len(orders)),
+		LoyaltyLedger: ledger,
+		Notes:         notes,
+	}
+	for i, order := range orders {
+		items := lines[order.ID]
+		if items == nil {
+			items = []*model.ReceiptLine{}
+		}
+		export.Orders[i] = &model.CustomerExportOrder{CustomerOrder: order, Items: items}
+	}
+	return export, nil
+}
+
+// AnonymizeCustomer scrubs the name, contact details, addresses and notes of the customer, including the
+// shipping address snapshots of their orders. Orders, amounts and the loyalty ledger are kept for accounting
+func (u *CustomerUsecase) AnonymizeCustomer(ctx context.Context, id int64) (*model.Customer, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	customer, err := u.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if customer.AnonymizedAt != nil {
+		return nil, apperror.Conflict("customer %d is already anonymized", id)
+	}
+
+	tx, err := u.customerRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := u.addressRepo.DeleteByCustomer(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := u.noteRepo.DeleteByCustomer(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := u.orderRepo.ScrubShippingAddresses(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if err := u.customerRepo.Anonymize(ctx, tx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	// The audit logs keep the history of the customer, not its personal data
+	if err := u.auditUsecase.RedactCustomer(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	anonymized, err := u.customerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityCustomer, id, customer, anonymized)
+	return anonymized, nil
+}
+
+// duplicateCandidateLimit bounds the customers scored for one customer
+const duplicateCandidateLimit = 50
+
+// Duplicate score weights, a matching phone or email alone reaches the default minimum score
+const (
+	duplicatePhoneWeight = 0.5
+	duplicateEmailWeight = 0.4
+	duplicateNameWeight  = 0.3
+	// minNameSimilarity is the name similarity under which names don't count
+	minNameSimilarity = 0.7
+)
+
+// FindDuplicates scores the customers that may be the same person as the customer, best match first
+func (u *CustomerUsecase) FindDuplicates(ctx context.Context, id int64, minScore float64) ([]*model.CustomerDuplicate, error) {
+	if minScore < 0 || minScore > 1 {
+		return nil, apperror.InvalidField("min_score", "range", "min_score must be between 0 and 1")
+	}
+	customer, err := u.GetCustomerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := u.customerRepo.FindDuplicateCandidates(ctx, customer, duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []*model.CustomerDuplicate{}
+	for _, candidate := range candidates {
+		score, reasons := scoreDuplicate(customer, candidate)
+		if score == 0 || score < minScore {
+			continue
+		}
+		duplicates = append(duplicates, &model.CustomerDuplicate{
+			Customer: candidate,
+			Score:    score,
+			Reasons:  reasons,
+		})
+	}
+	slices.SortStableFunc(duplicates, func(a, b *model.CustomerDuplicate) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return duplicates, nil
+}
+
+// MergeCustomers moves the orders, addresses, loyalty points, tags and notes of the given customers to the surviving customer
+// and deletes them, in one transaction. Empty fields of the surviving customer are filled from the
+// merged customers, and it keeps its default addresses when it has some
+func (u *CustomerUsecase) MergeCustomers(ctx context.Context, id int64, req *model.MergeCustomersRequest) (*model.CustomerMergeResult, error) {
+	target, err := u.GetCustomerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if target.AnonymizedAt != nil {
+		return nil, apperror.Conflict("customer %d is anonymized, customers can't be merged into it", id)
+	}
+
+	var sources []*model.Customer
+	sourceIDs := []int64{}
+	for i, sourceID := range req.CustomerIDs {
+		if sourceID == id {
+			return nil, apperror.InvalidField(fmt.Sprintf("customer_ids[%d]", i), "nefield", "customer %d can't be merged into itself", id)
+		}
+		if slices.Contains(sourceIDs, sourceID) {
+			continue
+		}
+		source, err := u.customerRepo.GetByID(ctx, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("customer %d: %w", sourceID, err)
+		}
+		if source.AnonymizedAt != nil {
+			return nil, apperror.Conflict("customer %d is anonymized and can't be merged", sourceID)
+		}
+		sources = append(sources, source)
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+
+	hasDefaultShipping, hasDefaultBilling := false, false
+	for _, address := range target.Addresses {
+		hasDefaultShipping = hasDefaultShipping || address.IsDefaultShipping
+		hasDefaultBilling = hasDefaultBilling || address.IsDefaultBilling
+	}
+
+	tx, err := u.customerRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ordersMoved, err := u.orderRepo.ReassignCustomer(ctx, tx, sourceIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	addressesMoved, err := u.addressRepo.ReassignCustomer(ctx, tx, sourceIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.loyaltyUsecase.MoveCustomerPoints(ctx, tx, sourceIDs, id); err != nil {
+		return nil, err
+	}
+	if err := u.tagRepo.CopyToCustomer(ctx, tx, sourceIDs, id); err != nil {
+		return nil, err
+	}
+	if err := u.noteRepo.ReassignCustomer(ctx, tx, sourceIDs, id); err != nil {
+		return nil, err
+	}
+	if addressesMoved > 0 && !hasDefaultShipping {
+		if err := u.addressRepo.PromoteDefault(ctx, tx, id, repository.AddressDefaultShipping); err != nil {
+			return nil, err
+		}
+	}
+	if addressesMoved > 0 && !hasDefaultBilling {
+		if err := u.addressRepo.PromoteDefault(ctx, tx, id, repository.AddressDefaultBilling); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.customerRepo.Merge(ctx, tx, id, sourceIDs, mergedCustomerUpdates(target, sources)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	merged, err := u.GetCustomerByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityCustomer, id, target, merged)
+	for _, source := range sources {
+		u.auditUsecase.Record(ctx, model.AuditActionDelete, model.AuditEntityCustomer, source.ID, source, nil)
+	}
+	return &model.CustomerMergeResult{
+		Customer:          merged,
+		MergedCustomerIDs: sourceIDs,
+		OrdersMoved:       ordersMoved,
+		AddressesMoved:    addressesMoved,
+	}, nil
+}
+
+// mergedCustomerUpdates fills the empty fields of target with the first merged customer having them
+func mergedCustomerUpdates(target *model.Customer, sources []*model.Customer) map[string]interface{} {
+	updates := make(map[string]interface{})
+	fields := []struct {
+		column string
+		value  func(*model.Customer) string
+	}{
+		{"first_name", func(c *model.Customer) string { return c.FirstName }},
+		{"last_name", func(c *model.Customer) string { return c.LastName }},
+		{"address", func(c *model.Customer) string { return c.Address }},
+		{"email", func(c *model.Customer) string { return c.Email }},
+		{"phone_number", func(c *model.Customer) string { return c.PhoneNumber }},
+	}
+	for _, field := range fields {
+		if strings.TrimSpace(field.value(target)) != "" {
+			continue
+		}
+		for _, source := range sources {
+			if value := strings.TrimSpace(field.value(source)); value != "" {
+				updates[field.column] = value
+				break
+			}
+		}
+	}
+	return updates
+}
+
+// scoreDuplicate scores how likely candidate is the same person as customer
+func scoreDuplicate(customer, candidate *model.Customer) (float64, []string) {
+	var (
+		score   float64
+		reasons = []string{}
+	)
+	if phone, err := normalizePhone(customer.PhoneNumber); err == nil {
+		if other, err := normalizePhone(candidate.PhoneNumber); err == nil && phone == other {
+			score += duplicatePhoneWeight
+			reasons = append(reasons, "same phone number")
+		}
+	}
+	email := strings.ToLower(strings.TrimSpace(customer.Email))
+	if email != "" && email == strings.ToLower(strings.TrimSpace(candidate.Email)) {
+		score += duplicateEmailWeight
+		reasons = append(reasons, "same email")
+	}
+	similarity := nameSimilarity(
+		customer.FirstName+" "+customer.LastName,
+		candidate.FirstName+" "+candidate.LastName,
+	)
+	if similarity >= minNameSimilarity {
+		score += duplicateNameWeight * similarity
+		if similarity == 1 {
+			reasons = append(reasons, "same name")
+		} else {
+			reasons = append(reasons, "similar name")
+		}
+	}
+	return math.Min(1, math.Round(score*100)/100), reasons
+}
+
+// nameSimilarity compares two names ignoring case, accents and word order, from 0 to 1
+func nameSimilarity(a, b string) float64 {
+	a, b = foldName(a), foldName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	return 1 - float64(levenshtein(ra, rb))/float64(max(len(ra), len(rb)))
+}
+
+var accentRemover = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// foldName lowercases the name, removes the accents and sorts the words
+// "Nguyễn Văn  An" and "an nguyen van" both give "an nguyen van"
+func foldName(name string) string {
+	folded, _, err := transform.String(accentRemover, strings.ToLower(name))
+	if err != nil {
+		folded = strings.ToLower(name)
+	}
+	folded = strings.ReplaceAll(folded, "đ", "d")
+	words := strings.Fields(folded)
+	slices.Sort(words)
+	return strings.Join(words, " ")
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// normalizePhone formats a Vietnamese phone number as E.164, +84 followed by the national number
+// "0901 234 567", "84901234567", "+84 901-234-567" and "0084901234567" all give "+84901234567"
+func normalizePhone(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	var digits strings.Builder
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0, r == ' ', r == '.', r == '-', r == '(', r == ')':
+		default:
+			return "", apperror.InvalidField("phone_number", "phone", "invalid phone number %q", phone)
+		}
+	}
+
+	number := digits.String()
+	var national string
+	switch {
+	case strings.HasPrefix(phone, "+"):
+		if !strings.HasPrefix(number, "84") {
+			return "", apperror.InvalidField("phone_number", "phone", "only Vietnamese phone numbers (+84) are supported")
+		}
+		national = number[2:]
+	case strings.HasPrefix(number, "0084"):
+		national = number[4:]
+	case strings.HasPrefix(number, "0"):
+		national = number[1:]
+	case strings.HasPrefix(number, "84") && len(number) >= 11:
+		national = number[2:]
+	default:
+		national = number
+	}
+	// Mobile numbers have 9 digits after the prefix, landlines 10
+	if len(national) < 9 || len(national) > 10 || national[0] == '0' {
+		return "", apperror.InvalidField("phone_number", "phone", "invalid phone number %q", phone)
+	}
+	return "+84" + national, nil
+}
@@ -0,0 +1,372 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/internal/utils"
+	"simple-template/pkg/pagination"
+	"slices"
+	"time"
+)
+
+type LoyaltyUsecase struct {
+	loyaltyRepo       *repository.LoyaltyRepository
+	orderRepo         *repository.OrdersRepository
+	customerRepo      *repository.CustomerRepository
+	paginationService *pagination.Service
+}
+
+func NewLoyaltyUsecase(
+	loyaltyRepo *repository.LoyaltyRepository,
+	orderRepo *repository.OrdersRepository,
+	customerRepo *repository.CustomerRepository,
+) *LoyaltyUsecase {
+	return &LoyaltyUsecase{
+		loyaltyRepo:       loyaltyRepo,
+		orderRepo:         orderRepo,
+		customerRepo:      customerRepo,
+		paginationService: pagination.NewService(),
+	}
+}
+
+func (u *LoyaltyUsecase) GetSettings(ctx context.Context) (*model.LoyaltyConfig, error) {
+	return u.loyaltyRepo.GetSettings(ctx)
+}
+
+// UpdateSettings replaces the program rules, they apply to the points earned or redeemed from now on
+func (u *LoyaltyUsecase) UpdateSettings(ctx context.Context, config *model.LoyaltyConfig) (*model.LoyaltyConfig, error) {
+	minSpend := map[string]float64{}
+	for _, rule := range config.Tiers {
+		if _, ok := minSpend[rule.Tier]; ok {
+			return nil, apperror.Validation("tier %s is defined twice", rule.Tier)
+		}
+		minSpend[rule.Tier] = rule.MinSpend
+	}
+	silver, hasSilver := minSpend[model.LoyaltyTierSilver]
+	gold, hasGold := minSpend[model.LoyaltyTierGold]
+	if hasSilver && hasGold && gold <= silver {
+		return nil, apperror.Validation("gold tier must require more spend than silver")
+	}
+
+	if err := u.loyaltyRepo.UpdateSettings(ctx, config); err != nil {
+		return nil, err
+	}
+	return u.loyaltyRepo.GetSettings(ctx)
+}
+
+// GetCustomerLoyalty returns the points balance of the customer and the tier reached with the rolling spend
+func (u *LoyaltyUsecase) GetCustomerLoyalty(ctx context.Context, customerID int64) (*model.CustomerLoyalty, error) {
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	config, err := u.loyaltyRepo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	loyalty, err := u.loyaltyRepo.GetBalance(ctx, customerID, now)
+	if err != nil {
+		return nil, err
+	}
+	loyalty.PointsValue = utils.RoundMoney(float64(loyalty.Points) * config.PointValue)
+
+	loyalty.RollingSpend, err = u.orderRepo.GetCompletedSpend(ctx, customerID, now.AddDate(0, -config.TierWindowMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+	loyalty.RollingSpend = utils.RoundMoney(loyalty.RollingSpend)
+	if tier := config.TierFor(loyalty.RollingSpend); tier != nil {
+		loyalty.Tier = tier.Tier
+	}
+	if next := config.NextTier(loyalty.RollingSpend); next != nil {
+		loyalty.NextTier = next.Tier
+		loyalty.SpendToNextTier = utils.RoundMoney(next.MinSpend - loyalty.RollingSpend)
+	}
+	return loyalty, nil
+}
+
+// GetLedger lists the loyalty entries of the customer page by page
+func (u *LoyaltyUsecase) GetLedger(ctx context.Context, customerID int64, req *pagination.Request) (*pagination.Response, error) {
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	u.paginationService.ValidateAndNormalize(req)
+	if req.SortBy != "created_at" && req.SortBy != "id" {
+		return nil, apperror.InvalidField("sort_by", "oneof", "invalid sort_by, expected created_at or id")
+	}
+
+	cursor, effectiveOrder := u.paginationService.GetNavigationParams(*req)
+	fetchLimit := u.paginationService.CalculateFetchLimit(req.Limit)
+	entries, err := u.loyaltyRepo.GetEntriesPaginated(ctx, customerID, cursor, fetchLimit, effectiveOrder, req.SortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		items[i] = entry
+	}
+	response := u.paginationService.BuildResponse(
+		items,
+		req,
+		func(e interface{}) (time.Time, int64) {
+			entry := e.(*model.LoyaltyEntry)
+			return entry.CreatedAt, entry.ID
+		},
+	)
+	return &response, nil
+}
+
+// GetAllEntries returns the whole ledger of the customer, oldest first
+func (u *LoyaltyUsecase) GetAllEntries(ctx context.Context, customerID int64) ([]*model.LoyaltyEntry, error) {
+	return u.loyaltyRepo.GetEntries(ctx, customerID)
+}
+
+// RedemptionDiscount checks the customer can pay the order with the points and returns the discount they give
+// The discount is capped by the program max redeem rate and by what is left to pay
+func (u *LoyaltyUsecase) RedemptionDiscount(ctx context.Context, customerID int64, points int64, amounts *model.OrderAmounts) (float64, error) {
+	if points == 0 {
+		return 0, nil
+	}
+	if customerID == 0 {
+		return 0, apperror.InvalidField("redeem_points", "walk_in", "walk-in customers can't redeem points")
+	}
+	config, err := u.loyaltyRepo.GetSettings(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !config.Enabled {
+		return 0, apperror.Conflict("loyalty program is disabled")
+	}
+	if points < config.MinRedeemPoints {
+		return 0, apperror.InvalidField("redeem_points", "min", "at least %d points must be redeemed", config.MinRedeemPoints)
+	}
+	balance, err := u.loyaltyRepo.GetBalance(ctx, customerID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	if balance.Points < points {
+		return 0, apperror.InvalidField("redeem_points", "balance", "customer has only %d points", balance.Points)
+	}
+
+	discount := utils.RoundMoney(float64(points) * config.PointValue)
+	maxDiscount := math.Min(
+		utils.RoundMoney(amounts.SubtotalAmount*config.MaxRedeemRate/100),
+		amounts.TotalAmount,
+	)
+	if discount > maxDiscount {
+		return 0, apperror.InvalidField("redeem_points", "max", "points can pay at most %.2f of this order (%d points)",
+			maxDiscount, int64(math.Floor(maxDiscount/config.PointValue)))
+	}
+	return discount, nil
+}
+
+// Redeem takes the points from the customer for the order, within the order transaction
+func (u *LoyaltyUsecase) Redeem(ctx context.Context, tx *sql.Tx, order *model.Orders, points int64) error {
+	if points == 0 {
+		return nil
+	}
+	consumed, err := u.consume(ctx, tx, order.CustomerID, points, 0)
+	if err != nil {
+		return err
+	}
+	if consumed < points {
+		return apperror.Conflict("customer has only %d points", consumed)
+	}
+	return u.loyaltyRepo.CreateEntry(ctx, tx, &model.LoyaltyEntry{
+		CustomerID:  order.CustomerID,
+		OrderID:     &order.ID,
+		Type:        model.LoyaltyEntryRedeem,
+		Points:      -points,
+		Description: fmt.Sprintf("Redeemed on order %d", order.ID),
+	})
+}
+
+// EarnForOrder credits the points of a completed order, within the status transaction
+// Walk-in orders, excluded platforms and orders that already earned are skipped
+func (u *LoyaltyUsecase) EarnForOrder(ctx context.Context, tx *sql.Tx, order *model.Orders) error {
+	if order.CustomerID == 0 {
+		return nil
+	}
+	config, err := u.loyaltyRepo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if !config.EarnsOn(order.PlatformID) {
+		return nil
+	}
+	entries, err := u.loyaltyRepo.LockOrderEntries(ctx, tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if findLoyaltyEntry(entries, model.LoyaltyEntryEarn) != nil {
+		return nil
+	}
+
+	// The tier comes from the spend before this order
+	now := time.Now().UTC()
+	spend, err := u.orderRepo.GetCompletedSpend(ctx, order.CustomerID, now.AddDate(0, -config.TierWindowMonths, 0))
+	if err != nil {
+		return err
+	}
+	multiplier := 1.0
+	description := fmt.Sprintf("Earned on order %d", order.ID)
+	if tier := config.TierFor(spend); tier != nil {
+		multiplier = tier.EarnMultiplier
+		description += fmt.Sprintf(" (%s x%g)", tier.Tier, tier.EarnMultiplier)
+	}
+	points := int64(math.Floor(order.TotalAmount / config.EarnAmount * multiplier))
+	if points <= 0 {
+		return nil
+	}
+
+	entry := &model.LoyaltyEntry{
+		CustomerID:      order.CustomerID,
+		OrderID:         &order.ID,
+		Type:            model.LoyaltyEntryEarn,
+		Points:          points,
+		RemainingPoints: points,
+		Description:     description,
+	}
+	if config.ExpiryMonths > 0 {
+		expiresAt := now.AddDate(0, config.ExpiryMonths, 0)
+		entry.ExpiresAt = &expiresAt
+	}
+	return u.loyaltyRepo.CreateEntry(ctx, tx, entry)
+}
+
+// ReverseOrder takes back the points a canceled or returned order earned and gives back the points it redeemed
+// Earned points the customer already spent can't be taken back, the reversal records what was
+func (u *LoyaltyUsecase) ReverseOrder(ctx context.Context, tx *sql.Tx, orderID int64) error {
+	entries, err := u.loyaltyRepo.LockOrderEntries(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	earn := findLoyaltyEntry(entries, model.LoyaltyEntryEarn)
+	if earn != nil && findLoyaltyEntry(entries, model.LoyaltyEntryReverseEarn) == nil {
+		reversed, err := u.consume(ctx, tx, earn.CustomerID, earn.Points, earn.ID)
+		if err != nil {
+			return err
+		}
+		description := fmt.Sprintf("Reversed points earned on order %d", orderID)
+		if reversed < earn.Points {
+			description += fmt.Sprintf(", %d already spent", earn.Points-reversed)
+		}
+		if err := u.loyaltyRepo.CreateEntry(ctx, tx, &model.LoyaltyEntry{
+			CustomerID:  earn.CustomerID,
+			OrderID:     &orderID,
+			Type:        model.LoyaltyEntryReverseEarn,
+			Points:      -reversed,
+			Description: description,
+		}); err != nil {
+			return err
+		}
+	}
+
+	redeem := findLoyaltyEntry(entries, model.LoyaltyEntryRedeem)
+	if redeem != nil && findLoyaltyEntry(entries, model.LoyaltyEntryReverseRedeem) == nil {
+		config, err := u.loyaltyRepo.GetSettings(ctx)
+		if err != nil {
+			return err
+		}
+		entry := &model.LoyaltyEntry{
+			CustomerID:      redeem.CustomerID,
+			OrderID:         &orderID,
+			Type:            model.LoyaltyEntryReverseRedeem,
+			Points:          -redeem.Points,
+			RemainingPoints: -redeem.Points,
+			Description:     fmt.Sprintf("Gave back points redeemed on order %d", orderID),
+		}
+		if config.ExpiryMonths > 0 {
+			expiresAt := time.Now().UTC().AddDate(0, config.ExpiryMonths, 0)
+			entry.ExpiresAt = &expiresAt
+		}
+		if err := u.loyaltyRepo.CreateEntry(ctx, tx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpirePoints records the expiry of the points left on expired credits, of one customer or of all when customerID is 0
+func (u *LoyaltyUsecase) ExpirePoints(ctx context.Context, customerID int64) (*model.LoyaltyExpiryResult, error) {
+	tx, err := u.loyaltyRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	credits, err := u.loyaltyRepo.LockExpiredCredits(ctx, tx, customerID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	result := &model.LoyaltyExpiryResult{}
+	for _, credit := range credits {
+		if err := u.loyaltyRepo.CreateEntry(ctx, tx, &model.LoyaltyEntry{
+			CustomerID:  credit.CustomerID,
+			Type:        model.LoyaltyEntryExpire,
+			Points:      -credit.RemainingPoints,
+			Description: fmt.Sprintf("Points of entry %d expired on %s", credit.ID, credit.ExpiresAt.Format(time.DateOnly)),
+		}); err != nil {
+			return nil, err
+		}
+		if err := u.loyaltyRepo.SetRemaining(ctx, tx, credit.ID, 0); err != nil {
+			return nil, err
+		}
+		result.EntryCount++
+		result.ExpiredPoints += credit.RemainingPoints
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// MoveCustomerPoints moves the ledger of merged customers, within the merge transaction
+func (u *LoyaltyUsecase) MoveCustomerPoints(ctx context.Context, tx *sql.Tx, fromIDs []int64, toID int64) error {
+	return u.loyaltyRepo.ReassignCustomer(ctx, tx, fromIDs, toID)
+}
+
+// consume takes up to points from the unexpired credits of the customer, expiring first
+// and preferredID first when set, and returns the points taken
+func (u *LoyaltyUsecase) consume(ctx context.Context, tx *sql.Tx, customerID, points, preferredID int64) (int64, error) {
+	credits, err := u.loyaltyRepo.LockCredits(ctx, tx, customerID, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	for i, credit := range credits {
+		if credit.ID == preferredID {
+			credits = slices.Insert(slices.Delete(credits, i, i+1), 0, credit)
+			break
+		}
+	}
+
+	left := points
+	for _, credit := range credits {
+		if left == 0 {
+			break
+		}
+		taken := min(credit.RemainingPoints, left)
+		if err := u.loyaltyRepo.SetRemaining(ctx, tx, credit.ID, credit.RemainingPoints-taken); err != nil {
+			return 0, err
+		}
+		left -= taken
+	}
+	return points - left, nil
+}
+
+func findLoyaltyEntry(entries []*model.LoyaltyEntry, entryType string) *model.LoyaltyEntry {
+	for _, entry := range entries {
+		if entry.Type == entryType {
+			return entry
+		}
+	}
+	return nil
+}
@@ -2,26 +2,127 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
+	"simple-template/internal/utils"
+	"time"
 )
 
 type OrderUsecase struct {
-	orderRepo *repository.OrdersRepository
+	orderRepo       *repository.OrdersRepository
+	platformRepo    *repository.PlatformRepository
+	retailStoreRepo *repository.RetailStoreRepository
+	paymentRepo     *repository.PaymentMethodsRepository
+	addressRepo     *repository.CustomerAddressRepository
+	loyaltyUsecase  *LoyaltyUsecase
+	auditUsecase    *AuditUsecase
 }
 
-func NewOrderUseCase(orderRepo *repository.OrdersRepository) *OrderUsecase {
+func NewOrderUseCase(
+	orderRepo *repository.OrdersRepository,
+	platformRepo *repository.PlatformRepository,
+	retailStoreRepo *repository.RetailStoreRepository,
+	paymentRepo *repository.PaymentMethodsRepository,
+	addressRepo *repository.CustomerAddressRepository,
+	loyaltyUsecase *LoyaltyUsecase,
+	auditUsecase *AuditUsecase,
+) *OrderUsecase {
 	return &OrderUsecase{
-		orderRepo: orderRepo,
+		orderRepo:       orderRepo,
+		platformRepo:    platformRepo,
+		retailStoreRepo: retailStoreRepo,
+		paymentRepo:     paymentRepo,
+		addressRepo:     addressRepo,
+		loyaltyUsecase:  loyaltyUsecase,
+		auditUsecase:    auditUsecase,
 	}
 }
 
-func (u *OrderUsecase) CreateOrders(ctx context.Context, req *model.CreateOrders) (*model.Orders, error) {
+// OrderTxHook runs inside the order creation transaction, right before commit
+type OrderTxHook func(ctx context.Context, tx *sql.Tx, order *model.Orders) error
+
+// CreateOrders creates a pending order, beforeCommit may be nil
+func (u *OrderUsecase) CreateOrders(ctx context.Context, req *model.CreateOrders, beforeCommit OrderTxHook) (*model.Orders, error) {
+	if err := authorizeStore(ctx, model.PermissionOrdersWrite, req.RetailStoreID); err != nil {
+		return nil, err
+	}
+	return u.createOrder(ctx, req, &model.OrderStatus{
+		Status:      int8(model.OrderStatusPending),
+		Description: "created new orders",
+	}, model.PaymentStatusUnpaid, true, beforeCommit)
+}
+
+// CreateImportedOrder creates a marketplace order in the status the marketplace reports,
+// orders past pending are paid to the marketplace, beforeCommit may be nil
+func (u *OrderUsecase) CreateImportedOrder(
+	ctx context.Context,
+	req *model.CreateOrders,
+	status model.OrderStatusItem,
+	beforeCommit OrderTxHook,
+) (*model.Orders, error) {
+	if err := authorizeStore(ctx, model.PermissionOrdersWrite, req.RetailStoreID); err != nil {
+		return nil, err
+	}
+	paymentStatus := model.PaymentStatusPaid
+	if status == model.OrderStatusPending {
+		paymentStatus = model.PaymentStatusUnpaid
+	}
+	return u.createOrder(ctx, req, &model.OrderStatus{
+		Status:      int8(status),
+		Description: statusDescription(int8(status)),
+	}, paymentStatus, true, beforeCommit)
+}
+
+// CreateCompletedOrder creates an order that is paid and handed over on the spot (counter sales)
+// A zero CustomerID records a walk-in sale, beforeCommit may be nil
+func (u *OrderUsecase) CreateCompletedOrder(ctx context.Context, req *model.CreateOrders, beforeCommit OrderTxHook) (*model.Orders, error) {
+	if err := authorizeStore(ctx, model.PermissionPosSell, req.RetailStoreID); err != nil {
+		return nil, err
+	}
+	return u.createOrder(ctx, req, &model.OrderStatus{
+		Status:      int8(model.OrderStatusCompleted),
+		Description: "Order paid and handed over at the counter",
+	}, model.PaymentStatusPaid, false, beforeCommit)
+}
+
+func (u *OrderUsecase) createOrder(
+	ctx context.Context,
+	req *model.CreateOrders,
+	status *model.OrderStatus,
+	paymentStatus int8,
+	defaultShipping bool,
+	beforeCommit OrderTxHook,
+) (*model.Orders, error) {
 	// Validate before starting transaction
-	if err := u.validateCreateOrder(ctx, req); err != nil {
+	stocks, err := u.validateCreateOrder(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	shippingAddressID, shippingAddress, err := u.resolveShippingAddress(ctx, req, defaultShipping)
+	if err != nil {
+		return nil, err
+	}
+
+	// Amounts and platform fees are frozen at creation time
+	amounts, err := u.calculateAmounts(req, stocks)
+	if err != nil {
+		return nil, err
+	}
+	// Redeemed points are part of the discount
+	loyaltyDiscount, err := u.loyaltyUsecase.RedemptionDiscount(ctx, req.CustomerID, req.RedeemPoints, amounts)
+	if err != nil {
+		return nil, err
+	}
+	amounts.DiscountAmount = utils.RoundMoney(amounts.DiscountAmount + loyaltyDiscount)
+	amounts.TotalAmount = utils.RoundMoney(amounts.TotalAmount - loyaltyDiscount)
+	platform, err := u.platformRepo.GetByID(ctx, req.PlatformID)
+	if err != nil {
 		return nil, err
 	}
+	fees := calculateChannelFees(platform.Config, amounts)
 
 	// Start transaction
 	tx, err := u.orderRepo.BeginTx(ctx)
@@ -33,17 +134,30 @@ func (u *OrderUsecase) CreateOrders(ctx context.Context, req *model.CreateOrders
 
 	// Create order within transaction
 	createOrders := &model.Orders{
-		PaymentStatus: int8(1),
-		CustomerID:    req.CustomerID,
-		PlatformID:    req.PlatformID,
-		RetailStoreID: req.RetailStoreID,
-		PaymentID:     req.PaymentID,
+		PaymentStatus:     paymentStatus,
+		CustomerID:        req.CustomerID,
+		ShippingAddressID: shippingAddressID,
+		ShippingAddress:   shippingAddress,
+		PlatformID:        req.PlatformID,
+		RetailStoreID:     req.RetailStoreID,
+		PaymentID:         req.PaymentID,
+		OrderAmounts:      *amounts,
 	}
 
 	orders, err := u.orderRepo.Create(ctx, tx, createOrders)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
+	if err := u.loyaltyUsecase.Redeem(ctx, tx, orders, req.RedeemPoints); err != nil {
+		return nil, err
+	}
+
+	for _, fee := range fees {
+		fee.OrderID = orders.ID
+	}
+	if err := u.orderRepo.CreateFees(ctx, tx, fees); err != nil {
+		return nil, fmt.Errorf("failed to create order fees: %w", err)
+	}
 
 	// Create order items within transaction
 	var createItems []*model.OrderItems
@@ -70,13 +184,20 @@ func (u *OrderUsecase) CreateOrders(ctx context.Context, req *model.CreateOrders
 	if err := u.orderRepo.ReduceStocksBatch(ctx, tx, stockUpdates); err != nil {
 		return nil, fmt.Errorf("failed to reduce stocks: %w", err)
 	}
-	if err := u.orderRepo.CreateOrderStatus(ctx, tx, &model.OrderStatus{
-		Status:      1,
-		Description: "created new orders",
-		OrderID:     orders.ID,
-	}); err != nil {
+	status.OrderID = orders.ID
+	if err := u.orderRepo.CreateOrderStatus(ctx, tx, status); err != nil {
 		return nil, fmt.Errorf("failed to create order status: %w", err)
 	}
+	if status.Status == int8(model.OrderStatusCompleted) {
+		if err := u.loyaltyUsecase.EarnForOrder(ctx, tx, orders); err != nil {
+			return nil, err
+		}
+	}
+	if beforeCommit != nil {
+		if err := beforeCommit(ctx, tx, orders); err != nil {
+			return nil, err
+		}
+	}
 
 	// Commit transaction - all operations succeeded atomically
 	if err := tx.Commit(); err != nil {
@@ -85,13 +206,75 @@ func (u *OrderUsecase) CreateOrders(ctx context.Context, req *model.CreateOrders
 
 	// Combine results
 	orders.Items = items
+	orders.Fees = fees
+	u.auditUsecase.Record(ctx, model.AuditActionCreate, model.AuditEntityOrder, orders.ID, nil, orders)
 
 	return orders, nil
 }
 
-func (u *OrderUsecase) validateCreateOrder(ctx context.Context, req *model.CreateOrders) error {
+// resolveShippingAddress returns the address the order ships to and the snapshot stored on the order
+// An address id must belong to the customer, without any address the customer default shipping address is used when useDefault is set
+func (u *OrderUsecase) resolveShippingAddress(
+	ctx context.Context,
+	req *model.CreateOrders,
+	useDefault bool,
+) (*int64, *model.AddressFields, error) {
+	if req.ShippingAddressID != nil && req.ShippingAddress != nil {
+		return nil, nil, apperror.InvalidField("shipping_address_id", "excluded_with", "shipping_address_id and shipping_address can't be used together")
+	}
+	if req.ShippingAddress != nil {
+		fields := trimAddressFields(*req.ShippingAddress)
+		if fields.Street == "" {
+			return nil, nil, apperror.InvalidField("shipping_address.street", "required", "shipping address street is required")
+		}
+		return nil, &fields, nil
+	}
+	if req.CustomerID == 0 {
+		if req.ShippingAddressID != nil {
+			return nil, nil, apperror.InvalidField("shipping_address_id", "walk_in", "walk-in orders can't ship to a customer address")
+		}
+		return nil, nil, nil
+	}
+
+	var (
+		address *model.CustomerAddress
+		err     error
+	)
+	if req.ShippingAddressID != nil {
+		address, err = u.addressRepo.GetByID(ctx, req.CustomerID, *req.ShippingAddressID)
+	} else if useDefault {
+		address, err = u.addressRepo.GetDefaultShipping(ctx, req.CustomerID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if address == nil {
+		return nil, nil, nil
+	}
+	fields := address.AddressFields
+	return &address.ID, &fields, nil
+}
+
+func (u *OrderUsecase) validateCreateOrder(ctx context.Context, req *model.CreateOrders) ([]*model.OrdersProduct, error) {
 	if len(req.Items) <= 0 {
-		return fmt.Errorf("invalid request")
+		return nil, apperror.InvalidField("items", "min", "at least one item is required")
+	}
+	retailStore, err := u.retailStoreRepo.GetByID(ctx, req.RetailStoreID)
+	if err != nil {
+		return nil, err
+	}
+	if !retailStore.IsActive {
+		return nil, apperror.Conflict("retail store %s is inactive", retailStore.Name)
+	}
+	paymentMethod, err := u.paymentRepo.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !paymentMethod.IsActive {
+		return nil, apperror.Conflict("payment method %s is inactive", paymentMethod.Name)
+	}
+	if !paymentMethod.AllowedFor(req.PlatformID, req.RetailStoreID) {
+		return nil, apperror.Validation("payment method %s is not allowed for this platform or store", paymentMethod.Name)
 	}
 	var (
 		ProductVariantIDs []int64
@@ -99,7 +282,11 @@ func (u *OrderUsecase) validateCreateOrder(ctx context.Context, req *model.Creat
 	)
 	stockQuantityMap := make(map[int64]int64)
 
-	for _, r := range req.Items {
+	for i, r := range req.Items {
+		// Amounts, fees and loyalty points are derived from the quantities, imported orders skip the request validation
+		if r.Quantity <= 0 {
+			return nil, apperror.InvalidField(fmt.Sprintf("items[%d].quantity", i), "gt", "quantity must be greater than 0")
+		}
 		ProductVariantIDs = append(ProductVariantIDs, r.ProductVariantID)
 		priceIDs = append(priceIDs, r.PriceID)
 		stockQuantityMap[r.ProductVariantID] = r.Quantity
@@ -108,29 +295,99 @@ func (u *OrderUsecase) validateCreateOrder(ctx context.Context, req *model.Creat
 	stocks, err := u.orderRepo.GetStocks(ctx, priceIDs, ProductVariantIDs)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if len(stocks) == 0 {
-		return fmt.Errorf("price don't match with variant")
+		return nil, apperror.Validation("price don't match with variant")
 	}
 
 	for _, stock := range stocks {
 		if stock.Status != 1 {
-			return fmt.Errorf("the product %s, %v have status inactive", stock.Name, stock.Value)
+			return nil, apperror.Conflict("the product %s, %v have status inactive", stock.Name, stock.Value)
 		}
 		requestQuantity, exist := stockQuantityMap[stock.VariantValueID]
 		if !exist {
-			return fmt.Errorf("price don't match the variant value")
+			return nil, apperror.Validation("price don't match the variant value")
 		}
 		if requestQuantity > int64(stock.StockQuantity) {
-			return fmt.Errorf("out of stock")
+			return nil, apperror.OutOfStock("out of stock")
 		}
 	}
-	return nil
+	return stocks, nil
+}
+
+// calculateAmounts computes subtotal, total and COGS from the item prices
+func (u *OrderUsecase) calculateAmounts(req *model.CreateOrders, stocks []*model.OrdersProduct) (*model.OrderAmounts, error) {
+	amounts := &model.OrderAmounts{}
+	for _, item := range req.Items {
+		var matched *model.OrdersProduct
+		for _, stock := range stocks {
+			if stock.PriceID == item.PriceID && stock.VariantValueID == item.ProductVariantID {
+				matched = stock
+				break
+			}
+		}
+		if matched == nil {
+			return nil, apperror.Validation("price don't match the variant value")
+		}
+		amounts.SubtotalAmount += matched.Price * float64(item.Quantity)
+		amounts.CogsAmount += matched.CostPrice * float64(item.Quantity)
+	}
+
+	if req.DiscountAmount > amounts.SubtotalAmount {
+		return nil, apperror.InvalidField("discount_amount", "lte", "discount can't be greater than the order subtotal")
+	}
+	amounts.SubtotalAmount = utils.RoundMoney(amounts.SubtotalAmount)
+	amounts.CogsAmount = utils.RoundMoney(amounts.CogsAmount)
+	amounts.DiscountAmount = utils.RoundMoney(req.DiscountAmount)
+	amounts.TotalAmount = amounts.SubtotalAmount - amounts.DiscountAmount
+	return amounts, nil
 }
 
+// calculateChannelFees applies the platform commission and fee rules to the order total
+// and fills the commission and channel fee amounts
+func calculateChannelFees(config model.PlatformConfig, amounts *model.OrderAmounts) []*model.OrderFee {
+	var fees []*model.OrderFee
+	if config.CommissionRate > 0 {
+		fees = append(fees, &model.OrderFee{
+			Name:         "commission",
+			Type:         model.PlatformFeeTypePercentage,
+			Rate:         config.CommissionRate,
+			Amount:       utils.RoundMoney(amounts.TotalAmount * config.CommissionRate / 100),
+			IsCommission: true,
+		})
+	}
+	for _, rule := range config.Fees {
+		amount := rule.Value
+		if rule.Type == model.PlatformFeeTypePercentage {
+			amount = amounts.TotalAmount * rule.Value / 100
+		}
+		fees = append(fees, &model.OrderFee{
+			Name:   rule.Name,
+			Type:   rule.Type,
+			Rate:   rule.Value,
+			Amount: utils.RoundMoney(amount),
+		})
+	}
+
+	for _, fee := range fees {
+		if fee.IsCommission {
+			amounts.CommissionAmount += fee.Amount
+		} else {
+			amounts.ChannelFeeAmount += fee.Amount
+		}
+	}
+	amounts.ChannelFeeAmount = utils.RoundMoney(amounts.ChannelFeeAmount)
+	return fees
+}
+
+// GetOrdersPage lists the orders of the stores the user can see
 func (u *OrderUsecase) GetOrdersPage(ctx context.Context) ([]*model.OrdersPage, error) {
-	orders, err := u.orderRepo.GetOrdersPage(ctx)
+	storeIDs := storeScope(ctx, model.PermissionOrdersRead)
+	if storeIDs != nil && len(storeIDs) == 0 {
+		return []*model.OrdersPage{}, nil
+	}
+	orders, err := u.orderRepo.GetOrdersPage(ctx, storeIDs)
 
 	if err != nil {
 		return nil, err
@@ -138,13 +395,64 @@ func (u *OrderUsecase) GetOrdersPage(ctx context.Context) ([]*model.OrdersPage,
 	return orders, nil
 }
 
+func (u *OrderUsecase) GetOrderRevenue(ctx context.Context, orderID int64) (*model.OrderRevenue, error) {
+	if orderID <= 0 {
+		return nil, apperror.Validation("invalid order id")
+	}
+	if err := u.authorizeOrder(ctx, model.PermissionOrdersRead, orderID); err != nil {
+		return nil, err
+	}
+	return u.orderRepo.GetOrderRevenue(ctx, orderID)
+}
+
+// GetRevenueByPlatform aggregates net revenue per platform between two dates (YYYY-MM-DD, inclusive)
+func (u *OrderUsecase) GetRevenueByPlatform(ctx context.Context, filter *model.RevenueFilter) ([]*model.PlatformRevenue, error) {
+	from, to, err := parseDateRange(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+	storeIDs := storeScope(ctx, model.PermissionOrdersRead)
+	if storeIDs != nil && len(storeIDs) == 0 {
+		return []*model.PlatformRevenue{}, nil
+	}
+	return u.orderRepo.GetRevenueByPlatform(ctx, from, to, storeIDs)
+}
+
+// parseDateRange parses optional YYYY-MM-DD dates, both inclusive, into a [from, to) range
+func parseDateRange(fromDate, toDate string) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if fromDate != "" {
+		date, err := time.Parse(time.DateOnly, fromDate)
+		if err != nil {
+			return nil, nil, apperror.InvalidField("from", "datetime", "invalid from date, expected YYYY-MM-DD")
+		}
+		from = &date
+	}
+	if toDate != "" {
+		date, err := time.Parse(time.DateOnly, toDate)
+		if err != nil {
+			return nil, nil, apperror.InvalidField("to", "datetime", "invalid to date, expected YYYY-MM-DD")
+		}
+		// Inclusive: up to the start of the next day
+		nextDay := date.AddDate(0, 0, 1)
+		to = &nextDay
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, apperror.InvalidField("to", "gtfield", "from must be before to")
+	}
+	return from, to, nil
+}
+
 func (u *OrderUsecase) UpdateOrderStatus(
 	ctx context.Context,
 	status int8,
 	orderID int64) error {
 
-	if status < 1 || status > 5 {
-		return fmt.Errorf("invalid status: must be between 1-5")
+	if status < 1 || status > int8(model.OrderStatusReturned) {
+		return apperror.InvalidField("status", "range", "invalid status: must be between 1-%d", model.OrderStatusReturned)
+	}
+	if err := u.authorizeOrder(ctx, model.PermissionOrdersWrite, orderID); err != nil {
+		return err
 	}
 
 	// Start transaction for status update
@@ -167,7 +475,7 @@ func (u *OrderUsecase) UpdateOrderStatus(
 	// Create new order status record
 	newStatus := &model.OrderStatus{
 		Status:      status,
-		Description: u.getStatusDescription(status),
+		Description: statusDescription(status),
 		OrderID:     orderID,
 	}
 
@@ -175,19 +483,56 @@ func (u *OrderUsecase) UpdateOrderStatus(
 		return fmt.Errorf("failed to create order status: %w", err)
 	}
 
+	// Paid orders are counted in the cash sales of the shift, completed orders earn loyalty points,
+	// canceled and returned orders give them back
+	switch model.OrderStatusItem(status) {
+	case model.OrderStatusPaid:
+		if err := u.orderRepo.SetPaymentStatus(ctx, tx, orderID, model.PaymentStatusPaid); err != nil {
+			return err
+		}
+	case model.OrderStatusCompleted:
+		order, err := u.orderRepo.GetByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if err := u.loyaltyUsecase.EarnForOrder(ctx, tx, order); err != nil {
+			return err
+		}
+	case model.OrderStatusCanceled, model.OrderStatusReturned:
+		if err := u.loyaltyUsecase.ReverseOrder(ctx, tx, orderID); err != nil {
+			return err
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityOrder, orderID,
+		map[string]interface{}{"status": latestStatus.Status},
+		map[string]interface{}{"status": status},
+	)
 
 	return nil
 }
 
+// authorizeOrder checks the user has the permission in the store of the order
+func (u *OrderUsecase) authorizeOrder(ctx context.Context, permission model.Permission, orderID int64) error {
+	if model.PrincipalFromContext(ctx) == nil {
+		return nil
+	}
+	order, err := u.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	return authorizeStore(ctx, permission, order.RetailStoreID)
+}
+
 // validateStatusTransition checks if status transition is allowed
 func (u *OrderUsecase) validateStatusTransition(currentStatus, newStatus int8) error {
 	// Can't transition to the same status
 	if currentStatus == newStatus {
-		return fmt.Errorf("order is already in status %d", currentStatus)
+		return apperror.InvalidTransition("order is already in status %d", currentStatus)
 	}
 
 	// Define valid transitions map
@@ -204,13 +549,16 @@ func (u *OrderUsecase) validateStatusTransition(currentStatus, newStatus int8) e
 			int8(model.OrderStatusCompleted),
 			int8(model.OrderStatusCanceled),
 		},
-		int8(model.OrderStatusCompleted): {}, // Final state - no transitions allowed
-		int8(model.OrderStatusCanceled):  {}, // Final state - no transitions allowed
+		int8(model.OrderStatusCompleted): {
+			int8(model.OrderStatusReturned),
+		},
+		int8(model.OrderStatusCanceled): {}, // Final state - no transitions allowed
+		int8(model.OrderStatusReturned): {}, // Final state - no transitions allowed
 	}
 
 	allowedStatuses, exists := validTransitions[currentStatus]
 	if !exists {
-		return fmt.Errorf("unknown current status: %d", currentStatus)
+		return apperror.InvalidTransition("unknown current status: %d", currentStatus)
 	}
 
 	// Check if new status is in allowed transitions
@@ -221,11 +569,11 @@ func (u *OrderUsecase) validateStatusTransition(currentStatus, newStatus int8) e
 	}
 
 	// Invalid transition
-	return fmt.Errorf("cannot transition from status %d to %d", currentStatus, newStatus)
+	return apperror.InvalidTransition("cannot transition from status %d to %d", currentStatus, newStatus)
 }
 
-// getStatusDescription returns description based on status
-func (u *OrderUsecase) getStatusDescription(status int8) string {
+// statusDescription returns the English description of the status, stored as is and translated when responding
+func statusDescription(status int8) string {
 	switch status {
 	case int8(model.OrderStatusPending):
 		return "Order is pending payment"
@@ -237,6 +585,8 @@ func (u *OrderUsecase) getStatusDescription(status int8) string {
 		return "Order completed and delivered"
 	case int8(model.OrderStatusCanceled):
 		return "Order has been canceled"
+	case int8(model.OrderStatusReturned):
+		return "Order has been returned"
 	default:
 		return "Status updated"
 	}
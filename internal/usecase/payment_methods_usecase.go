@@ -2,20 +2,70 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
+	"strings"
 )
 
 type PaymentMethodsUsecase struct {
 	PaymentMethodsRepo *repository.PaymentMethodsRepository
+	platformRepo       *repository.PlatformRepository
+	retailStoreRepo    *repository.RetailStoreRepository
 }
 
-func NewPaymentMethodsUsecase(PaymentMethodsR *repository.PaymentMethodsRepository) *PaymentMethodsUsecase {
+func NewPaymentMethodsUsecase(
+	PaymentMethodsR *repository.PaymentMethodsRepository,
+	platformRepo *repository.PlatformRepository,
+	retailStoreRepo *repository.RetailStoreRepository,
+) *PaymentMethodsUsecase {
 	return &PaymentMethodsUsecase{
 		PaymentMethodsRepo: PaymentMethodsR,
+		platformRepo:       platformRepo,
+		retailStoreRepo:    retailStoreRepo,
 	}
 }
 
+func (r *PaymentMethodsUsecase) Create(ctx context.Context, req *model.CreatePaymentMethodRequest) (*model.PaymentMethods, error) {
+	paymentMethod := &model.PaymentMethods{
+		Name:        strings.TrimSpace(req.Name),
+		Code:        strings.ToUpper(strings.TrimSpace(req.Code)),
+		Description: strings.TrimSpace(req.Description),
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+	if paymentMethod.Name == "" {
+		return nil, apperror.InvalidField("name", "required", "name is required")
+	}
+	if err := r.ensureUniqueCode(ctx, paymentMethod.Code, 0); err != nil {
+		return nil, err
+	}
+	if err := r.validateRestrictions(ctx, req.PlatformIDs, req.RetailStoreIDs); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.PaymentMethodsRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.PaymentMethodsRepo.Create(ctx, tx, paymentMethod); err != nil {
+		return nil, err
+	}
+	if err := r.PaymentMethodsRepo.ReplacePlatforms(ctx, tx, paymentMethod.ID, req.PlatformIDs); err != nil {
+		return nil, err
+	}
+	if err := r.PaymentMethodsRepo.ReplaceRetailStores(ctx, tx, paymentMethod.ID, req.RetailStoreIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.PaymentMethodsRepo.GetByID(ctx, paymentMethod.ID)
+}
+
 func (r *PaymentMethodsUsecase) GetAll(ctx context.Context) ([]*model.PaymentMethods, error) {
 	PaymentMethods, err := r.PaymentMethodsRepo.GetAll(ctx)
 	if err != nil {
@@ -24,3 +74,116 @@ func (r *PaymentMethodsUsecase) GetAll(ctx context.Context) ([]*model.PaymentMet
 
 	return PaymentMethods, nil
 }
+
+func (r *PaymentMethodsUsecase) GetByID(ctx context.Context, id int64) (*model.PaymentMethods, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	return r.PaymentMethodsRepo.GetByID(ctx, id)
+}
+
+func (r *PaymentMethodsUsecase) Update(ctx context.Context, id int64, req *model.UpdatePaymentMethodRequest) (*model.PaymentMethods, error) {
+	paymentMethod, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		paymentMethod.Name = strings.TrimSpace(*req.Name)
+		if paymentMethod.Name == "" {
+			return nil, apperror.InvalidField("name", "required", "name is required")
+		}
+	}
+	if req.Code != nil {
+		paymentMethod.Code = strings.ToUpper(strings.TrimSpace(*req.Code))
+		if err := r.ensureUniqueCode(ctx, paymentMethod.Code, id); err != nil {
+			return nil, err
+		}
+	}
+	if req.Description != nil {
+		paymentMethod.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.IsActive != nil {
+		paymentMethod.IsActive = *req.IsActive
+	}
+
+	var platformIDs, retailStoreIDs []int64
+	if req.PlatformIDs != nil {
+		platformIDs = *req.PlatformIDs
+	}
+	if req.RetailStoreIDs != nil {
+		retailStoreIDs = *req.RetailStoreIDs
+	}
+	if err := r.validateRestrictions(ctx, platformIDs, retailStoreIDs); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.PaymentMethodsRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.PaymentMethodsRepo.Update(ctx, tx, paymentMethod); err != nil {
+		return nil, err
+	}
+	if req.PlatformIDs != nil {
+		if err := r.PaymentMethodsRepo.ReplacePlatforms(ctx, tx, id, platformIDs); err != nil {
+			return nil, err
+		}
+	}
+	if req.RetailStoreIDs != nil {
+		if err := r.PaymentMethodsRepo.ReplaceRetailStores(ctx, tx, id, retailStoreIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return r.PaymentMethodsRepo.GetByID(ctx, id)
+}
+
+// Delete removes a payment method that was never used, used ones must be deactivated instead
+func (r *PaymentMethodsUsecase) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return apperror.Validation("invalid id")
+	}
+	count, err := r.PaymentMethodsRepo.CountOrders(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return apperror.Conflict("payment method is used by %d orders, deactivate it instead", count)
+	}
+	return r.PaymentMethodsRepo.Delete(ctx, id)
+}
+
+func (r *PaymentMethodsUsecase) ensureUniqueCode(ctx context.Context, code string, excludeID int64) error {
+	if code == "" {
+		return apperror.InvalidField("code", "required", "code is required")
+	}
+	exists, err := r.PaymentMethodsRepo.ExistsByCode(ctx, code, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperror.Conflict("payment method code %s already exists", code)
+	}
+	return nil
+}
+
+// validateRestrictions checks the restricted platforms and stores exist
+func (r *PaymentMethodsUsecase) validateRestrictions(ctx context.Context, platformIDs, retailStoreIDs []int64) error {
+	for _, platformID := range platformIDs {
+		if _, err := r.platformRepo.GetByID(ctx, platformID); err != nil {
+			return fmt.Errorf("platform %d: %w", platformID, err)
+		}
+	}
+	for _, retailStoreID := range retailStoreIDs {
+		if _, err := r.retailStoreRepo.GetByID(ctx, retailStoreID); err != nil {
+			return fmt.Errorf("retail store %d: %w", retailStoreID, err)
+		}
+	}
+	return nil
+}
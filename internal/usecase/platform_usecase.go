@@ -2,10 +2,18 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
+	"simple-template/pkg/jsonschema"
+	"strings"
 )
 
+// platformConfigSchema validates the config of every created or updated platform
+var platformConfigSchema = jsonschema.MustCompile(model.PlatformConfigSchema)
+
 type PlatformUsecase struct {
 	platformRepo *repository.PlatformRepository
 }
@@ -16,6 +24,27 @@ func NewPlatformUsecase(platformR *repository.PlatformRepository) *PlatformUseca
 	}
 }
 
+func (r *PlatformUsecase) Create(ctx context.Context, req *model.CreatePlatformRequest) (*model.Platform, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, apperror.InvalidField("name", "required", "name is required")
+	}
+	if err := r.ensureUniqueName(ctx, name, 0); err != nil {
+		return nil, err
+	}
+
+	config, err := parsePlatformConfig(req.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.platformRepo.Create(ctx, &model.Platform{
+		Name:        name,
+		ApiEndpoint: strings.TrimSpace(req.ApiEndpoint),
+		Config:      *config,
+	})
+}
+
 func (r *PlatformUsecase) GetAll(ctx context.Context) ([]*model.Platform, error) {
 	platforms, err := r.platformRepo.GetAll(ctx)
 	if err != nil {
@@ -24,3 +53,113 @@ func (r *PlatformUsecase) GetAll(ctx context.Context) ([]*model.Platform, error)
 
 	return platforms, nil
 }
+
+func (r *PlatformUsecase) GetByID(ctx context.Context, id int64) (*model.Platform, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	return r.platformRepo.GetByID(ctx, id)
+}
+
+func (r *PlatformUsecase) Update(ctx context.Context, id int64, req *model.UpdatePlatformRequest) (*model.Platform, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	platform, err := r.platformRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, apperror.InvalidField("name", "required", "name is required")
+		}
+		if err := r.ensureUniqueName(ctx, name, id); err != nil {
+			return nil, err
+		}
+		platform.Name = name
+	}
+	if req.ApiEndpoint != nil {
+		platform.ApiEndpoint = strings.TrimSpace(*req.ApiEndpoint)
+	}
+	if len(req.Config) > 0 {
+		config, err := parsePlatformConfig(req.Config)
+		if err != nil {
+			return nil, err
+		}
+		platform.Config = *config
+	}
+
+	if err := r.platformRepo.Update(ctx, platform); err != nil {
+		return nil, err
+	}
+	return r.platformRepo.GetByID(ctx, id)
+}
+
+func (r *PlatformUsecase) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return apperror.Validation("invalid id")
+	}
+	return r.platformRepo.Delete(ctx, id)
+}
+
+func (r *PlatformUsecase) ensureUniqueName(ctx context.Context, name string, excludeID int64) error {
+	exists, err := r.platformRepo.ExistsByName(ctx, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperror.Conflict("platform %s already exists", name)
+	}
+	return nil
+}
+
+// platformConfigRules maps the JSON schema keywords to the rules and messages of request validation
+var platformConfigRules = map[string]struct{ rule, format string }{
+	"type":                 {"type", "%[1]s must be of type %[2]s"},
+	"enum":                 {"oneof", "%[1]s must be one of %[2]s"},
+	"minimum":              {"gte", "%[1]s must be at least %[2]s"},
+	"exclusiveMinimum":     {"gt", "%[1]s must be greater than %[2]s"},
+	"maximum":              {"lte", "%[1]s must be at most %[2]s"},
+	"minLength":            {"min", "%[1]s must be at least %[2]s characters long"},
+	"maxLength":            {"max", "%[1]s must be at most %[2]s characters long"},
+	"pattern":              {"pattern", "%[1]s must match the pattern %[2]s"},
+	"minItems":             {"min", "%[1]s must have at least %[2]s items"},
+	"maxItems":             {"max", "%[1]s must have at most %[2]s items"},
+	"uniqueItems":          {"unique", "%[1]s must not contain duplicate items"},
+	"required":             {"required", "%[1]s is required"},
+	"additionalProperties": {"unknown", "%[1]s is not a known field"},
+}
+
+// parsePlatformConfig validates the raw config against the schema before decoding it,
+// every violation is reported on its field under config
+func parsePlatformConfig(raw json.RawMessage) (*model.PlatformConfig, error) {
+	if len(raw) == 0 {
+		return nil, apperror.InvalidField("config", "required", "config is required")
+	}
+	var violations jsonschema.ValidationErrors
+	if err := platformConfigSchema.Validate(raw); errors.As(err, &violations) {
+		fields := make([]apperror.FieldError, 0, len(violations))
+		for _, violation := range violations {
+			field := "config"
+			if violation.Path != "" {
+				field += "." + violation.Path
+			}
+			rule, known := platformConfigRules[violation.Keyword]
+			if !known {
+				rule.rule, rule.format = "invalid", "%[1]s is invalid"
+			}
+			fieldError := apperror.Field(field, rule.rule, rule.format, field, violation.Param)
+			fieldError.Param = violation.Param
+			fields = append(fields, fieldError)
+		}
+		return nil, apperror.Invalid(fields...)
+	}
+
+	var config model.PlatformConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, apperror.Validation("invalid config: %s", err.Error())
+	}
+	return &config, nil
+}
@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/internal/utils"
+	"strings"
+	"time"
+)
+
+type PosUsecase struct {
+	posRepo         *repository.PosRepository
+	orderUsecase    *OrderUsecase
+	platformRepo    *repository.PlatformRepository
+	retailStoreRepo *repository.RetailStoreRepository
+	paymentRepo     *repository.PaymentMethodsRepository
+	customerRepo    *repository.CustomerRepository
+	loyaltyUsecase  *LoyaltyUsecase
+}
+
+func NewPosUsecase(
+	posRepo *repository.PosRepository,
+	orderUsecase *OrderUsecase,
+	platformRepo *repository.PlatformRepository,
+	retailStoreRepo *repository.RetailStoreRepository,
+	paymentRepo *repository.PaymentMethodsRepository,
+	customerRepo *repository.CustomerRepository,
+	loyaltyUsecase *LoyaltyUsecase,
+) *PosUsecase {
+	return &PosUsecase{
+		posRepo:         posRepo,
+		orderUsecase:    orderUsecase,
+		platformRepo:    platformRepo,
+		retailStoreRepo: retailStoreRepo,
+		paymentRepo:     paymentRepo,
+		customerRepo:    customerRepo,
+		loyaltyUsecase:  loyaltyUsecase,
+	}
+}
+
+// Lookup finds the item of a scanned barcode or SKU
+func (u *PosUsecase) Lookup(ctx context.Context, code string) (*model.PosItem, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, apperror.InvalidField("code", "required", "code is required")
+	}
+	items, err := u.posRepo.FindItemsByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	switch len(items) {
+	case 0:
+		return nil, apperror.NotFound("no item found for code %s", code)
+	case 1:
+		return items[0], nil
+	default:
+		return nil, apperror.Validation("code %s matches %d variants of %s, scan the variant code", code, len(items), items[0].ProductName)
+	}
+}
+
+// BuildCart prices the scanned items, the same item scanned twice is merged into one line
+func (u *PosUsecase) BuildCart(ctx context.Context, req *model.PosCartRequest) (*model.PosCart, error) {
+	if len(req.Items) == 0 {
+		return nil, apperror.InvalidField("items", "min", "cart is empty")
+	}
+	if err := authorizeStore(ctx, model.PermissionPosSell, req.RetailStoreID); err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, item := range req.Items {
+		if item.VariantValueID > 0 {
+			ids = append(ids, item.VariantValueID)
+		}
+	}
+	byID := make(map[int64]*model.PosItem)
+	items, err := u.posRepo.GetItemsByVariantValueIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		byID[item.VariantValueID] = item
+	}
+
+	cart := &model.PosCart{RetailStoreID: req.RetailStoreID, Lines: []*model.PosCartLine{}}
+	lines := make(map[int64]*model.PosCartLine)
+	for _, reqItem := range req.Items {
+		var item *model.PosItem
+		if reqItem.VariantValueID > 0 {
+			item = byID[reqItem.VariantValueID]
+			if item == nil {
+				return nil, apperror.NotFound("variant value %d not found", reqItem.VariantValueID)
+			}
+		} else {
+			item, err = u.Lookup(ctx, reqItem.Code)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if item.PriceID == 0 {
+			return nil, apperror.Validation("%s %s has no active price", item.ProductName, item.Value)
+		}
+
+		line, exists := lines[item.VariantValueID]
+		if !exists {
+			line = &model.PosCartLine{PosItem: *item}
+			lines[item.VariantValueID] = line
+			cart.Lines = append(cart.Lines, line)
+		}
+		line.Quantity += reqItem.Quantity
+	}
+
+	for _, line := range cart.Lines {
+		if line.Quantity > int64(line.StockQuantity) {
+			return nil, apperror.OutOfStock("%s %s: only %d left in stock", line.ProductName, line.Value, line.StockQuantity)
+		}
+		line.LineTotal = utils.RoundMoney(line.Price * float64(line.Quantity))
+		cart.SubtotalAmount += line.LineTotal
+	}
+	cart.SubtotalAmount = utils.RoundMoney(cart.SubtotalAmount)
+	if req.DiscountAmount > cart.SubtotalAmount {
+		return nil, apperror.InvalidField("discount_amount", "lte", "discount can't be greater than the cart subtotal")
+	}
+	cart.DiscountAmount = utils.RoundMoney(req.DiscountAmount)
+	cart.TotalAmount = utils.RoundMoney(cart.SubtotalAmount - cart.DiscountAmount)
+	return cart, nil
+}
+
+// Checkout captures the payment and records the sale as a completed offline order
+func (u *PosUsecase) Checkout(ctx context.Context, req *model.PosCheckoutRequest) (*model.Receipt, error) {
+	cart, err := u.BuildCart(ctx, &req.PosCartRequest)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := u.platformRepo.GetByName(ctx, model.PlatformOffline)
+	if err != nil {
+		return nil, err
+	}
+	if req.CustomerID > 0 {
+		if _, err := u.customerRepo.GetByID(ctx, req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	paymentMethod, err := u.paymentRepo.GetByID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	// Redeemed points lower what is left to pay, the order usecase adds them to the discount again
+	manualDiscount := cart.DiscountAmount
+	loyaltyDiscount, err := u.loyaltyUsecase.RedemptionDiscount(ctx, req.CustomerID, req.RedeemPoints, &model.OrderAmounts{
+		SubtotalAmount: cart.SubtotalAmount,
+		DiscountAmount: cart.DiscountAmount,
+		TotalAmount:    cart.TotalAmount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cart.DiscountAmount = utils.RoundMoney(cart.DiscountAmount + loyaltyDiscount)
+	cart.TotalAmount = utils.RoundMoney(cart.TotalAmount - loyaltyDiscount)
+
+	payment := &model.PosPayment{
+		PaymentID:      paymentMethod.ID,
+		AmountDue:      cart.TotalAmount,
+		AmountTendered: cart.TotalAmount,
+	}
+	if paymentMethod.Code == model.PaymentMethodCodeCash {
+		if req.AmountTendered == nil {
+			return nil, apperror.InvalidField("amount_tendered", "required", "amount tendered is required for cash payments")
+		}
+		tendered := utils.RoundMoney(*req.AmountTendered)
+		if tendered < cart.TotalAmount {
+			return nil, apperror.InvalidField("amount_tendered", "gte", "amount tendered %.2f is less than the total %.2f", tendered, cart.TotalAmount)
+		}
+		payment.AmountTendered = tendered
+		payment.ChangeAmount = utils.RoundMoney(tendered - cart.TotalAmount)
+	}
+
+	createOrder := &model.CreateOrders{
+		CustomerID:     req.CustomerID,
+		PlatformID:     platform.ID,
+		RetailStoreID:  req.RetailStoreID,
+		PaymentID:      paymentMethod.ID,
+		DiscountAmount: manualDiscount,
+		RedeemPoints:   req.RedeemPoints,
+	}
+	for _, line := range cart.Lines {
+		createOrder.Items = append(createOrder.Items, model.CreateOrderItems{
+			Quantity:         line.Quantity,
+			ProductVariantID: line.VariantValueID,
+			PriceID:          line.PriceID,
+		})
+	}
+
+	// Store, payment method restrictions and stock are validated again by the order usecase
+	order, err := u.orderUsecase.CreateCompletedOrder(ctx, createOrder, func(ctx context.Context, tx *sql.Tx, order *model.Orders) error {
+		payment.OrderID = order.ID
+		return u.posRepo.CreatePayment(ctx, tx, payment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.GetReceipt(ctx, order.ID)
+}
+
+func (u *PosUsecase) GetReceipt(ctx context.Context, orderID int64) (*model.Receipt, error) {
+	if orderID <= 0 {
+		return nil, apperror.Validation("invalid order id")
+	}
+	receipt, err := u.posRepo.GetReceipt(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeStore(ctx, model.PermissionPosSell, receipt.RetailStoreID); err != nil {
+		return nil, err
+	}
+	retailStore, err := u.retailStoreRepo.GetByID(ctx, receipt.RetailStoreID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt.ReceiptNumber = fmt.Sprintf("POS-%d-%08d", retailStore.ID, receipt.OrderID)
+	receipt.StoreName = retailStore.Name
+	receipt.StoreAddress = retailStore.Address
+	receipt.StorePhone = retailStore.PhoneNumber
+	if location, err := time.LoadLocation(retailStore.Timezone); err == nil {
+		receipt.IssuedAt = receipt.IssuedAt.In(location)
+	}
+	return receipt, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
 	"simple-template/internal/utils"
@@ -15,12 +16,14 @@ import (
 
 type ProductUsecase struct {
 	productRepo       *repository.ProductRepository
+	auditUsecase      *AuditUsecase
 	paginationService *pagination.Service
 }
 
-func NewProductUsecase(productRepo *repository.ProductRepository) *ProductUsecase {
+func NewProductUsecase(productRepo *repository.ProductRepository, auditUsecase *AuditUsecase) *ProductUsecase {
 	return &ProductUsecase{
 		productRepo:       productRepo,
+		auditUsecase:      auditUsecase,
 		paginationService: pagination.NewService(),
 	}
 }
@@ -61,6 +64,8 @@ func (u *ProductUsecase) CreateProduct(ctx context.Context, req *model.CreatePro
 		for _, reqValue := range reqVariant.Values {
 			value := &model.ProductVariantValue{
 				Value:         strings.TrimSpace(reqValue.Value),
+				SKU:           strings.TrimSpace(reqValue.SKU),
+				Barcode:       strings.TrimSpace(reqValue.Barcode),
 				DisplayOrder:  utils.DerefIntOrDefault(reqValue.DisplayOrder, 0),
 				StockQuantity: utils.DerefIntOrDefault(reqValue.StockQuantity, 0),
 			}
@@ -73,32 +78,38 @@ func (u *ProductUsecase) CreateProduct(ctx context.Context, req *model.CreatePro
 		return nil, fmt.Errorf("failed to create product %w", err)
 	}
 
-	return u.GetByID(ctx, product.ID)
+	createdProduct, err := u.GetByID(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.auditUsecase.Record(ctx, model.AuditActionCreate, model.AuditEntityProduct, createdProduct.ID, nil, createdProduct)
+
+	return createdProduct, nil
 }
 func (u *ProductUsecase) validateCreateProduct(req *model.CreateProductRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("product name is required")
+		return apperror.InvalidField("name", "required", "product name is required")
 	}
 
 	if strings.TrimSpace(req.SKU) == "" {
-		return fmt.Errorf("product SKU is required")
+		return apperror.InvalidField("sku", "required", "product SKU is required")
 	}
 
 	if req.CategoryID <= 0 {
-		return fmt.Errorf("valid category ID is required")
+		return apperror.InvalidField("category_id", "required", "valid category ID is required")
 	}
 
 	if len(req.Variants) == 0 {
-		return fmt.Errorf("at least one variant is required")
+		return apperror.InvalidField("variants", "min", "at least one variant is required")
 	}
 
 	for i, variant := range req.Variants {
 		if strings.TrimSpace(variant.Name) == "" {
-			return fmt.Errorf("variant %d: name is required", i+1)
+			return apperror.InvalidField(fmt.Sprintf("variants[%d].name", i), "required", "variant %d: name is required", i+1)
 		}
 
 		if variant.Price == nil || *variant.Price <= 0 {
-			return fmt.Errorf("variant %d: valid price is required", i+1)
+			return apperror.InvalidField(fmt.Sprintf("variants[%d].price", i), "gt", "variant %d: valid price is required", i+1)
 		}
 	}
 
@@ -190,7 +201,12 @@ func (u *ProductUsecase) GetAll(ctx context.Context, req *pagination.Request) (*
 
 func (u *ProductUsecase) Delete(ctx context.Context, id int64) error {
 	if id <= 0 {
-		return fmt.Errorf("invalid product id")
+		return apperror.Validation("invalid product id")
+	}
+
+	product, err := u.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	// Delete in correct order
@@ -208,10 +224,11 @@ func (u *ProductUsecase) Delete(ctx context.Context, id int64) error {
 
 	if err := u.productRepo.DeleteProductByID(ctx, id); err != nil {
 		if err == sql.ErrNoRows {
-			return fmt.Errorf("product not found")
+			return apperror.NotFound("product not found")
 		}
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionDelete, model.AuditEntityProduct, id, product, nil)
 
 	return nil
 }
@@ -2,25 +2,196 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"simple-template/internal/apperror"
 	"simple-template/internal/model"
 	"simple-template/internal/repository"
+	"strings"
+	"time"
 )
 
 type RetailStoreUsecase struct {
 	RetailStoreRepo *repository.RetailStoreRepository
+	userRepo        *repository.UserRepository
 }
 
-func NewRetailStoreUsecase(RetailStoreR *repository.RetailStoreRepository) *RetailStoreUsecase {
+func NewRetailStoreUsecase(RetailStoreR *repository.RetailStoreRepository, userRepo *repository.UserRepository) *RetailStoreUsecase {
 	return &RetailStoreUsecase{
 		RetailStoreRepo: RetailStoreR,
+		userRepo:        userRepo,
 	}
 }
 
-func (r *RetailStoreUsecase) GetAll(ctx context.Context) ([]*model.RetailStore, error) {
-	RetailStores, err := r.RetailStoreRepo.GetAll(ctx)
+func (r *RetailStoreUsecase) Create(ctx context.Context, req *model.CreateRetailStoreRequest) (*model.RetailStore, error) {
+	if err := authorizeStore(ctx, model.PermissionStoresWrite, 0); err != nil {
+		return nil, err
+	}
+	retailStore := &model.RetailStore{
+		Name:         strings.TrimSpace(req.Name),
+		PhoneNumber:  strings.TrimSpace(req.PhoneNumber),
+		Address:      strings.TrimSpace(req.Address),
+		Latitude:     req.Latitude,
+		Longitude:    req.Longitude,
+		Timezone:     strings.TrimSpace(req.Timezone),
+		OpeningHours: req.OpeningHours,
+	}
+	if retailStore.Timezone == "" {
+		retailStore.Timezone = model.DefaultStoreTimezone
+	}
+	if err := r.validateRetailStore(retailStore); err != nil {
+		return nil, err
+	}
+
+	return r.RetailStoreRepo.Create(ctx, retailStore)
+}
+
+// GetAll lists the stores the user can see
+func (r *RetailStoreUsecase) GetAll(ctx context.Context, includeInactive bool) ([]*model.RetailStore, error) {
+	storeIDs := storeScope(ctx, model.PermissionStoresRead)
+	if storeIDs != nil && len(storeIDs) == 0 {
+		return []*model.RetailStore{}, nil
+	}
+	RetailStores, err := r.RetailStoreRepo.GetAll(ctx, includeInactive, storeIDs)
 	if err != nil {
 		return nil, err
 	}
 
 	return RetailStores, nil
 }
+
+func (r *RetailStoreUsecase) GetByID(ctx context.Context, id int64) (*model.RetailStore, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid id")
+	}
+	if err := authorizeStore(ctx, model.PermissionStoresRead, id); err != nil {
+		return nil, err
+	}
+	return r.RetailStoreRepo.GetByID(ctx, id)
+}
+
+func (r *RetailStoreUsecase) Update(ctx context.Context, id int64, req *model.UpdateRetailStoreRequest) (*model.RetailStore, error) {
+	if err := authorizeStore(ctx, model.PermissionStoresWrite, id); err != nil {
+		return nil, err
+	}
+	retailStore, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		retailStore.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.PhoneNumber != nil {
+		retailStore.PhoneNumber = strings.TrimSpace(*req.PhoneNumber)
+	}
+	if req.Address != nil {
+		retailStore.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.Latitude != nil {
+		retailStore.Latitude = req.Latitude
+	}
+	if req.Longitude != nil {
+		retailStore.Longitude = req.Longitude
+	}
+	if req.Timezone != nil {
+		retailStore.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+	if req.OpeningHours != nil {
+		retailStore.OpeningHours = *req.OpeningHours
+	}
+	if err := r.validateRetailStore(retailStore); err != nil {
+		return nil, err
+	}
+
+	if err := r.RetailStoreRepo.Update(ctx, retailStore); err != nil {
+		return nil, err
+	}
+	return r.RetailStoreRepo.GetByID(ctx, id)
+}
+
+// SetActive deactivates or reactivates a store, stores are never deleted because orders reference them
+func (r *RetailStoreUsecase) SetActive(ctx context.Context, id int64, isActive bool) (*model.RetailStore, error) {
+	if err := authorizeStore(ctx, model.PermissionStoresWrite, id); err != nil {
+		return nil, err
+	}
+	retailStore, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if retailStore.IsActive == isActive {
+		return retailStore, nil
+	}
+
+	if err := r.RetailStoreRepo.SetActive(ctx, id, isActive); err != nil {
+		return nil, err
+	}
+	retailStore.IsActive = isActive
+	return retailStore, nil
+}
+
+func (r *RetailStoreUsecase) GetUsers(ctx context.Context, storeID int64) ([]*model.StoreUser, error) {
+	if _, err := r.GetByID(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return r.RetailStoreRepo.GetUsers(ctx, storeID)
+}
+
+// AssignUser gives the user a role in the store, admin is only given for every store
+func (r *RetailStoreUsecase) AssignUser(ctx context.Context, storeID int64, req *model.AssignStoreUserRequest) error {
+	if err := authorizeStore(ctx, model.PermissionStoresWrite, storeID); err != nil {
+		return err
+	}
+	role := req.Role
+	if role == "" {
+		role = model.RoleCashier
+	}
+	if !model.IsValidRole(role) || role == model.RoleAdmin {
+		return apperror.Validation("invalid store role %s", role)
+	}
+	retailStore, err := r.GetByID(ctx, storeID)
+	if err != nil {
+		return err
+	}
+	if !retailStore.IsActive {
+		return apperror.Conflict("retail store is inactive")
+	}
+	if _, err := r.userRepo.GetByID(ctx, req.UserID); err != nil {
+		return err
+	}
+	return r.RetailStoreRepo.AssignUser(ctx, storeID, req.UserID, role)
+}
+
+func (r *RetailStoreUsecase) UnassignUser(ctx context.Context, storeID, userID int64) error {
+	if storeID <= 0 || userID <= 0 {
+		return apperror.Validation("invalid id")
+	}
+	if err := authorizeStore(ctx, model.PermissionStoresWrite, storeID); err != nil {
+		return err
+	}
+	return r.RetailStoreRepo.UnassignUser(ctx, storeID, userID)
+}
+
+func (r *RetailStoreUsecase) validateRetailStore(retailStore *model.RetailStore) error {
+	if retailStore.Name == "" {
+		return apperror.InvalidField("name", "required", "name is required")
+	}
+	if (retailStore.Latitude == nil) != (retailStore.Longitude == nil) {
+		return apperror.InvalidField("longitude", "required_with", "latitude and longitude must be set together")
+	}
+	if _, err := time.LoadLocation(retailStore.Timezone); err != nil || retailStore.Timezone == "" {
+		return apperror.InvalidField("timezone", "timezone", "invalid timezone %q", retailStore.Timezone)
+	}
+
+	seen := make(map[string]bool)
+	for i, hours := range retailStore.OpeningHours {
+		if hours.Open == hours.Close {
+			return apperror.InvalidField(fmt.Sprintf("opening_hours[%d].close", i), "nefield", "opening hours of %s: open and close can't be the same", hours.Day)
+		}
+		key := hours.Day + " " + hours.Open
+		if seen[key] {
+			return apperror.InvalidField(fmt.Sprintf("opening_hours[%d].open", i), "unique", "opening hours of %s: duplicated range starting at %s", hours.Day, hours.Open)
+		}
+		seen[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/internal/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+type SegmentUsecase struct {
+	segmentRepo  *repository.SegmentRepository
+	platformRepo *repository.PlatformRepository
+}
+
+func NewSegmentUsecase(
+	segmentRepo *repository.SegmentRepository,
+	platformRepo *repository.PlatformRepository,
+) *SegmentUsecase {
+	return &SegmentUsecase{
+		segmentRepo:  segmentRepo,
+		platformRepo: platformRepo,
+	}
+}
+
+func (u *SegmentUsecase) GetAll(ctx context.Context) ([]*model.CustomerSegment, error) {
+	return u.segmentRepo.GetAll(ctx)
+}
+
+func (u *SegmentUsecase) GetByID(ctx context.Context, id int64) (*model.CustomerSegment, error) {
+	return u.segmentRepo.GetByID(ctx, id)
+}
+
+func (u *SegmentUsecase) Create(ctx context.Context, req *model.CreateCustomerSegmentRequest) (*model.CustomerSegment, error) {
+	segment := &model.CustomerSegment{
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Rules:       req.Rules,
+	}
+	if err := u.validate(ctx, segment); err != nil {
+		return nil, err
+	}
+	return u.segmentRepo.Create(ctx, segment)
+}
+
+func (u *SegmentUsecase) Update(ctx context.Context, id int64, req *model.UpdateCustomerSegmentRequest) (*model.CustomerSegment, error) {
+	segment, err := u.segmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		segment.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		segment.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Rules != nil {
+		segment.Rules = *req.Rules
+	}
+	if err := u.validate(ctx, segment); err != nil {
+		return nil, err
+	}
+
+	if err := u.segmentRepo.Update(ctx, segment); err != nil {
+		return nil, err
+	}
+	return u.segmentRepo.GetByID(ctx, id)
+}
+
+func (u *SegmentUsecase) Delete(ctx context.Context, id int64) error {
+	return u.segmentRepo.Delete(ctx, id)
+}
+
+func (u *SegmentUsecase) validate(ctx context.Context, segment *model.CustomerSegment) error {
+	if segment.Name == "" {
+		return apperror.InvalidField("name", "required", "name is required")
+	}
+	exists, err := u.segmentRepo.ExistsByName(ctx, segment.Name, segment.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperror.Conflict("segment %s already exists", segment.Name)
+	}
+	return u.validateRules(ctx, segment.Rules)
+}
+
+func (u *SegmentUsecase) validateRules(ctx context.Context, rules model.SegmentRules) error {
+	if rules.MinSpent != nil && rules.MaxSpent != nil && *rules.MinSpent > *rules.MaxSpent {
+		return apperror.InvalidField("rules.min_spent", "ltefield", "min_spent must not exceed max_spent")
+	}
+	if rules.MinOrders != nil && rules.MaxOrders != nil && *rules.MinOrders > *rules.MaxOrders {
+		return apperror.InvalidField("rules.min_orders", "ltefield", "min_orders must not exceed max_orders")
+	}
+	if rules.MinInactiveDays != nil && rules.MaxInactiveDays != nil && *rules.MinInactiveDays > *rules.MaxInactiveDays {
+		return apperror.InvalidField("rules.min_inactive_days", "ltefield", "min_inactive_days must not exceed max_inactive_days")
+	}
+	for _, platformID := range rules.PlatformIDs {
+		if _, err := u.platformRepo.GetByID(ctx, platformID); err != nil {
+			return fmt.Errorf("platform %d: %w", platformID, err)
+		}
+	}
+	return nil
+}
+
+// GetMembers evaluates the saved segment against the current orders
+func (u *SegmentUsecase) GetMembers(ctx context.Context, id int64) (*model.SegmentMembership, error) {
+	segment, err := u.segmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := u.evaluate(ctx, segment.Rules)
+	if err != nil {
+		return nil, err
+	}
+	membership.Segment = segment
+	return membership, nil
+}
+
+// Preview evaluates rules without saving them
+func (u *SegmentUsecase) Preview(ctx context.Context, rules *model.SegmentRules) (*model.SegmentMembership, error) {
+	if err := u.validateRules(ctx, *rules); err != nil {
+		return nil, err
+	}
+	return u.evaluate(ctx, *rules)
+}
+
+func (u *SegmentUsecase) evaluate(ctx context.Context, rules model.SegmentRules) (*model.SegmentMembership, error) {
+	now := time.Now()
+	customers, err := u.scoreCustomers(ctx, now, rules.Window(), rules.PlatformIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []*model.CustomerRFM{}
+	for _, customer := range customers {
+		if rules.Matches(customer) {
+			members = append(members, customer)
+		}
+	}
+	slices.SortStableFunc(members, func(a, b *model.CustomerRFM) int {
+		return cmp.Compare(b.Monetary, a.Monetary)
+	})
+	return &model.SegmentMembership{
+		Rules:       rules,
+		EvaluatedAt: now,
+		MemberCount: len(members),
+		Members:     members,
+	}, nil
+}
+
+// GetRFMReport breaks down the customers with orders on the platforms (all when empty) by RFM segment
+func (u *SegmentUsecase) GetRFMReport(ctx context.Context, windowDays int, platformIDs []int64) (*model.RFMReport, error) {
+	if windowDays == 0 {
+		windowDays = model.DefaultSegmentWindowDays
+	}
+	if windowDays < 0 || windowDays > 3650 {
+		return nil, apperror.InvalidField("window_days", "lte", "window_days must be between 1 and 3650")
+	}
+	now := time.Now()
+	customers, err := u.scoreCustomers(ctx, now, windowDays, platformIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*model.RFMSegmentSummary, len(model.RFMSegments))
+	report := &model.RFMReport{
+		WindowDays:    windowDays,
+		PlatformIDs:   platformIDs,
+		CustomerCount: len(customers),
+		Segments:      make([]*model.RFMSegmentSummary, len(model.RFMSegments)),
+		EvaluatedAt:   now,
+	}
+	for i, segment := range model.RFMSegments {
+		summaries[segment] = &model.RFMSegmentSummary{Segment: segment}
+		report.Segments[i] = summaries[segment]
+	}
+	for _, customer := range customers {
+		summary := summaries[customer.Segment]
+		summary.CustomerCount++
+		summary.Monetary += customer.Monetary
+		summary.AverageFrequency += float64(customer.Frequency)
+		summary.AverageRecency += float64(customer.RecencyDays)
+	}
+	for _, summary := range report.Segments {
+		if summary.CustomerCount == 0 {
+			continue
+		}
+		count := float64(summary.CustomerCount)
+		summary.Monetary = utils.RoundMoney(summary.Monetary)
+		summary.AverageMonetary = utils.RoundMoney(summary.Monetary / count)
+		summary.AverageFrequency = utils.RoundMoney(summary.AverageFrequency / count)
+		summary.AverageRecency = utils.RoundMoney(summary.AverageRecency / count)
+	}
+	return report, nil
+}
+
+// scoreCustomers loads the activity of the customers and scores each of recency, frequency and monetary
+// from 1 to 5 by quintile among them, then names their RFM segment
+func (u *SegmentUsecase) scoreCustomers(ctx context.Context, now time.Time, windowDays int, platformIDs []int64) ([]*model.CustomerRFM, error) {
+	since := now.AddDate(0, 0, -windowDays)
+	customers, err := u.segmentRepo.GetCustomerActivity(ctx, since, platformIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, customer := range customers {
+		customer.RecencyDays = int(now.Sub(customer.LastOrderAt).Hours() / 24)
+	}
+	// The most recent buyers get the highest recency score
+	recency := quintileScores(customers, func(c *model.CustomerRFM) float64 { return -float64(c.RecencyDays) })
+	frequency := quintileScores(customers, func(c *model.CustomerRFM) float64 { return float64(c.Frequency) })
+	monetary := quintileScores(customers, func(c *model.CustomerRFM) float64 { return c.Monetary })
+	for i, customer := range customers {
+		customer.RecencyScore = recency[i]
+		customer.FrequencyScore = frequency[i]
+		customer.MonetaryScore = monetary[i]
+		customer.Segment = model.RFMSegmentFor(customer.RecencyScore, customer.FrequencyScore, customer.MonetaryScore)
+	}
+	return customers, nil
+}
+
+// quintileScores ranks the customers by value, lowest first, and scores them from 1 to 5 by quintile.
+// Customers with the same value share the score of the first of them
+func quintileScores(customers []*model.CustomerRFM, value func(*model.CustomerRFM) float64) []int {
+	order := make([]int, len(customers))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(value(customers[a]), value(customers[b]))
+	})
+
+	scores := make([]int, len(customers))
+	rank := 0
+	for position, i := range order {
+		if position == 0 || value(customers[i]) != value(customers[order[position-1]]) {
+			rank = position
+		}
+		scores[i] = 1 + rank*5/len(customers)
+	}
+	return scores
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"strings"
+)
+
+type TagUsecase struct {
+	tagRepo      *repository.TagRepository
+	customerRepo *repository.CustomerRepository
+}
+
+func NewTagUsecase(
+	tagRepo *repository.TagRepository,
+	customerRepo *repository.CustomerRepository,
+) *TagUsecase {
+	return &TagUsecase{
+		tagRepo:      tagRepo,
+		customerRepo: customerRepo,
+	}
+}
+
+func (u *TagUsecase) GetAll(ctx context.Context) ([]*model.Tag, error) {
+	return u.tagRepo.GetAll(ctx)
+}
+
+func (u *TagUsecase) Create(ctx context.Context, req *model.CreateTagRequest) (*model.Tag, error) {
+	tag := &model.Tag{
+		Name:  strings.TrimSpace(req.Name),
+		Color: strings.ToLower(req.Color),
+	}
+	if err := u.ensureUniqueName(ctx, tag.Name, 0); err != nil {
+		return nil, err
+	}
+	return u.tagRepo.Create(ctx, tag)
+}
+
+func (u *TagUsecase) Update(ctx context.Context, id int64, req *model.UpdateTagRequest) (*model.Tag, error) {
+	tag, err := u.tagRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		tag.Name = strings.TrimSpace(*req.Name)
+		if err := u.ensureUniqueName(ctx, tag.Name, id); err != nil {
+			return nil, err
+		}
+	}
+	if req.Color != nil {
+		tag.Color = strings.ToLower(*req.Color)
+	}
+
+	if err := u.tagRepo.Update(ctx, tag); err != nil {
+		return nil, err
+	}
+	return u.tagRepo.GetByID(ctx, id)
+}
+
+func (u *TagUsecase) Delete(ctx context.Context, id int64) error {
+	return u.tagRepo.Delete(ctx, id)
+}
+
+func (u *TagUsecase) ensureUniqueName(ctx context.Context, name string, excludeID int64) error {
+	if name == "" {
+		return apperror.InvalidField("name", "required", "name is required")
+	}
+	exists, err := u.tagRepo.ExistsByName(ctx, name, excludeID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return apperror.Conflict("tag %s already exists", name)
+	}
+	return nil
+}
+
+// TagCustomer adds the tag to the customer and returns the tags of the customer
+func (u *TagUsecase) TagCustomer(ctx context.Context, customerID, tagID int64) ([]*model.Tag, error) {
+	if _, err := u.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, err
+	}
+	if _, err := u.tagRepo.GetByID(ctx, tagID); err != nil {
+		return nil, err
+	}
+	if err := u.tagRepo.AddToCustomer(ctx, customerID, tagID); err != nil {
+		return nil, err
+	}
+	return u.customerTags(ctx, customerID)
+}
+
+// UntagCustomer removes the tag from the customer and returns the tags left
+func (u *TagUsecase) UntagCustomer(ctx context.Context, customerID, tagID int64) ([]*model.Tag, error) {
+	if err := u.tagRepo.RemoveFromCustomer(ctx, customerID, tagID); err != nil {
+		return nil, err
+	}
+	return u.customerTags(ctx, customerID)
+}
+
+func (u *TagUsecase) customerTags(ctx context.Context, customerID int64) ([]*model.Tag, error) {
+	tags, err := u.tagRepo.GetByCustomers(ctx, []int64{customerID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[customerID] == nil {
+		return []*model.Tag{}, nil
+	}
+	return tags[customerID], nil
+}
@@ -0,0 +1,284 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base32"
+	"simple-template/internal/apperror"
+	"simple-template/internal/model"
+	"simple-template/internal/repository"
+	"simple-template/pkg/totp"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// totpSkew accepts the codes of the previous and next time steps, for clock drift
+	totpSkew = 1
+	// maxTwoFactorAttempts invalid codes in a row lock the second factor for twoFactorLockout
+	maxTwoFactorAttempts = 5
+	twoFactorLockout     = 15 * time.Minute
+	recoveryCodeCount    = 10
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// TwoFactorSettings configures TwoFactorUsecase
+type TwoFactorSettings struct {
+	// Issuer is the account issuer shown by authenticator apps
+	Issuer string
+	// RequiredForAdmin withholds every permission of admins until they enable two-factor authentication
+	RequiredForAdmin bool
+}
+
+type TwoFactorUsecase struct {
+	userRepo      *repository.UserRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	settings      TwoFactorSettings
+}
+
+func NewTwoFactorUsecase(
+	userRepo *repository.UserRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	settings TwoFactorSettings,
+) *TwoFactorUsecase {
+	return &TwoFactorUsecase{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		settings:      settings,
+	}
+}
+
+// Status returns whether the user has two-factor authentication and whether they must
+func (u *TwoFactorUsecase) Status(ctx context.Context, userID int64) (*model.TwoFactorStatus, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.TwoFactorStatus{Required: u.isRequired(user)}
+	if twoFactor.IsEnabled() {
+		status.Enabled = true
+		status.EnabledAt = twoFactor.EnabledAt
+		if status.RecoveryCodesLeft, err = u.twoFactorRepo.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// Setup generates a new secret for the user, it is pending until Enable confirms a first code
+func (u *TwoFactorUsecase) Setup(ctx context.Context, userID int64) (*model.TwoFactorSetup, error) {
+	if err := requireSession(ctx); err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		return nil, apperror.Conflict("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.SetPending(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+	return &model.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(u.settings.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable turns two-factor authentication on once the authenticator app returns a valid code,
+// the recovery codes are only returned here
+func (u *TwoFactorUsecase) Enable(ctx context.Context, userID int64, req *model.TwoFactorCodeRequest) (*model.RecoveryCodes, error) {
+	if err := requireSession(ctx); err != nil {
+		return nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, apperror.Conflict("two-factor authentication is not being set up")
+	}
+	if twoFactor.IsEnabled() {
+		return nil, apperror.Conflict("two-factor authentication is already enabled")
+	}
+
+	now := time.Now()
+	if err := checkTwoFactorLock(twoFactor, now); err != nil {
+		return nil, err
+	}
+	step, ok := totp.Validate(twoFactor.Secret, req.Code, now, totpSkew)
+	if !ok {
+		return nil, recordTwoFactorFailure(ctx, u.twoFactorRepo, twoFactor, now)
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.Enable(ctx, userID, step, hashes, now); err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// Disable turns two-factor authentication off, it needs the password and a code.
+// Admins can't when it is mandatory for them
+func (u *TwoFactorUsecase) Disable(ctx context.Context, userID int64, req *model.DisableTwoFactorRequest) error {
+	if err := requireSession(ctx); err != nil {
+		return err
+	}
+	user, err := u.userRepo.GetCredentialsByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.isRequired(user) {
+		return apperror.Forbidden("two-factor authentication is mandatory for admins")
+	}
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return apperror.Unauthorized("password is incorrect")
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.IsEnabled() {
+		return apperror.Conflict("two-factor authentication is not enabled")
+	}
+
+	if err := verifySecondFactor(ctx, u.twoFactorRepo, twoFactor, req.Code, true, time.Now()); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.Delete(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, it needs a code of the authenticator app
+func (u *TwoFactorUsecase) RegenerateRecoveryCodes(ctx context.Context, userID int64, req *model.TwoFactorCodeRequest) (*model.RecoveryCodes, error) {
+	if err := requireSession(ctx); err != nil {
+		return nil, err
+	}
+	twoFactor, err := u.twoFactorRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, apperror.Conflict("two-factor authentication is not enabled")
+	}
+
+	if err := verifySecondFactor(ctx, u.twoFactorRepo, twoFactor, req.Code, false, time.Now()); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// Reset removes the second factor of another user who lost their authenticator and recovery codes
+func (u *TwoFactorUsecase) Reset(ctx context.Context, userID int64) error {
+	if principal := model.PrincipalFromContext(ctx); principal != nil && principal.UserID == userID {
+		return apperror.Forbidden("you can't reset your own two-factor authentication, disable it instead")
+	}
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		return err
+	}
+	return u.twoFactorRepo.Delete(ctx, userID)
+}
+
+func (u *TwoFactorUsecase) isRequired(user *model.User) bool {
+	return u.settings.RequiredForAdmin && user.Role == model.RoleAdmin
+}
+
+// verifySecondFactor accepts a code of the authenticator app, or a recovery code when allowRecovery.
+// Each code is accepted once and too many invalid codes lock the second factor for a while
+func verifySecondFactor(
+	ctx context.Context,
+	repo *repository.TwoFactorRepository,
+	twoFactor *model.TwoFactor,
+	code string,
+	allowRecovery bool,
+	now time.Time,
+) error {
+	if err := checkTwoFactorLock(twoFactor, now); err != nil {
+		return err
+	}
+	if step, ok := totp.Validate(twoFactor.Secret, code, now, totpSkew); ok {
+		used, err := repo.UseStep(ctx, twoFactor.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return apperror.Unauthorized("two-factor code has already been used, wait for the next one")
+		}
+		return nil
+	}
+	if allowRecovery {
+		used, err := repo.UseRecoveryCode(ctx, twoFactor.UserID, hashToken(normalizeRecoveryCode(code)), now)
+		if err != nil {
+			return err
+		}
+		if used {
+			return repo.SetFailedAttempts(ctx, twoFactor.UserID, 0, nil)
+		}
+	}
+	return recordTwoFactorFailure(ctx, repo, twoFactor, now)
+}
+
+func checkTwoFactorLock(twoFactor *model.TwoFactor, now time.Time) error {
+	if twoFactor.LockedUntil != nil && twoFactor.LockedUntil.After(now) {
+		return apperror.TooManyAttempts("too many invalid two-factor codes, try again after %s", twoFactor.LockedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// recordTwoFactorFailure counts the invalid code and returns the error to report
+func recordTwoFactorFailure(ctx context.Context, repo *repository.TwoFactorRepository, twoFactor *model.TwoFactor, now time.Time) error {
+	attempts := twoFactor.FailedAttempts + 1
+	var lockedUntil *time.Time
+	if attempts >= maxTwoFactorAttempts {
+		until := now.Add(twoFactorLockout)
+		attempts, lockedUntil = 0, &until
+	}
+	if err := repo.SetFailedAttempts(ctx, twoFactor.UserID, attempts, lockedUntil); err != nil {
+		return err
+	}
+	return apperror.Unauthorized("invalid two-factor code")
+}
+
+// generateRecoveryCodes returns new recovery codes, formatted as xxxxx-xxxxx, with their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := randomToken(8, recoveryCodeEncoding.EncodeToString)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:10]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"simple-template/internal/apperror"
 	"strings"
 
 	"simple-template/internal/model"
@@ -11,13 +12,15 @@ import (
 
 // UserUsecase handles business logic related to users
 type UserUsecase struct {
-	userRepo *repository.UserRepository
+	userRepo     *repository.UserRepository
+	auditUsecase *AuditUsecase
 }
 
 // NewUserUsecase creates a new instance of UserUsecase
-func NewUserUsecase(userRepo *repository.UserRepository) *UserUsecase {
+func NewUserUsecase(userRepo *repository.UserRepository, auditUsecase *AuditUsecase) *UserUsecase {
 	return &UserUsecase{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		auditUsecase: auditUsecase,
 	}
 }
 
@@ -28,10 +31,16 @@ func (u *UserUsecase) CreateUser(ctx context.Context, req *model.CreateUserReque
 		return nil, err
 	}
 
+	passwordHash, err := HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create user object
 	user := &model.User{
-		Name:  strings.TrimSpace(req.Name),
-		Email: strings.ToLower(strings.TrimSpace(req.Email)),
+		Name:         strings.TrimSpace(req.Name),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		PasswordHash: passwordHash,
 	}
 
 	// Call repository to save to database
@@ -44,6 +53,7 @@ func (u *UserUsecase) CreateUser(ctx context.Context, req *model.CreateUserReque
 	if err != nil {
 		return nil, fmt.Errorf("failed to get created user: %w", err)
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionCreate, model.AuditEntityUser, createdUser.ID, nil, createdUser)
 
 	return createdUser, nil
 }
@@ -51,7 +61,7 @@ func (u *UserUsecase) CreateUser(ctx context.Context, req *model.CreateUserReque
 // GetUserByID gets user information by ID
 func (u *UserUsecase) GetUserByID(ctx context.Context, id int64) (*model.User, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid user id")
+		return nil, apperror.Validation("invalid user id")
 	}
 
 	// Call repository to get user
@@ -77,7 +87,7 @@ func (u *UserUsecase) GetAllUsers(ctx context.Context) ([]*model.User, error) {
 // UpdateUser updates user information
 func (u *UserUsecase) UpdateUser(ctx context.Context, id int64, req *model.UpdateUserRequest) (*model.User, error) {
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid user id")
+		return nil, apperror.Validation("invalid user id")
 	}
 
 	// Validate input
@@ -85,18 +95,28 @@ func (u *UserUsecase) UpdateUser(ctx context.Context, id int64, req *model.Updat
 		return nil, err
 	}
 
+	current, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	// Tạo map updates
 	updates := make(map[string]interface{})
 	if req.Name != "" {
 		updates["name"] = strings.TrimSpace(req.Name)
 	}
 	if req.Email != "" {
-		updates["email"] = strings.ToLower(strings.TrimSpace(req.Email))
+		email := strings.ToLower(strings.TrimSpace(req.Email))
+		updates["email"] = email
+		// The new email has to be verified again
+		if email != current.Email {
+			updates["email_verified_at"] = nil
+		}
 	}
 
 	// Check if there's anything to update
 	if len(updates) == 0 {
-		return nil, fmt.Errorf("no fields to update")
+		return nil, apperror.Validation("no fields to update")
 	}
 
 	// Call repository to update
@@ -109,6 +129,7 @@ func (u *UserUsecase) UpdateUser(ctx context.Context, id int64, req *model.Updat
 	if err != nil {
 		return nil, fmt.Errorf("failed to get updated user: %w", err)
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityUser, id, current, updatedUser)
 
 	return updatedUser, nil
 }
@@ -116,13 +137,19 @@ func (u *UserUsecase) UpdateUser(ctx context.Context, id int64, req *model.Updat
 // DeleteUser deletes a user by ID
 func (u *UserUsecase) DeleteUser(ctx context.Context, id int64) error {
 	if id <= 0 {
-		return fmt.Errorf("invalid user id")
+		return apperror.Validation("invalid user id")
+	}
+
+	user, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
 	}
 
 	// Call repository to delete user
 	if err := u.userRepo.Delete(ctx, id); err != nil {
 		return err
 	}
+	u.auditUsecase.Record(ctx, model.AuditActionDelete, model.AuditEntityUser, id, user, nil)
 
 	return nil
 }
@@ -130,16 +157,16 @@ func (u *UserUsecase) DeleteUser(ctx context.Context, id int64) error {
 // validateCreateUser validate dữ liệu khi tạo user
 func (u *UserUsecase) validateCreateUser(req *model.CreateUserRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("name is required")
+		return apperror.InvalidField("name", "required", "name is required")
 	}
 
 	if strings.TrimSpace(req.Email) == "" {
-		return fmt.Errorf("email is required")
+		return apperror.InvalidField("email", "required", "email is required")
 	}
 
 	// Validate email format đơn giản
 	if !strings.Contains(req.Email, "@") {
-		return fmt.Errorf("invalid email format")
+		return apperror.InvalidField("email", "email", "invalid email format")
 	}
 
 	return nil
@@ -149,8 +176,46 @@ func (u *UserUsecase) validateCreateUser(req *model.CreateUserRequest) error {
 func (u *UserUsecase) validateUpdateUser(req *model.UpdateUserRequest) error {
 	// Nếu có email, validate format
 	if req.Email != "" && !strings.Contains(req.Email, "@") {
-		return fmt.Errorf("invalid email format")
+		return apperror.InvalidField("email", "email", "invalid email format")
 	}
 
 	return nil
 }
+
+// GetRoles lists the roles with the permissions they grant
+func (u *UserUsecase) GetRoles() []*model.RoleDefinition {
+	roles := make([]*model.RoleDefinition, len(model.Roles))
+	for i, role := range model.Roles {
+		roles[i] = &model.RoleDefinition{Role: role, Permissions: model.RolePermissions[role]}
+	}
+	return roles
+}
+
+// SetRole sets the role the user has in every store, an empty role leaves them with their store roles only
+func (u *UserUsecase) SetRole(ctx context.Context, id int64, req *model.SetUserRoleRequest) (*model.User, error) {
+	if id <= 0 {
+		return nil, apperror.Validation("invalid user id")
+	}
+	role := strings.TrimSpace(req.Role)
+	if role != "" && !model.IsValidRole(role) {
+		return nil, apperror.InvalidField("role", "oneof", "invalid role %s", role)
+	}
+	// Admins could otherwise lock themselves out of user management
+	if principal := model.PrincipalFromContext(ctx); principal != nil && principal.UserID == id {
+		return nil, apperror.Forbidden("you can't change your own role")
+	}
+	current, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.userRepo.SetRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	updatedUser, err := u.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	u.auditUsecase.Record(ctx, model.AuditActionUpdate, model.AuditEntityUser, id, current, updatedUser)
+	return updatedUser, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"database/sql"
+	"math"
 	"strings"
 )
 
@@ -80,3 +81,24 @@ func NullStringToString(ns sql.NullString) string {
 	}
 	return ""
 }
+
+// NullIfEmpty returns nil for a blank string so it's stored as NULL
+func NullIfEmpty(value string) interface{} {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return value
+}
+
+// NullIfZero returns nil for a zero id so it's stored as NULL
+func NullIfZero(value int64) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// RoundMoney rounds an amount to 2 decimals, the precision of the DECIMAL money columns
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
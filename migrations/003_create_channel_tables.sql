@@ -0,0 +1,53 @@
+-- Marketplace channels (Shopee, Lazada, TikTok Shop)
+
+-- Marketplace SKU -> our variant value
+CREATE TABLE IF NOT EXISTS `channel_sku_mappings` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `platform_id` bigint NOT NULL,
+    `external_sku` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `external_product_id` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'item_id / product_id on the marketplace',
+    `external_variant_id` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'model_id / sku_id on the marketplace',
+    `variant_value_id` bigint NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `UQ_platform_external_sku` (`platform_id`, `external_sku`),
+    KEY `variant_value_id` (`variant_value_id`),
+    CONSTRAINT `channel_sku_mappings_ibfk_1` FOREIGN KEY (`platform_id`) REFERENCES `platform` (`id`),
+    CONSTRAINT `channel_sku_mappings_ibfk_2` FOREIGN KEY (`variant_value_id`) REFERENCES `product_variant_value` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Imported marketplace orders, also prevents importing the same order twice
+CREATE TABLE IF NOT EXISTS `channel_orders` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `platform_id` bigint NOT NULL,
+    `external_order_id` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `order_id` bigint NOT NULL,
+    `external_status` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'pending, paid, shipped, completed, canceled',
+    `tracking_number` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `carrier` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `shipped_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `UQ_platform_external_order` (`platform_id`, `external_order_id`),
+    UNIQUE KEY `UQ_order_id` (`order_id`),
+    CONSTRAINT `channel_orders_ibfk_1` FOREIGN KEY (`platform_id`) REFERENCES `platform` (`id`),
+    CONSTRAINT `channel_orders_ibfk_2` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `channel_sync_runs` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `platform_id` bigint NOT NULL,
+    `kind` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'orders, stock, prices',
+    `status` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'running, succeeded, failed',
+    `processed` int NOT NULL DEFAULT 0,
+    `skipped` int NOT NULL DEFAULT 0,
+    `failed` int NOT NULL DEFAULT 0,
+    `error_message` text COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `started_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `finished_at` timestamp NULL DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_platform_kind_started` (`platform_id`, `kind`, `started_at`),
+    CONSTRAINT `channel_sync_runs_ibfk_1` FOREIGN KEY (`platform_id`) REFERENCES `platform` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,14 @@
+-- Typed platform config, feature_struct now holds a JSON document matching
+-- internal/model/platform_config.schema.json
+
+UPDATE `platform`
+SET `feature_struct` = CASE `name`
+    WHEN 'shopee' THEN '{"commission_rate": 2.5, "credentials_ref": "SHOPEE", "sync_intervals": {"orders": 15, "stock": 30, "prices": 60}, "features": ["order_sync", "stock_sync", "price_sync", "shipment_sync"]}'
+    WHEN 'lazada' THEN '{"commission_rate": 2.0, "credentials_ref": "LAZADA", "sync_intervals": {"orders": 15, "stock": 30, "prices": 60}, "features": ["order_sync", "stock_sync", "price_sync", "shipment_sync"]}'
+    WHEN 'tiktok shop' THEN '{"commission_rate": 3.0, "credentials_ref": "TIKTOK", "sync_intervals": {"orders": 15, "stock": 30, "prices": 60}, "features": ["order_sync", "stock_sync", "price_sync", "shipment_sync"]}'
+    ELSE '{"commission_rate": 0, "sync_intervals": {"orders": 0, "stock": 0, "prices": 0}}'
+END;
+
+ALTER TABLE `platform`
+    MODIFY `feature_struct` JSON DEFAULT NULL,
+    ADD UNIQUE KEY `UQ_platform_name` (`name`);
@@ -0,0 +1,41 @@
+-- Order amounts are computed once at creation time so later price or
+-- commission changes don't rewrite past revenue
+ALTER TABLE `orders`
+    ADD COLUMN `subtotal_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 COMMENT 'Sum of item price * quantity' AFTER `retail_stores_id`,
+    ADD COLUMN `discount_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 AFTER `subtotal_amount`,
+    ADD COLUMN `total_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 COMMENT 'subtotal - discount, paid by the customer' AFTER `discount_amount`,
+    ADD COLUMN `commission_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 COMMENT 'Platform commission' AFTER `total_amount`,
+    ADD COLUMN `channel_fee_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 COMMENT 'Other platform fees' AFTER `commission_amount`,
+    ADD COLUMN `cogs_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0 COMMENT 'Sum of item cost_price * quantity' AFTER `channel_fee_amount`,
+    ADD KEY `idx_platform_created_at` (`platform_id`, `created_at`);
+
+-- Breakdown of commission_amount and channel_fee_amount
+CREATE TABLE IF NOT EXISTS `order_fees` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `order_id` bigint NOT NULL,
+    `name` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `type` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'percentage, fixed',
+    `rate` DECIMAL(10, 4) NOT NULL COMMENT 'Percent for percentage fees, amount for fixed fees',
+    `amount` DECIMAL(12, 2) NOT NULL,
+    `is_commission` TINYINT NOT NULL DEFAULT 0,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `order_id` (`order_id`),
+    CONSTRAINT `order_fees_ibfk_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Backfill existing orders from their items and the current platform commission
+UPDATE `orders` o
+JOIN (
+    SELECT oi.`order_id`,
+        SUM(oi.`quantity` * p.`price`) AS subtotal,
+        SUM(oi.`quantity` * COALESCE(p.`cost_price`, 0)) AS cogs
+    FROM `order_items` oi
+    JOIN `price` p ON p.`id` = oi.`price_id`
+    GROUP BY oi.`order_id`
+) t ON t.`order_id` = o.`id`
+LEFT JOIN `platform` pl ON pl.`id` = o.`platform_id`
+SET o.`subtotal_amount` = t.subtotal,
+    o.`total_amount` = t.subtotal,
+    o.`cogs_amount` = t.cogs,
+    o.`commission_amount` = ROUND(t.subtotal * COALESCE(JSON_EXTRACT(pl.`feature_struct`, '$.commission_rate'), 0) / 100, 2);
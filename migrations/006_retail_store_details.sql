@@ -0,0 +1,20 @@
+ALTER TABLE `retail_stores`
+    ADD COLUMN `address` text COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `phone_number`,
+    ADD COLUMN `latitude` DECIMAL(10, 7) DEFAULT NULL AFTER `address`,
+    ADD COLUMN `longitude` DECIMAL(10, 7) DEFAULT NULL AFTER `latitude`,
+    ADD COLUMN `timezone` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'Asia/Ho_Chi_Minh' COMMENT 'IANA time zone' AFTER `longitude`,
+    ADD COLUMN `opening_hours` JSON DEFAULT NULL COMMENT '[{"day": "monday", "open": "08:00", "close": "22:00"}]' AFTER `timezone`,
+    ADD COLUMN `is_active` BOOLEAN NOT NULL DEFAULT TRUE AFTER `opening_hours`;
+
+-- Users working in a store
+CREATE TABLE IF NOT EXISTS `store_users` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `store_id` bigint NOT NULL,
+    `user_id` bigint NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `UQ_store_user` (`store_id`, `user_id`),
+    KEY `user_id` (`user_id`),
+    CONSTRAINT `store_users_ibfk_1` FOREIGN KEY (`store_id`) REFERENCES `retail_stores` (`id`),
+    CONSTRAINT `store_users_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,29 @@
+-- A payment method without rows here is allowed on every platform / store
+CREATE TABLE IF NOT EXISTS `payment_method_platforms` (
+    `payment_method_id` bigint NOT NULL,
+    `platform_id` bigint NOT NULL,
+    PRIMARY KEY (`payment_method_id`, `platform_id`),
+    KEY `platform_id` (`platform_id`),
+    CONSTRAINT `payment_method_platforms_ibfk_1` FOREIGN KEY (`payment_method_id`) REFERENCES `payment_methods` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `payment_method_platforms_ibfk_2` FOREIGN KEY (`platform_id`) REFERENCES `platform` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `payment_method_stores` (
+    `payment_method_id` bigint NOT NULL,
+    `retail_store_id` bigint NOT NULL,
+    PRIMARY KEY (`payment_method_id`, `retail_store_id`),
+    KEY `retail_store_id` (`retail_store_id`),
+    CONSTRAINT `payment_method_stores_ibfk_1` FOREIGN KEY (`payment_method_id`) REFERENCES `payment_methods` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `payment_method_stores_ibfk_2` FOREIGN KEY (`retail_store_id`) REFERENCES `retail_stores` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Cash only offline, marketplace wallets only on their marketplace
+INSERT IGNORE INTO `payment_method_platforms` (`payment_method_id`, `platform_id`)
+SELECT pm.`id`, p.`id`
+FROM `payment_methods` pm
+JOIN `platform` p ON (pm.`code`, p.`name`) IN (
+    ('CASH', 'offline'),
+    ('SHOPEEPAY', 'shopee'),
+    ('LAZADAWALLET', 'lazada'),
+    ('TIKTOKPAY', 'tiktok shop')
+);
@@ -0,0 +1,22 @@
+-- Codes printed on the item labels, scanned at the counter
+ALTER TABLE `product_variant_value`
+    ADD COLUMN `sku` varchar(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `value`,
+    ADD COLUMN `barcode` varchar(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `sku`,
+    ADD UNIQUE KEY `UQ_variant_value_sku` (`sku`),
+    ADD UNIQUE KEY `UQ_variant_value_barcode` (`barcode`);
+
+-- Payment captured at the counter, one per POS order
+CREATE TABLE IF NOT EXISTS `pos_payments` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `order_id` bigint NOT NULL,
+    `payment_id` bigint NOT NULL,
+    `amount_due` DECIMAL(12, 2) NOT NULL,
+    `amount_tendered` DECIMAL(12, 2) NOT NULL,
+    `change_amount` DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `UQ_pos_payment_order` (`order_id`),
+    KEY `payment_id` (`payment_id`),
+    CONSTRAINT `pos_payments_ibfk_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`),
+    CONSTRAINT `pos_payments_ibfk_2` FOREIGN KEY (`payment_id`) REFERENCES `payment_methods` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,39 @@
+-- Cashier shifts of the stores taking cash, at most one open shift per store
+CREATE TABLE IF NOT EXISTS `cash_shifts` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `retail_store_id` bigint NOT NULL,
+    `user_id` bigint NOT NULL COMMENT 'Cashier',
+    `status` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'open' COMMENT 'open, closed',
+    `opening_float` DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    `cash_sales_amount` DECIMAL(12, 2) DEFAULT NULL COMMENT 'Frozen when the shift is closed',
+    `cash_in_amount` DECIMAL(12, 2) DEFAULT NULL,
+    `cash_out_amount` DECIMAL(12, 2) DEFAULT NULL,
+    `expected_amount` DECIMAL(12, 2) DEFAULT NULL COMMENT 'opening float + cash sales + cash in - cash out',
+    `counted_amount` DECIMAL(12, 2) DEFAULT NULL,
+    `difference_amount` DECIMAL(12, 2) DEFAULT NULL COMMENT 'counted - expected',
+    `order_count` int DEFAULT NULL,
+    `opening_note` text COLLATE utf8mb4_unicode_ci,
+    `closing_note` text COLLATE utf8mb4_unicode_ci,
+    `opened_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `closed_at` timestamp NULL DEFAULT NULL,
+    `open_retail_store_id` bigint GENERATED ALWAYS AS (IF(`status` = 'open', `retail_store_id`, NULL)) STORED,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `UQ_cash_shift_open_store` (`open_retail_store_id`),
+    KEY `idx_store_opened_at` (`retail_store_id`, `opened_at`),
+    KEY `user_id` (`user_id`),
+    CONSTRAINT `cash_shifts_ibfk_1` FOREIGN KEY (`retail_store_id`) REFERENCES `retail_stores` (`id`),
+    CONSTRAINT `cash_shifts_ibfk_2` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Cash put in or taken out of the drawer outside of sales
+CREATE TABLE IF NOT EXISTS `cash_movements` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `shift_id` bigint NOT NULL,
+    `type` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'cash_in, cash_out',
+    `amount` DECIMAL(12, 2) NOT NULL,
+    `reason` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `shift_id` (`shift_id`),
+    CONSTRAINT `cash_movements_ibfk_1` FOREIGN KEY (`shift_id`) REFERENCES `cash_shifts` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
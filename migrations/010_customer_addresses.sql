@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS `customer_addresses` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `customer_id` bigint NOT NULL,
+    `label` varchar(50) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'e.g., Home, Office',
+    `recipient_name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `phone_number` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `street` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `ward` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `district` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `province` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `postal_code` varchar(20) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `is_default_shipping` BOOLEAN NOT NULL DEFAULT FALSE,
+    `is_default_billing` BOOLEAN NOT NULL DEFAULT FALSE,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `customer_id` (`customer_id`),
+    CONSTRAINT `customer_addresses_ibfk_1` FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- The free text address becomes the default address of the customer
+INSERT INTO `customer_addresses` (`customer_id`, `recipient_name`, `phone_number`, `street`, `is_default_shipping`, `is_default_billing`)
+SELECT c.`id`,
+       TRIM(CONCAT_WS(' ', c.`first_name`, c.`last_name`)),
+       COALESCE(c.`phone_number`, ''),
+       LEFT(TRIM(c.`address`), 255),
+       TRUE,
+       TRUE
+FROM `customer` c
+WHERE TRIM(COALESCE(c.`address`, '')) <> ''
+  AND NOT EXISTS (SELECT 1 FROM `customer_addresses` a WHERE a.`customer_id` = c.`id`);
+
+-- Orders keep a copy of the shipping address, the referenced address may change or be deleted later
+ALTER TABLE `orders`
+    ADD COLUMN `shipping_address_id` bigint DEFAULT NULL AFTER `customer_id`,
+    ADD COLUMN `shipping_address` JSON DEFAULT NULL COMMENT 'Snapshot taken when the order is created' AFTER `shipping_address_id`,
+    ADD CONSTRAINT `orders_shipping_address_ibfk_1` FOREIGN KEY (`shipping_address_id`) REFERENCES `customer_addresses` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,25 @@
+-- Customer phone numbers are stored in E.164 (+84 followed by the national number)
+-- A number that would collide with another customer is left as is, the duplicate detection finds it
+UPDATE `customer` c
+JOIN (
+    SELECT `id`, `e164`, COUNT(*) OVER (PARTITION BY `e164`) AS `holders`
+    FROM (
+        SELECT `id`,
+               CASE
+                   WHEN `digits` LIKE '0084%' THEN CONCAT('+84', SUBSTRING(`digits`, 5))
+                   WHEN `digits` LIKE '84%' AND CHAR_LENGTH(`digits`) >= 11 THEN CONCAT('+', `digits`)
+                   WHEN `digits` LIKE '0%' THEN CONCAT('+84', SUBSTRING(`digits`, 2))
+                   ELSE CONCAT('+84', `digits`)
+               END AS `e164`
+        FROM (
+            SELECT `id`, REGEXP_REPLACE(`phone_number`, '[^0-9]', '') AS `digits`
+            FROM `customer`
+            WHERE `phone_number` IS NOT NULL
+              AND `phone_number` NOT LIKE '%*%'
+        ) d
+    ) n
+) p ON p.`id` = c.`id`
+SET c.`phone_number` = p.`e164`
+WHERE p.`holders` = 1
+  AND CHAR_LENGTH(p.`e164`) IN (12, 13)
+  AND c.`phone_number` <> p.`e164`;
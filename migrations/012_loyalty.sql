@@ -0,0 +1,35 @@
+-- Single row holding the loyalty program rules, see model.LoyaltyConfig
+CREATE TABLE IF NOT EXISTS `loyalty_settings` (
+    `id` tinyint NOT NULL,
+    `config` JSON NOT NULL,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- 1 point per 10,000 spent, worth 100 when redeemed, valid 12 months
+INSERT INTO `loyalty_settings` (`id`, `config`) VALUES
+    (1, '{"enabled": true, "earn_amount": 10000, "point_value": 100, "min_redeem_points": 100, "max_redeem_rate": 50, "expiry_months": 12, "tier_window_months": 12, "tiers": [{"tier": "silver", "min_spend": 5000000, "earn_multiplier": 1.25}, {"tier": "gold", "min_spend": 20000000, "earn_multiplier": 1.5}]}')
+ON DUPLICATE KEY UPDATE `id` = `id`;
+
+-- Credits (earn, reverse_redeem) keep the points not yet consumed in remaining_points,
+-- debits (redeem, reverse_earn, expire) consume the credits expiring first
+CREATE TABLE IF NOT EXISTS `loyalty_ledger` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `customer_id` bigint NOT NULL,
+    `order_id` bigint DEFAULT NULL,
+    `type` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'earn, redeem, reverse_earn, reverse_redeem, expire',
+    `points` int NOT NULL,
+    `remaining_points` int NOT NULL DEFAULT 0,
+    `expires_at` timestamp NULL DEFAULT NULL,
+    `description` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_loyalty_ledger_order_type` (`order_id`, `type`),
+    KEY `customer_id` (`customer_id`, `expires_at`),
+    CONSTRAINT `loyalty_ledger_ibfk_1` FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `loyalty_ledger_ibfk_2` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Completed orders can be returned, the points they earned are reversed
+ALTER TABLE `order_status`
+    MODIFY `status` TINYINT NOT NULL DEFAULT 1 COMMENT '1=pending, 2=paid, 3=shipped, 4=completed, 5=canceled, 6=returned';
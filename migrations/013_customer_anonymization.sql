@@ -0,0 +1,3 @@
+-- Anonymized customers keep their orders for accounting, their personal data is scrubbed
+ALTER TABLE `customer`
+    ADD COLUMN `anonymized_at` timestamp NULL DEFAULT NULL AFTER `phone_number`;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `name` varchar(50) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `color` varchar(7) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'hex color, e.g. #ff9900',
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_tags_name` (`name`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `customer_tags` (
+    `customer_id` bigint NOT NULL,
+    `tag_id` bigint NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`customer_id`, `tag_id`),
+    KEY `tag_id` (`tag_id`),
+    CONSTRAINT `customer_tags_ibfk_1` FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `customer_tags_ibfk_2` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Notes are append-only, the author is kept as a name when the user is deleted
+CREATE TABLE IF NOT EXISTS `customer_notes` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `customer_id` bigint NOT NULL,
+    `author_id` bigint DEFAULT NULL,
+    `author_name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `body` text COLLATE utf8mb4_unicode_ci NOT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `customer_id` (`customer_id`, `created_at`),
+    KEY `author_id` (`author_id`),
+    CONSTRAINT `customer_notes_ibfk_1` FOREIGN KEY (`customer_id`) REFERENCES `customer` (`id`) ON DELETE CASCADE,
+    CONSTRAINT `customer_notes_ibfk_2` FOREIGN KEY (`author_id`) REFERENCES `users` (`id`) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
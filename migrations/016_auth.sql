@@ -0,0 +1,25 @@
+-- Users sign in with their email and a bcrypt hashed password, users without one can't sign in
+ALTER TABLE `users`
+    ADD COLUMN `password_hash` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `email`,
+    ADD COLUMN `is_active` tinyint(1) NOT NULL DEFAULT 1 AFTER `password_hash`,
+    ADD COLUMN `last_login_at` timestamp NULL DEFAULT NULL AFTER `is_active`;
+
+-- Refresh tokens are stored hashed, each use replaces the token with a new one of the same family.
+-- A revoked token used again revokes its whole family
+CREATE TABLE IF NOT EXISTS `refresh_tokens` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `user_id` bigint NOT NULL,
+    `family_id` char(32) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `token_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `expires_at` timestamp NOT NULL,
+    `revoked_at` timestamp NULL DEFAULT NULL,
+    `replaced_by_id` bigint DEFAULT NULL,
+    `user_agent` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `ip_address` varchar(45) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_refresh_tokens_hash` (`token_hash`),
+    KEY `user_id` (`user_id`),
+    KEY `family_id` (`family_id`),
+    CONSTRAINT `refresh_tokens_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+-- Role of the user in every store, admin can only be given here
+ALTER TABLE `users`
+    ADD COLUMN `role` varchar(20) COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT 'admin, manager, cashier, warehouse, read_only' AFTER `is_active`;
+
+-- Role of the user in one store
+ALTER TABLE `store_users`
+    ADD COLUMN `role` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'cashier' COMMENT 'manager, cashier, warehouse, read_only' AFTER `user_id`;
+
+-- Everyone able to log in had full access so far
+UPDATE `users` SET `role` = 'admin' WHERE `password_hash` IS NOT NULL;
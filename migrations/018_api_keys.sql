@@ -0,0 +1,18 @@
+-- API keys give non-interactive access on behalf of a user, limited to the scopes of the key.
+-- Only the SHA-256 of the key is stored, the prefix lets users tell their keys apart
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `user_id` bigint NOT NULL,
+    `name` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `prefix` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `key_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `scopes` JSON NOT NULL COMMENT '["orders:read", "channels:write"]',
+    `expires_at` timestamp NULL DEFAULT NULL,
+    `last_used_at` timestamp NULL DEFAULT NULL,
+    `revoked_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_api_keys_hash` (`key_hash`),
+    KEY `user_id` (`user_id`),
+    CONSTRAINT `api_keys_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,21 @@
+-- Set once the user proved they own their email, cleared when the email changes
+ALTER TABLE `users`
+    ADD COLUMN `email_verified_at` timestamp NULL DEFAULT NULL AFTER `is_active`;
+
+-- One-time tokens sent by email, only the SHA-256 of the token is stored.
+-- A token is only valid for the email it was sent to
+CREATE TABLE IF NOT EXISTS `user_tokens` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `user_id` bigint NOT NULL,
+    `purpose` varchar(30) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'invitation, email_verification, password_reset',
+    `email` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `token_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `expires_at` timestamp NOT NULL,
+    `used_at` timestamp NULL DEFAULT NULL,
+    `created_by` bigint DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_user_tokens_hash` (`token_hash`),
+    KEY `user_purpose` (`user_id`, `purpose`),
+    CONSTRAINT `user_tokens_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
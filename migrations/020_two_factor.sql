@@ -0,0 +1,25 @@
+-- TOTP second factor of a user, the secret is pending until enabled_at is set by a first valid code.
+-- last_step is the time step of the last code accepted, a code can't be used twice
+CREATE TABLE IF NOT EXISTS `user_totp` (
+    `user_id` bigint NOT NULL,
+    `secret` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `enabled_at` timestamp NULL DEFAULT NULL,
+    `last_step` bigint NOT NULL DEFAULT 0,
+    `failed_attempts` int NOT NULL DEFAULT 0,
+    `locked_until` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`user_id`),
+    CONSTRAINT `user_totp_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Single use codes replacing a TOTP code when the authenticator is lost, only their SHA-256 is stored
+CREATE TABLE IF NOT EXISTS `user_recovery_codes` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `user_id` bigint NOT NULL,
+    `code_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `used_at` timestamp NULL DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uq_user_recovery_codes` (`user_id`, `code_hash`),
+    CONSTRAINT `user_recovery_codes_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,18 @@
+-- Who changed what: one row per create, update or delete of a user, customer, product or order.
+-- changes holds the fields that changed as {"field": {"before": ..., "after": ...}}
+CREATE TABLE IF NOT EXISTS `audit_logs` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `actor_user_id` bigint DEFAULT NULL COMMENT 'NULL when done by the server itself, e.g. a marketplace sync',
+    `actor_api_key_id` bigint DEFAULT NULL,
+    `action` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'create, update, delete',
+    `entity_type` varchar(50) COLLATE utf8mb4_unicode_ci NOT NULL,
+    `entity_id` bigint NOT NULL,
+    `changes` JSON NOT NULL,
+    `ip_address` varchar(45) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `request_id` varchar(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+    `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `entity` (`entity_type`, `entity_id`),
+    KEY `actor_user_id` (`actor_user_id`),
+    KEY `created_at` (`created_at`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+-- An order sync with orders that failed to import is partial, the next sync resumes from the oldest of them
+ALTER TABLE `channel_sync_runs`
+    MODIFY COLUMN `status` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL COMMENT 'running, succeeded, partial, failed',
+    ADD COLUMN `resume_from` timestamp NULL DEFAULT NULL COMMENT 'Where the next order sync starts' AFTER `error_message`;
@@ -0,0 +1,104 @@
+// Package i18n translates the API messages into the language asked by the client.
+// Messages are written in English in the code and the English text is the key of the catalogs,
+// a message missing from a catalog stays in English
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a language the API speaks, as a base language tag
+type Locale string
+
+const (
+	English    Locale = "en"
+	Vietnamese Locale = "vi"
+	// Default is the locale of clients asking for no supported language
+	Default = English
+)
+
+// Locales are the supported locales, English is the language of the code and has no catalog
+var Locales = []Locale{English, Vietnamese}
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+// catalogs map the English messages to their translation, by locale
+var catalogs = map[Locale]map[string]string{}
+
+func init() {
+	for _, locale := range Locales {
+		data, err := catalogFiles.ReadFile("locales/" + string(locale) + ".json")
+		if err != nil {
+			continue
+		}
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: invalid %s catalog: %v", locale, err))
+		}
+		catalogs[locale] = catalog
+	}
+}
+
+type localeKey struct{}
+
+// LocaleKey is the c.Locals key of the request locale, usecases read it back from the request context
+var LocaleKey = localeKey{}
+
+// FromContext returns the locale of the request, Default outside of an HTTP request
+func FromContext(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(LocaleKey).(Locale); ok {
+		return locale
+	}
+	return Default
+}
+
+// T translates the message, a format of fmt when args are given
+func T(locale Locale, format string, args ...interface{}) string {
+	if translation, ok := catalogs[locale][format]; ok {
+		format = translation
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Parse picks the supported locale preferred by an Accept-Language header, e.g. "vi-VN,vi;q=0.9,en;q=0.8"
+func Parse(acceptLanguage string) Locale {
+	type preference struct {
+		locale  Locale
+		quality float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if locale := Locale(base); quality > 0 && slices.Contains(Locales, locale) {
+			preferences = append(preferences, preference{locale, quality})
+		}
+	}
+	if len(preferences) == 0 {
+		return Default
+	}
+	// Stable: the first of the languages with the same quality wins
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	return preferences[0].locale
+}
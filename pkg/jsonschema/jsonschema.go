@@ -0,0 +1,290 @@
+// Package jsonschema validates JSON documents against a subset of JSON Schema (draft 2020-12)
+// Supported keywords: type, properties, required, additionalProperties, items, enum,
+// minimum, maximum, exclusiveMinimum, minLength, maxLength, pattern, minItems, maxItems, uniqueItems
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ValidationError is one violation, Path uses JSON pointer-like notation (e.g. fees[1].type)
+// Keyword is the schema keyword failing (e.g. minimum) and Param its value in the schema (e.g. 0),
+// both are empty when the document isn't JSON
+type ValidationError struct {
+	Path    string `json:"path"`
+	Keyword string `json:"keyword,omitempty"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors is returned by Validate when the document doesn't match the schema
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Compile parses a schema document and compiles its patterns
+func Compile(document []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(document, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// MustCompile is like Compile but panics, for schemas embedded in the binary
+func MustCompile(document []byte) *Schema {
+	schema, err := Compile(document)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	for _, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// Validate checks a JSON document, returns ValidationErrors when it doesn't match
+func (s *Schema) Validate(document []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return ValidationErrors{{Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var errs ValidationErrors
+	s.validate("", value, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *ValidationErrors) {
+	fail := func(keyword string, param interface{}, format string) {
+		violation := ValidationError{Path: path, Keyword: keyword, Message: format}
+		if param != nil {
+			violation.Param = fmt.Sprint(param)
+			violation.Message = fmt.Sprintf(format, param)
+		}
+		*errs = append(*errs, violation)
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		fail("type", s.Type, "must be of type %s")
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("enum", formatEnum(s.Enum), "must be one of %s")
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		number, _ := v.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			fail("minimum", *s.Minimum, "must be >= %v")
+		}
+		if s.ExclusiveMinimum != nil && number <= *s.ExclusiveMinimum {
+			fail("exclusiveMinimum", *s.ExclusiveMinimum, "must be > %v")
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			fail("maximum", *s.Maximum, "must be <= %v")
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("minLength", *s.MinLength, "must be at least %d characters")
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("maxLength", *s.MaxLength, "must be at most %d characters")
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("pattern", s.Pattern, "must match pattern %s")
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("minItems", *s.MinItems, "must have at least %d items")
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("maxItems", *s.MaxItems, "must have at most %d items")
+		}
+		if s.UniqueItems && hasDuplicates(v) {
+			fail("uniqueItems", nil, "must not contain duplicate items")
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, exists := v[name]; !exists {
+				*errs = append(*errs, ValidationError{Path: joinPath(path, name), Keyword: "required", Message: "is required"})
+			}
+		}
+
+		// Sorted so the errors are stable between calls
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, known := s.Properties[name]
+			if !known {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, ValidationError{Path: joinPath(path, name), Keyword: "additionalProperties", Message: "is not allowed"})
+				}
+				continue
+			}
+			property.validate(joinPath(path, name), v[name], errs)
+		}
+	}
+}
+
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := number.Float64()
+		return err == nil && f == math.Trunc(f)
+	default:
+		return false
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if equal(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares decoded JSON values, numbers are compared by value
+func equal(a, b interface{}) bool {
+	if n, ok := b.(json.Number); ok {
+		f, _ := n.Float64()
+		b = f
+	}
+	if n, ok := a.(json.Number); ok {
+		f, _ := n.Float64()
+		a = f
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func hasDuplicates(items []interface{}) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if equal(items[i], items[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		encoded, _ := json.Marshal(value)
+		values = append(values, string(encoded))
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,326 @@
+// Package openapi builds an OpenAPI 3 document from the routes registered on a Fiber app and the Go types
+// of their requests and responses. Request constraints come from the validate tags of the types
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Version is the OpenAPI version of the documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI document, limited to what the API uses
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers,omitempty"`
+	Paths      map[string]map[string]*Endpoint `json:"paths"`
+	Components Components                      `json:"components"`
+	Security   []map[string][]string           `json:"security,omitempty"`
+	Tags       []Tag                           `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// Endpoint is the OpenAPI operation of a method on a path
+type Endpoint struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation documents a route, it is matched with the registered route by method and path
+type Operation struct {
+	// Method and Path are those of the route registration, e.g. GET and /api/v1/products/:id
+	Method string
+	Path   string
+	Tag    string
+	// Summary is the first line of the operation, Description the details
+	Summary     string
+	Description string
+	// Public operations need no credentials
+	Public bool
+	// Query are structs whose query tags are the query parameters, Params the parameters read one by one
+	Query  []interface{}
+	Params []*Parameter
+	// Body is the JSON request body
+	Body interface{}
+	// Data is the data of the response envelope, nil when the response has none
+	Data interface{}
+	// Status is the status of the response, 200 when 0
+	Status int
+	// ContentType replaces the JSON envelope of the response, e.g. text/csv for exports.
+	// Data is then the schema of the whole response, a string when nil
+	ContentType string
+}
+
+// QueryParam documents a query parameter read with c.Query
+func QueryParam(name, typ, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Spec is the description of an API and its operations
+type Spec struct {
+	Info       Info
+	Operations []Operation
+	// Envelope is the type of the JSON responses, its data field is replaced by the data of each operation
+	Envelope interface{}
+	// Security are the security schemes of the operations that aren't public, any of them is accepted
+	Security map[string]*SecurityScheme
+}
+
+// routeMethods are the methods documented, Fiber also registers HEAD for every GET
+var routeMethods = []string{fiber.MethodGet, fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete}
+
+// Drift lists the registered routes without an operation and the operations without a route
+func (s *Spec) Drift(routes []fiber.Route) (undocumented, unregistered []string) {
+	documented := make(map[string]bool, len(s.Operations))
+	for _, operation := range s.Operations {
+		documented[routeKey(operation.Method, operation.Path)] = true
+	}
+	registered := make(map[string]bool)
+	for _, route := range routes {
+		if !slices.Contains(routeMethods, route.Method) {
+			continue
+		}
+		key := routeKey(route.Method, route.Path)
+		if registered[key] {
+			continue
+		}
+		registered[key] = true
+		if !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	for _, operation := range s.Operations {
+		if key := routeKey(operation.Method, operation.Path); !registered[key] {
+			unregistered = append(unregistered, key)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unregistered)
+	return undocumented, unregistered
+}
+
+// Document builds the OpenAPI document of the registered routes that have an operation
+func (s *Spec) Document(routes []fiber.Route) *Document {
+	operations := make(map[string]Operation, len(s.Operations))
+	for _, operation := range s.Operations {
+		operations[routeKey(operation.Method, operation.Path)] = operation
+	}
+
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.Info,
+		Paths:   map[string]map[string]*Endpoint{},
+		Components: Components{
+			Schemas:         g.schemas,
+			SecuritySchemes: s.Security,
+			Responses: map[string]*Response{
+				"Error": {
+					Description: "Error, the code field tells its kind",
+					Content:     jsonContent(g.schema(reflect.TypeOf(s.Envelope))),
+				},
+			},
+		},
+	}
+	for name := range s.Security {
+		doc.Security = append(doc.Security, map[string][]string{name: {}})
+	}
+	sort.Slice(doc.Security, func(i, j int) bool {
+		return firstKey(doc.Security[i]) < firstKey(doc.Security[j])
+	})
+
+	tags := map[string]bool{}
+	done := map[string]bool{}
+	for _, route := range routes {
+		key := routeKey(route.Method, route.Path)
+		operation, ok := operations[key]
+		if !ok || done[key] || !slices.Contains(routeMethods, route.Method) {
+			continue
+		}
+		done[key] = true
+		path := pathParam.ReplaceAllString(normalizePath(route.Path), "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Endpoint{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = g.endpoint(operation, s.Envelope)
+		if operation.Tag != "" && !tags[operation.Tag] {
+			tags[operation.Tag] = true
+			doc.Tags = append(doc.Tags, Tag{Name: operation.Tag})
+		}
+	}
+	return doc
+}
+
+var (
+	// pathParam matches the parameters of Fiber paths, e.g. :id
+	pathParam = regexp.MustCompile(`:(\w+)`)
+	// separators split paths into the words of operation ids
+	separators = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+func (g *generator) endpoint(operation Operation, envelope interface{}) *Endpoint {
+	endpoint := &Endpoint{
+		OperationID: operationID(operation.Method, operation.Path),
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Responses:   map[string]*Response{"default": {Ref: "#/components/responses/Error"}},
+	}
+	if operation.Tag != "" {
+		endpoint.Tags = []string{operation.Tag}
+	}
+	if operation.Public {
+		endpoint.Security = &[]map[string][]string{}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(operation.Path, -1) {
+		name := match[1]
+		// Every path parameter of the API ending with id is a numeric id
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schema = &Schema{Type: "integer", Format: "int64"}
+		}
+		endpoint.Parameters = append(endpoint.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	for _, query := range operation.Query {
+		endpoint.Parameters = append(endpoint.Parameters, g.queryParameters(reflect.TypeOf(query))...)
+	}
+	endpoint.Parameters = append(endpoint.Parameters, operation.Params...)
+
+	if operation.Body != nil {
+		endpoint.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schema(reflect.TypeOf(operation.Body)))}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if operation.ContentType != "" {
+		schema := &Schema{Type: "string"}
+		if operation.Data != nil {
+			schema = g.schema(reflect.TypeOf(operation.Data))
+		}
+		success.Content = map[string]*MediaType{operation.ContentType: {Schema: schema}}
+	} else {
+		success.Content = jsonContent(g.envelope(envelope, operation.Data))
+	}
+	endpoint.Responses[strconv.Itoa(status)] = success
+	return endpoint
+}
+
+// envelope returns the schema of the response envelope with the data of the operation
+func (g *generator) envelope(envelope, data interface{}) *Schema {
+	schema := g.inlineStruct(reflect.TypeOf(envelope))
+	for name := range schema.Properties {
+		// Only the fields of successful responses are documented here, errors have their own response
+		if name != "success" && name != "message" && name != "data" && name != "request_id" {
+			delete(schema.Properties, name)
+		}
+	}
+	if data == nil {
+		delete(schema.Properties, "data")
+	} else {
+		schema.Properties["data"] = g.schema(reflect.TypeOf(data))
+	}
+	return schema
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{fiber.MIMEApplicationJSON: {Schema: schema}}
+}
+
+func routeKey(method, path string) string {
+	return method + " " + normalizePath(path)
+}
+
+// normalizePath drops the trailing slash Fiber keeps on the root route of groups
+func normalizePath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// operationID names the operation after its route, e.g. getApiV1ProductsId for GET /api/v1/products/:id
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range separators.Split(path, -1) {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func firstKey(m map[string][]string) string {
+	for key := range m {
+		return key
+	}
+	return ""
+}
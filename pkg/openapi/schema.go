@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator turns Go types into schemas, named structs become components referenced by name
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// schema returns the schema of the type, a reference for named structs
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.inlineStruct(t)
+		}
+		return g.ref(t)
+	default:
+		// interface{} holds any JSON value
+		return &Schema{}
+	}
+}
+
+// ref registers the named struct as a component and references it
+func (g *generator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := g.schemas[name]; taken {
+			// Structs of different packages may share a name, the package tells them apart, e.g. PaginationRequest
+			pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+			name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+		}
+		g.names[t] = name
+		// Registered before the fields are walked, types referencing themselves reuse it
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *g.inlineStruct(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// inlineStruct returns the object schema of the struct fields
+func (g *generator) inlineStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(schema, t)
+	return schema
+}
+
+// addFields adds the JSON fields of the struct to the object schema, embedded structs are flattened
+func (g *generator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(schema, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, required := g.constrain(g.schema(field.Type), field.Type, t, field.Tag.Get("validate"))
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// queryParameters returns the query parameters of the struct fields with a query tag
+func (g *generator) queryParameters(t reflect.Type) []*Parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var parameters []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema, required := g.constrain(g.schema(field.Type), field.Type, t, field.Tag.Get("validate"))
+		parameters = append(parameters, &Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return parameters
+}
+
+// constrain applies the validate rules of a field of the parent struct to its schema and tells whether the field
+// is required. Rules after dive apply to the items of the field
+func (g *generator) constrain(schema *Schema, t, parent reflect.Type, tag string) (*Schema, bool) {
+	if tag == "" {
+		return schema, false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	rules := strings.Split(tag, ",")
+	if i := slices.Index(rules, "dive"); i >= 0 {
+		if schema.Items != nil {
+			schema.Items, _ = g.constrain(schema.Items, t.Elem(), nil, strings.Join(rules[i+1:], ","))
+		}
+		rules = rules[:i]
+	}
+	if schema.Ref != "" {
+		// Keywords next to a reference are ignored, only the required flag is kept
+		return schema, slices.Contains(rules, "required")
+	}
+
+	required := false
+	for _, rule := range rules {
+		// Alternatives like hexcolor|len=0 can't be expressed as schema keywords
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			if name == "len" {
+				setBound(schema, t, "min", n)
+				setBound(schema, t, "max", n)
+			} else {
+				setBound(schema, t, name, n)
+			}
+		case "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil || !isNumber(t) {
+				continue
+			}
+			bound, exclusive := "min", name == "gt"
+			if strings.HasPrefix(name, "lt") {
+				bound, exclusive = "max", name == "lt"
+			}
+			setBound(schema, t, bound, n)
+			if bound == "min" {
+				schema.ExclusiveMinimum = exclusive
+			} else {
+				schema.ExclusiveMaximum = exclusive
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				if n, err := strconv.ParseFloat(value, 64); err == nil && isNumber(t) {
+					schema.Enum = append(schema.Enum, n)
+				} else {
+					schema.Enum = append(schema.Enum, value)
+				}
+			}
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "datetime":
+			schema.Description = "Formatted as " + param + " (Go layout)"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "hexcolor":
+			schema.Pattern = "^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$"
+		case "latitude":
+			setBound(schema, t, "min", -90)
+			setBound(schema, t, "max", 90)
+		case "longitude":
+			setBound(schema, t, "min", -180)
+			setBound(schema, t, "max", 180)
+		case "required_without":
+			schema.Description = "Required when " + fieldName(parent, param) + " is missing"
+		case "excluded_with":
+			schema.Description = "Can't be used together with " + fieldName(parent, param)
+		}
+	}
+	return schema, required
+}
+
+// fieldName returns the JSON (or query) name of the field of the struct, rule parameters name fields by their Go name
+func fieldName(t reflect.Type, name string) string {
+	if t == nil {
+		return name
+	}
+	field, ok := t.FieldByName(name)
+	if !ok {
+		return name
+	}
+	for _, tag := range []string{"json", "query"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return name
+}
+
+// setBound sets the minimum or maximum that fits the kind of the field: its length, item count or value
+func setBound(schema *Schema, t reflect.Type, bound string, n float64) {
+	count := int(n)
+	switch {
+	case t.Kind() == reflect.String:
+		if bound == "min" {
+			schema.MinLength = &count
+		} else {
+			schema.MaxLength = &count
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map:
+		if bound == "min" {
+			schema.MinItems = &count
+		} else {
+			schema.MaxItems = &count
+		}
+	case isNumber(t):
+		if bound == "min" {
+			schema.Minimum = &n
+		} else {
+			schema.Maximum = &n
+		}
+	}
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uiPage is the Swagger UI page of a document, its assets are loaded from the jsDelivr CDN
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: %[2]q, dom_id: "#swagger-ui", persistAuthorization: true });
+  </script>
+</body>
+</html>
+`
+
+// Handler serves the document as JSON, it is encoded once
+func Handler(doc *Document) (fiber.Handler, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi document: %w", err)
+	}
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return c.Send(body)
+	}, nil
+}
+
+// UIHandler serves Swagger UI showing the document at specURL
+func UIHandler(title, specURL string) fiber.Handler {
+	page := fmt.Sprintf(uiPage, html.EscapeString(title), specURL)
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page)
+	}
+}
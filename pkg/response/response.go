@@ -1,38 +1,97 @@
 package response
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"simple-template/pkg/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 // Response is the standard structure for API responses
 type Response struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	// Code is the machine-readable kind of the error, e.g. not_found
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Errors are the request fields failing validation
+	Errors []FieldError `json:"errors,omitempty"`
+	// RequestID is the id of the request, also in the X-Request-ID header
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// FieldError is the validation failure of one request field
+type FieldError struct {
+	// Field is the path of the field in the request, e.g. variants[1].name
+	Field string `json:"field"`
+	// Code is the failed rule, e.g. required or min, and Param its parameter, e.g. 8 for min=8
+	Code    string `json:"code"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// statusCodes are the error codes of the responses not given a more precise one
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:            "bad_request",
+	fiber.StatusUnauthorized:          "unauthorized",
+	fiber.StatusForbidden:             "forbidden",
+	fiber.StatusNotFound:              "not_found",
+	fiber.StatusMethodNotAllowed:      "method_not_allowed",
+	fiber.StatusConflict:              "conflict",
+	fiber.StatusRequestEntityTooLarge: "request_too_large",
+	fiber.StatusTooManyRequests:       "too_many_requests",
+	fiber.StatusInternalServerError:   "internal_error",
+}
+
+// Locale returns the locale of the request, read from Accept-Language when middleware.Locale didn't run
+func Locale(c *fiber.Ctx) i18n.Locale {
+	if locale, ok := c.Locals(i18n.LocaleKey).(i18n.Locale); ok {
+		return locale
+	}
+	return i18n.Parse(c.Get(fiber.HeaderAcceptLanguage))
+}
+
+// requestID returns the id given to the request by middleware.RequestID
+func requestID(c *fiber.Ctx) string {
+	return c.GetRespHeader(fiber.HeaderXRequestID)
 }
 
 // Success returns a successful response
 func Success(c *fiber.Ctx, data interface{}, message string) error {
 	return c.Status(fiber.StatusOK).JSON(Response{
-		Success: true,
-		Message: message,
-		Data:    data,
+		Success:   true,
+		Message:   i18n.T(Locale(c), message),
+		Data:      data,
+		RequestID: requestID(c),
 	})
 }
 
 // Created returns a response when creation is successful
 func Created(c *fiber.Ctx, data interface{}, message string) error {
 	return c.Status(fiber.StatusCreated).JSON(Response{
-		Success: true,
-		Message: message,
-		Data:    data,
+		Success:   true,
+		Message:   i18n.T(Locale(c), message),
+		Data:      data,
+		RequestID: requestID(c),
 	})
 }
 
-// Error returns an error response
+// Error returns an error response, its code is derived from the status
 func Error(c *fiber.Ctx, statusCode int, message string, err error) error {
+	code, ok := statusCodes[statusCode]
+	if !ok {
+		code = "error"
+	}
+	return ErrorWithCode(c, statusCode, code, message, err)
+}
+
+// ErrorWithCode returns an error response with the machine-readable code
+func ErrorWithCode(c *fiber.Ctx, statusCode int, code, message string, err error) error {
 	response := Response{
-		Success: false,
-		Message: message,
+		Success:   false,
+		Message:   i18n.T(Locale(c), message),
+		Code:      code,
+		RequestID: requestID(c),
 	}
 
 	if err != nil {
@@ -42,6 +101,17 @@ func Error(c *fiber.Ctx, statusCode int, message string, err error) error {
 	return c.Status(statusCode).JSON(response)
 }
 
+// ValidationFailed returns a 400 error listing the request fields failing validation
+func ValidationFailed(c *fiber.Ctx, message string, errors []FieldError) error {
+	return c.Status(fiber.StatusBadRequest).JSON(Response{
+		Success:   false,
+		Message:   i18n.T(Locale(c), message),
+		Code:      "validation_failed",
+		Errors:    errors,
+		RequestID: requestID(c),
+	})
+}
+
 // BadRequest returns a 400 error
 func BadRequest(c *fiber.Ctx, message string, err error) error {
 	return Error(c, fiber.StatusBadRequest, message, err)
@@ -52,6 +122,16 @@ func NotFound(c *fiber.Ctx, message string) error {
 	return Error(c, fiber.StatusNotFound, message, nil)
 }
 
+// Unauthorized returns a 401 error
+func Unauthorized(c *fiber.Ctx, message string) error {
+	return Error(c, fiber.StatusUnauthorized, message, nil)
+}
+
+// Forbidden returns a 403 error
+func Forbidden(c *fiber.Ctx, message string) error {
+	return Error(c, fiber.StatusForbidden, message, nil)
+}
+
 // InternalServerError returns a 500 error
 func InternalServerError(c *fiber.Ctx, message string, err error) error {
 	return Error(c, fiber.StatusInternalServerError, message, err)
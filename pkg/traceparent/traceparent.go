@@ -0,0 +1,127 @@
+// Package traceparent reads and writes the traceparent header of W3C Trace Context
+// (https://www.w3.org/TR/trace-context/), e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+package traceparent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Header is the HTTP header carrying the trace
+const Header = "traceparent"
+
+// FlagSampled is the trace flag telling the caller may have recorded the trace
+const FlagSampled byte = 0x01
+
+// TraceParent is a span of a trace, the caller of a request sends its own span as parent
+type TraceParent struct {
+	// TraceID is 32 lower case hex digits, SpanID 16, neither is only zeros
+	TraceID string
+	SpanID  string
+	Flags   byte
+}
+
+type contextKey struct{}
+
+// ContextKey is the c.Locals key of the span of the request, read back from the request context
+var ContextKey = contextKey{}
+
+// FromContext returns the span of the request, false outside of an HTTP request
+func FromContext(ctx context.Context) (TraceParent, bool) {
+	trace, ok := ctx.Value(ContextKey).(TraceParent)
+	return trace, ok
+}
+
+// Parse reads a traceparent header, false when it isn't valid.
+// Versions after 00 are read as 00, as the specification asks
+func Parse(header string) (TraceParent, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceParent{}, false
+	}
+	version := parts[0]
+	if !isHex(version, 2) || version == "ff" || version == "00" && len(parts) != 4 {
+		return TraceParent{}, false
+	}
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) || isZero(traceID) || isZero(spanID) {
+		return TraceParent{}, false
+	}
+	b, _ := hex.DecodeString(flags)
+	return TraceParent{TraceID: traceID, SpanID: spanID, Flags: b[0]}, true
+}
+
+// New starts a trace, sampled since no caller decided otherwise
+func New() TraceParent {
+	return TraceParent{TraceID: randomHex(16), SpanID: randomHex(8), Flags: FlagSampled}
+}
+
+// Child returns a new span of the trace, with the same flags
+func (t TraceParent) Child() TraceParent {
+	return TraceParent{TraceID: t.TraceID, SpanID: randomHex(8), Flags: t.Flags}
+}
+
+// Sampled tells whether the sampled flag is set
+func (t TraceParent) Sampled() bool {
+	return t.Flags&FlagSampled != 0
+}
+
+// String formats the span as a version 00 traceparent header
+func (t TraceParent) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", t.TraceID, t.SpanID, t.Flags)
+}
+
+// Transport sends the trace of the request context to the called services, as a child span of the request
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		trace, ok := FromContext(req.Context())
+		if !ok || req.Header.Get(Header) != "" {
+			return next.RoundTrip(req)
+		}
+		// Round trippers must not modify the request they are given
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, trace.Child().String())
+		return next.RoundTrip(req)
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// isHex reports whether s is n lower case hex digits, upper case isn't valid in traceparent
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	for {
+		// crypto/rand never fails on supported platforms
+		_, _ = rand.Read(b)
+		if id := hex.EncodeToString(b); !isZero(id) {
+			return id
+		}
+	}
+}